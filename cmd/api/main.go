@@ -22,21 +22,26 @@ const (
 
 func main() {
 	app := kingpin.New("api", "Flights path API")
-	configFile := app.
-		Flag("config", "path to config file").
+	configFiles := app.
+		Flag("config", "path to config file, http(s) URL to fetch it from, or - to read it from stdin; "+
+			"repeat to merge overlays into the base config").
 		Short('c').
 		Required().
 		PlaceHolder("./path/config.yaml").
-		String()
+		Strings()
 
 	app.Command(cmdServer, "runs the API server")
 	command := kingpin.MustParse(app.Parse(os.Args[1:]))
 
-	cfg := config.Read(*configFile)
+	cfg := config.ReadAll(*configFiles)
 
 	logger, undo := initLogger(cfg)
 	defer undo()
 
+	for _, warning := range cfg.Warnings() {
+		logger.Warn(warning)
+	}
+
 	router, err := initRouter(cfg, logger)
 	if err != nil {
 		log.Fatalln(err)
@@ -58,10 +63,8 @@ func initRouter(cfg *config.Config, logger *zap.Logger) (*chi.Mux, error) {
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(mw.Logger(logger))
-	r.Use(middleware.AllowContentType("application/json"))
 	r.Use(middleware.StripSlashes)
-	r.Use(middleware.SetHeader("Content-type", "application/json"))
-	r.Use(middleware.Recoverer)
+	r.Use(mw.Recoverer(logger, cfg.Api.Debug))
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.Api.Cors.AllowedOrigins,
 		AllowedMethods:   cfg.Api.Cors.AllowedMethods,
@@ -71,8 +74,7 @@ func initRouter(cfg *config.Config, logger *zap.Logger) (*chi.Mux, error) {
 		MaxAge:           cfg.Api.Cors.MaxAge,
 	}))
 
-	compressor := middleware.NewCompressor(5)
-	r.Use(compressor.Handler)
+	r.Use(mw.Compress(cfg.Api.Compression.GzipLevel(), cfg.Api.Compression.MinSize))
 
 	if err := routes.MakeRoutes(r, cfg, logger); err != nil {
 		return nil, err
@@ -97,5 +99,16 @@ func initLogger(cfg *config.Config) (*zap.Logger, func()) {
 }
 
 func runServer(r *chi.Mux, cfg *config.Config) error {
-	return http.ListenAndServe(fmt.Sprintf(":%d", cfg.Api.Port), r)
+	return newServer(r, cfg).ListenAndServe()
+}
+
+func newServer(h http.Handler, cfg *config.Config) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Api.Port),
+		Handler:           h,
+		ReadTimeout:       cfg.Api.Timeouts.Read,
+		ReadHeaderTimeout: cfg.Api.Timeouts.ReadHeader,
+		WriteTimeout:      cfg.Api.Timeouts.Write,
+		IdleTimeout:       cfg.Api.Timeouts.Idle,
+	}
 }
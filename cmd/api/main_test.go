@@ -0,0 +1,282 @@
+package main
+
+import (
+	"artemb/flights-path/pkg/api/controller"
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerReadHeaderTimeout(t *testing.T) {
+	cfg := &config.Config{Api: &config.Api{Timeouts: config.Timeouts{
+		Read:       time.Second,
+		ReadHeader: 100 * time.Millisecond,
+		Write:      time.Second,
+		Idle:       time.Second,
+	}}}
+	srv := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), cfg)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// Send the request line but never finish the headers.
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n")
+	assert.NoError(t, err)
+
+	started := time.Now()
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err, "the server should close the connection before the read deadline")
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
+
+func TestCorsPreflightMaxAge(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxAge     int
+		wantMaxAge string
+	}{
+		{
+			name:       "Configured max age",
+			maxAge:     300,
+			wantMaxAge: "300",
+		},
+		{
+			name: "Preflights not cached",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Api: &config.Api{
+					Cors: config.Cors{
+						AllowedOrigins: []string{"https://example.com"},
+						AllowedMethods: []string{http.MethodPost},
+						MaxAge:         test.maxAge,
+					},
+				},
+				Graph: config.Graph{Store: config.StoreMemory},
+			}
+			router, err := initRouter(cfg, zap.NewNop())
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodOptions, "/calculate", nil)
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, "https://example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, test.wantMaxAge, rr.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+}
+
+func TestAllowedContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{
+			name:        "JSON",
+			method:      http.MethodGet,
+			url:         "/calculate",
+			contentType: "application/json",
+			body:        `[["SFO", "ATL"]]`,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "Arrow lines",
+			method:      http.MethodGet,
+			url:         "/calculate",
+			contentType: "text/plain",
+			body:        "SFO->ATL\n",
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "Unsupported",
+			method:      http.MethodGet,
+			url:         "/calculate",
+			contentType: "application/xml",
+			body:        `<segments/>`,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Graph JSON",
+			method:      http.MethodPost,
+			url:         "/v2/graph/edges",
+			contentType: "application/json",
+			body:        `[["SFO", "ATL"]]`,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "Graph arrow lines",
+			method:      http.MethodPost,
+			url:         "/v2/graph/edges",
+			contentType: "text/plain",
+			body:        "SFO->ATL\n",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Export arrow lines",
+			method:      http.MethodPost,
+			url:         "/graph/export",
+			contentType: "text/plain",
+			body:        "SFO->ATL\n",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Api:   &config.Api{},
+				Graph: config.Graph{Store: config.StoreMemory},
+			}
+			router, err := initRouter(cfg, zap.NewNop())
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestRouteContentTypes(t *testing.T) {
+	cfg := &config.Config{
+		Api:   &config.Api{},
+		Graph: config.Graph{Store: config.StoreMemory, Stats: true},
+	}
+	router, err := initRouter(cfg, zap.NewNop())
+	assert.NoError(t, err)
+
+	// The cases share the router, so the segments added first are known to
+	// the path queries.
+	tests := []struct {
+		name            string
+		method          string
+		url             string
+		accept          string
+		body            string
+		wantCode        int
+		wantContentType string
+	}{
+		{
+			name:            "Add edges",
+			method:          http.MethodPost,
+			url:             "/graph/edges",
+			body:            `[["SFO", "ATL"]]`,
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Add edges with progress",
+			method:          http.MethodPost,
+			url:             "/v2/graph/edges",
+			accept:          controller.ContentTypeEventStream,
+			body:            `[["ATL", "EWR"]]`,
+			wantCode:        http.StatusOK,
+			wantContentType: controller.ContentTypeEventStream,
+		},
+		{
+			name:            "Calculate",
+			method:          http.MethodPost,
+			url:             "/calculate",
+			body:            `[["SFO", "ATL"]]`,
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Calculate error",
+			method:          http.MethodGet,
+			url:             "/v2/calculate",
+			body:            `[["SFO"]]`,
+			wantCode:        http.StatusBadRequest,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Export DOT",
+			method:          http.MethodPost,
+			url:             "/v1/graph/export",
+			body:            `[["SFO", "ATL"]]`,
+			wantCode:        http.StatusOK,
+			wantContentType: controller.ContentTypeDOT,
+		},
+		{
+			name:            "Export GraphML",
+			method:          http.MethodPost,
+			url:             "/graph/export",
+			accept:          controller.ContentTypeGraphML,
+			body:            `[["SFO", "ATL"]]`,
+			wantCode:        http.StatusOK,
+			wantContentType: controller.ContentTypeGraphML,
+		},
+		{
+			name:            "Path",
+			method:          http.MethodGet,
+			url:             "/graph/path?from=SFO&to=EWR",
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Stats",
+			method:          http.MethodGet,
+			url:             "/graph/stats",
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Liveness",
+			method:          http.MethodGet,
+			url:             "/healthz",
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "Readiness",
+			method:          http.MethodGet,
+			url:             "/readyz",
+			wantCode:        http.StatusOK,
+			wantContentType: response.ContentTypeJSON,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.wantCode, rr.Code)
+			assert.Equal(t, test.wantContentType, rr.Header().Get("Content-Type"))
+		})
+	}
+}
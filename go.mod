@@ -1,6 +1,6 @@
 module artemb/flights-path
 
-go 1.20
+go 1.23
 
 require (
 	github.com/alecthomas/kingpin/v2 v2.3.2
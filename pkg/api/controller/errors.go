@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"errors"
+	"net/http"
+)
+
+// errorCode is the public response of an internal error: its HTTP status, its
+// code in the structured errors of V2 and its message. The message is fixed,
+// so rewording the internal error doesn't change the API.
+type errorCode struct {
+	Err     error
+	Status  int
+	Code    string
+	Message string
+}
+
+// graphErrorCodes maps the errors of the graph package to their responses.
+// Errors are matched with errors.Is in order, so an error wrapping several of
+// them, like graph.ErrPathsConflict wrapping graph.ErrEdgeCreatesCycle, gets
+// the first one. Errors which aren't listed are reported by the handlers
+// themselves.
+var graphErrorCodes = []errorCode{
+	{Err: graph.ErrPathsConflict, Status: http.StatusConflict, Code: "PATHS_CONFLICT", Message: "paths conflict"},
+	{Err: graph.ErrEdgeCreatesCycle, Status: http.StatusBadRequest, Code: "CYCLE", Message: "edge would create a cycle"},
+	{Err: graph.ErrGraphHasCycle, Status: http.StatusBadRequest, Code: "CYCLE", Message: "segments contain a cycle"},
+	{Err: graph.ErrNegativeCycle, Status: http.StatusBadRequest, Code: "NEGATIVE_CYCLE", Message: "segments contain a negative cycle"},
+	{Err: graph.ErrVertexNotFound, Status: http.StatusNotFound, Code: "UNKNOWN_AIRPORT", Message: "unknown airport"},
+	{Err: graph.ErrEdgeNotFound, Status: http.StatusNotFound, Code: "UNKNOWN_SEGMENT", Message: "unknown segment"},
+	{Err: graph.ErrVertexAlreadyExists, Status: http.StatusConflict, Code: "DUPLICATE_AIRPORT", Message: "airport already exists"},
+	{Err: graph.ErrEdgeAlreadyExists, Status: http.StatusConflict, Code: "DUPLICATE_SEGMENT", Message: "segment already exists"},
+	{Err: graph.ErrVertexHasEdges, Status: http.StatusConflict, Code: "AIRPORT_HAS_SEGMENTS", Message: "airport has segments"},
+	{Err: graph.ErrTargetNotReachable, Status: http.StatusUnprocessableEntity, Code: "NO_PATH", Message: "can't find route"},
+	{Err: graph.ErrMaxHopsExceeded, Status: http.StatusUnprocessableEntity, Code: "NO_PATH", Message: "can't find route within the maximum number of hops"},
+	{Err: graph.ErrGraphNotConnected, Status: http.StatusUnprocessableEntity, Code: "NOT_CONNECTED", Message: "segments aren't connected"},
+	{Err: graph.ErrMaxCyclesExceeded, Status: http.StatusUnprocessableEntity, Code: "TOO_MANY_CYCLES", Message: "segments contain too many cycles"},
+	{Err: graph.ErrTooManyRequired, Status: http.StatusBadRequest, Code: "TOO_MANY_REQUIRED", Message: "too many required airports"},
+	{Err: graph.ErrLimitExceeded, Status: http.StatusUnprocessableEntity, Code: "LIMIT_EXCEEDED", Message: "graph limit exceeded"},
+	{Err: graph.ErrReadOnlyStore, Status: http.StatusForbidden, Code: "READ_ONLY", Message: "graph is read-only"},
+}
+
+// graphErrorCode returns the first graphErrorCodes entry matching err.
+func graphErrorCode(err error) (errorCode, bool) {
+	for _, code := range graphErrorCodes {
+		if errors.Is(err, code.Err) {
+			return code, true
+		}
+	}
+	return errorCode{}, false
+}
+
+// writeGraphError writes the response of the first graphErrorCodes entry
+// matching err and reports whether there was one.
+func writeGraphError(w http.ResponseWriter, r *http.Request, err error) bool {
+	code, ok := graphErrorCode(err)
+	if ok {
+		response.WriteJSONResponse(w, r, code.Status, response.ErrorResponse{Error: code.Message, Code: code.Code})
+	}
+	return ok
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteGraphError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantCode     int
+		wantResponse string
+	}{
+		{
+			name:         "Paths conflict",
+			err:          fmt.Errorf("%w: %w", graph.ErrPathsConflict, graph.ErrEdgeCreatesCycle),
+			wantCode:     http.StatusConflict,
+			wantResponse: `{"error":{"code":"PATHS_CONFLICT","message":"paths conflict"}}`,
+		},
+		{
+			name:         "Edge creates cycle",
+			err:          graph.ErrEdgeCreatesCycle,
+			wantCode:     http.StatusBadRequest,
+			wantResponse: `{"error":{"code":"CYCLE","message":"edge would create a cycle"}}`,
+		},
+		{
+			name:         "Graph has cycle",
+			err:          graph.ErrGraphHasCycle,
+			wantCode:     http.StatusBadRequest,
+			wantResponse: `{"error":{"code":"CYCLE","message":"segments contain a cycle"}}`,
+		},
+		{
+			name:         "Negative cycle",
+			err:          graph.ErrNegativeCycle,
+			wantCode:     http.StatusBadRequest,
+			wantResponse: `{"error":{"code":"NEGATIVE_CYCLE","message":"segments contain a negative cycle"}}`,
+		},
+		{
+			name:         "Vertex not found",
+			err:          graph.ErrVertexNotFound,
+			wantCode:     http.StatusNotFound,
+			wantResponse: `{"error":{"code":"UNKNOWN_AIRPORT","message":"unknown airport"}}`,
+		},
+		{
+			name:         "Edge not found",
+			err:          graph.ErrEdgeNotFound,
+			wantCode:     http.StatusNotFound,
+			wantResponse: `{"error":{"code":"UNKNOWN_SEGMENT","message":"unknown segment"}}`,
+		},
+		{
+			name:         "Vertex already exists",
+			err:          graph.ErrVertexAlreadyExists,
+			wantCode:     http.StatusConflict,
+			wantResponse: `{"error":{"code":"DUPLICATE_AIRPORT","message":"airport already exists"}}`,
+		},
+		{
+			name:         "Edge already exists",
+			err:          graph.ErrEdgeAlreadyExists,
+			wantCode:     http.StatusConflict,
+			wantResponse: `{"error":{"code":"DUPLICATE_SEGMENT","message":"segment already exists"}}`,
+		},
+		{
+			name:         "Vertex has edges",
+			err:          graph.ErrVertexHasEdges,
+			wantCode:     http.StatusConflict,
+			wantResponse: `{"error":{"code":"AIRPORT_HAS_SEGMENTS","message":"airport has segments"}}`,
+		},
+		{
+			name:         "Target not reachable",
+			err:          graph.ErrTargetNotReachable,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route"}}`,
+		},
+		{
+			name:         "Max hops exceeded",
+			err:          graph.ErrMaxHopsExceeded,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route within the maximum number of hops"}}`,
+		},
+		{
+			name:         "Graph not connected",
+			err:          graph.ErrGraphNotConnected,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantResponse: `{"error":{"code":"NOT_CONNECTED","message":"segments aren't connected"}}`,
+		},
+		{
+			name:         "Max cycles exceeded",
+			err:          graph.ErrMaxCyclesExceeded,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantResponse: `{"error":{"code":"TOO_MANY_CYCLES","message":"segments contain too many cycles"}}`,
+		},
+		{
+			name:         "Too many required",
+			err:          graph.ErrTooManyRequired,
+			wantCode:     http.StatusBadRequest,
+			wantResponse: `{"error":{"code":"TOO_MANY_REQUIRED","message":"too many required airports"}}`,
+		},
+		{
+			name:         "Limit exceeded",
+			err:          fmt.Errorf("adding SFO: %w", graph.ErrLimitExceeded),
+			wantCode:     http.StatusUnprocessableEntity,
+			wantResponse: `{"error":{"code":"LIMIT_EXCEEDED","message":"graph limit exceeded"}}`,
+		},
+		{
+			name:         "Read-only store",
+			err:          graph.ErrReadOnlyStore,
+			wantCode:     http.StatusForbidden,
+			wantResponse: `{"error":{"code":"READ_ONLY","message":"graph is read-only"}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := response.WithVersion(response.V2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.True(t, writeGraphError(w, r, test.err))
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/test", nil))
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+
+	// Every mapped error is covered above.
+	assert.Len(t, tests, len(graphErrorCodes))
+
+	w := httptest.NewRecorder()
+	assert.False(t, writeGraphError(w, httptest.NewRequest("GET", "http://example.com/test", nil), errors.New("wrong payload")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
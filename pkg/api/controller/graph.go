@@ -0,0 +1,527 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"artemb/flights-path/pkg/graph/draw"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ContentTypeDOT     = "text/vnd.graphviz"
+	ContentTypeGraphML = "application/graphml+xml"
+
+	// ContentTypeEventStream is accepted by clients of AddEdges which want
+	// its progress as server-sent events.
+	ContentTypeEventStream = "text/event-stream"
+
+	defaultProgressInterval = 1000
+
+	// maxPatternOrigins is the number of airports the "from" pattern of Path
+	// may match. Each of them takes a search through the whole graph while
+	// the read lock is held.
+	maxPatternOrigins = 100
+)
+
+var errPatternTooBroad = errors.New("airport pattern matches too many airports")
+
+type GraphController struct {
+	Logger *zap.Logger
+	// Routes is the persistent graph of flights, extended by AddEdges and
+	// queried by Path. It's shared between requests and guarded by mu.
+	Routes graph.Graph[string, string]
+	// Store is the store backing Routes, it's used to report its stats.
+	Store graph.Store[string, string]
+	// ProgressInterval is the number of segments between the progress events
+	// of AddEdges. It's 1000 by default.
+	ProgressInterval int
+
+	mu sync.RWMutex
+	// version counts the changes of Routes, it's part of the ETags of Path.
+	version uint64
+}
+
+type AddEdgesResponse struct {
+	// Added is the number of new segments, duplicates of known segments are
+	// not counted.
+	Added int `json:"added"`
+}
+
+// AddEdgesProgress is the data of the progress events of AddEdges.
+type AddEdgesProgress struct {
+	// Processed is the number of segments handled so far, including known
+	// segments.
+	Processed int `json:"processed"`
+	Total     int `json:"total"`
+}
+
+type PathResponse struct {
+	Path []string `json:"path"`
+}
+
+// unknownAirportError reports an airport, or a pattern matching no airport,
+// which isn't in the persistent graph.
+type unknownAirportError struct {
+	airport string
+}
+
+func (e *unknownAirportError) Error() string {
+	return fmt.Sprintf("unknown airport %q", e.airport)
+}
+
+func (e *unknownAirportError) Unwrap() error {
+	return graph.ErrVertexNotFound
+}
+
+// Export builds the graph of the submitted segments and renders it as GraphML
+// when requested by the Accept header, or as Graphviz DOT otherwise.
+func (c *GraphController) Export(w http.ResponseWriter, r *http.Request) {
+	segments, ok := readSegments(w, r, false, false)
+	if !ok {
+		return
+	}
+
+	g, _, err := buildGraph(segments, false, graph.PreventCycles())
+	if err != nil {
+		if !writeGraphError(w, r, err) {
+			response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := ContentTypeDOT
+	if strings.Contains(r.Header.Get("Accept"), ContentTypeGraphML) {
+		contentType = ContentTypeGraphML
+		err = draw.GraphML(g, &buf)
+	} else {
+		err = draw.DOT(g, &buf)
+	}
+	if err != nil {
+		response.WriteJSONInternalServerError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(w, r, http.StatusOK, contentType, buf.Bytes())
+}
+
+// AddEdges adds the submitted segments to the persistent graph. Segments which
+// are already known are ignored. Lone airports are rejected, since the
+// persistent graph only holds airports with flights.
+//
+// Clients accepting text/event-stream get the progress of large batches as
+// server-sent events instead, see streamAddEdges.
+func (c *GraphController) AddEdges(w http.ResponseWriter, r *http.Request) {
+	segments, ok := readSegments(w, r, false, false)
+	if !ok {
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), ContentTypeEventStream) {
+		c.streamAddEdges(w, segments)
+		return
+	}
+
+	res, err := c.addSegments(segments, nil)
+	if err != nil {
+		if !writeGraphError(w, r, err) {
+			response.WriteJSONInternalServerError(w, r, err)
+		}
+		return
+	}
+
+	response.WriteJSONResponse(w, r, http.StatusOK, res)
+}
+
+// streamAddEdges adds the segments like AddEdges and streams its progress as
+// server-sent events. A "progress" event is sent every ProgressInterval
+// segments and once all are processed, followed by a "done" event holding the
+// AddEdgesResponse:
+//
+//	event: progress
+//	data: {"processed":1000,"total":2500}
+//
+//	event: done
+//	data: {"added":2400}
+//
+// The status is sent with the first event, so a failure is reported by an
+// "error" event with a structured error instead.
+func (c *GraphController) streamAddEdges(w http.ResponseWriter, segments []segment) {
+	w.Header().Set("Content-Type", ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	res, err := c.addSegments(segments, func(processed, total int) {
+		c.writeEvent(w, "progress", AddEdgesProgress{Processed: processed, Total: total})
+	})
+	if err != nil {
+		code, ok := graphErrorCode(err)
+		if !ok {
+			if c.Logger != nil {
+				c.Logger.Error("internal error", zap.Error(err))
+			}
+			code = errorCode{Code: "INTERNAL_SERVER_ERROR", Message: response.MsgInternalServerError}
+		}
+		c.writeEvent(w, "error", response.StructuredError{Code: code.Code, Message: code.Message})
+		return
+	}
+
+	c.writeEvent(w, "done", res)
+}
+
+// writeEvent writes a server-sent event with the data as JSON and flushes it
+// to the client.
+func (c *GraphController) writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err == nil {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	}
+	if err == nil {
+		err = http.NewResponseController(w).Flush()
+	}
+	if err != nil && !errors.Is(err, http.ErrNotSupported) && c.Logger != nil {
+		c.Logger.Error("can't write event", zap.String("event", event), zap.Error(err))
+	}
+}
+
+// addSegments adds the segments to the persistent graph in chunks of
+// ProgressInterval segments. The write lock is held for one chunk at a time,
+// so path queries aren't blocked by a large batch. A failure removes the
+// segments and airports added by the earlier chunks again, so the batch is
+// added completely or not at all.
+//
+// If progress isn't nil, it's called without the lock after each chunk. It
+// gets the number of processed segments rather than added ones: known
+// segments count as well, so it reaches the total. The
+// number of added segments is returned once all are processed.
+func (c *GraphController) addSegments(segments []segment, progress func(processed, total int)) (AddEdgesResponse, error) {
+	interval := c.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	var batch addedSegments
+	for start := 0; start < len(segments); start += interval {
+		end := min(start+interval, len(segments))
+		if err := c.addChunk(segments[start:end], &batch); err != nil {
+			c.removeBatch(batch)
+			return AddEdgesResponse{}, err
+		}
+
+		if progress != nil {
+			progress(end, len(segments))
+		}
+	}
+
+	return AddEdgesResponse{Added: len(batch.edges)}, nil
+}
+
+// addedSegments records the changes of a batch of AddEdges, so they can be
+// undone if it fails.
+type addedSegments struct {
+	edges [][2]string
+	// airports are created for the edges.
+	airports []string
+}
+
+// addChunk adds the segments under the write lock and records the changes in
+// the batch.
+func (c *GraphController) addChunk(segments []segment, batch *addedSegments) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, segment := range segments {
+		var missing []string
+		for _, airport := range []string{segment.Source, segment.Target} {
+			if _, err := c.Routes.Vertex(airport); errors.Is(err, graph.ErrVertexNotFound) {
+				missing = append(missing, airport)
+			}
+		}
+
+		err := c.Routes.AddEdge(segment.Source, segment.Target)
+		switch {
+		case err == nil:
+			batch.edges = append(batch.edges, [2]string{segment.Source, segment.Target})
+			batch.airports = append(batch.airports, missing...)
+			c.version++
+		case !errors.Is(err, graph.ErrEdgeAlreadyExists):
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeBatch removes the segments and airports added by a failed batch, the
+// latest first. Airports which got segments of another batch in the meantime
+// are kept.
+func (c *GraphController) removeBatch(batch addedSegments) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := len(batch.edges) - 1; i >= 0; i-- {
+		edge := batch.edges[i]
+		if err := c.Routes.RemoveEdge(edge[0], edge[1]); err != nil && c.Logger != nil {
+			c.Logger.Error("can't remove segment of failed batch", zap.Strings("segment", edge[:]), zap.Error(err))
+		}
+	}
+
+	for i := len(batch.airports) - 1; i >= 0; i-- {
+		err := c.Routes.RemoveVertex(batch.airports[i])
+		switch {
+		case err == nil, errors.Is(err, graph.ErrVertexHasEdges), errors.Is(err, graph.ErrVertexNotFound):
+		default:
+			if c.Logger != nil {
+				c.Logger.Error("can't remove airport of failed batch", zap.String("airport", batch.airports[i]), zap.Error(err))
+			}
+		}
+	}
+
+	if len(batch.edges) > 0 || len(batch.airports) > 0 {
+		c.version++
+	}
+}
+
+// Path returns the route with the fewest connections between the airports
+// given by the "from" and "to" query parameters in the persistent graph.
+//
+// Both parameters accept glob patterns like "E*" or "S?O", in which case the
+// best route between any of the matching airports is returned. Ties are broken
+// alphabetically by the destination and then the origin.
+//
+// Routes are sent with an ETag derived from the version of the graph and the
+// query, so clients polling with If-None-Match get 304 Not Modified until the
+// graph changes. ETags don't survive a restart of the server, see etagEpoch.
+func (c *GraphController) Path(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "from and to are required"})
+		return
+	}
+
+	maxHops, err := readMaxHops(r)
+	if err != nil {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.mu.RLock()
+	etag := pathETag(c.version, r)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		c.mu.RUnlock()
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	route, err := c.bestPath(from, to, maxHops)
+	c.mu.RUnlock()
+
+	// V1 kept answering 400 with its own messages for a missing route, the
+	// mapped 422 responses are for V2 only.
+	v1 := response.Version(r.Context()) < response.V2
+
+	var unknownAirport *unknownAirportError
+	switch {
+	case err == nil:
+		w.Header().Set("ETag", etag)
+		response.WriteJSONResponse(w, r, http.StatusOK, PathResponse{Path: route})
+	case errors.Is(err, path.ErrBadPattern):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "invalid airport pattern"})
+	case errors.Is(err, errPatternTooBroad):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: errPatternTooBroad.Error(), Code: "PATTERN_TOO_BROAD"})
+	case errors.As(err, &unknownAirport):
+		response.WriteJSONResponse(w, r, http.StatusNotFound, response.ErrorResponse{
+			Error:   "unknown airport",
+			Code:    "UNKNOWN_AIRPORT",
+			Airport: unknownAirport.airport,
+		})
+	case v1 && errors.Is(err, graph.ErrTargetNotReachable):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "can't find route"})
+	case v1 && errors.Is(err, graph.ErrMaxHopsExceeded):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: maxHopsError(maxHops).Error()})
+	case writeGraphError(w, r, err):
+	default:
+		response.WriteJSONInternalServerError(w, r, err)
+	}
+}
+
+// etagEpoch tells the ETags of different processes apart. The graph version
+// starts over with each process, which may have loaded another network, so an
+// ETag of an earlier process mustn't match.
+var etagEpoch = rand.Uint64()
+
+// pathETag returns the ETag of the route for the query at the given version of
+// the graph. It's weak since routes of equal length may be picked in any order,
+// so the same query may get an equivalent rather than an identical route.
+func pathETag(version uint64, r *http.Request) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.URL.Query().Encode()))
+
+	return fmt.Sprintf(`W/"%016x-%d-%016x"`, etagEpoch, version, h.Sum64())
+}
+
+// etagMatches reports whether the If-None-Match header lists the ETag, using
+// the weak comparison of RFC 9110. "*" isn't supported, as it's checked before
+// knowing whether there's a route at all.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bestPath returns the shortest path between any of the airports matching the
+// from and to patterns. The caller must hold the read lock.
+func (c *GraphController) bestPath(from, to string, maxHops int) ([]string, error) {
+	if !isPattern(from) && !isPattern(to) {
+		for _, airport := range []string{from, to} {
+			if _, err := c.Routes.Vertex(airport); errors.Is(err, graph.ErrVertexNotFound) {
+				return nil, &unknownAirportError{airport: airport}
+			}
+		}
+		return graph.ShortestPath(c.Routes, from, to, graph.MaxHops(maxHops))
+	}
+
+	adjacencyMap, err := c.Routes.AdjacencyMap()
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := matchAirports(adjacencyMap, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) > maxPatternOrigins {
+		return nil, errPatternTooBroad
+	}
+
+	targets, err := matchAirports(adjacencyMap, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single search from each origin finds its routes to all targets. The
+	// sources are sorted, so keeping the first of equally short routes to a
+	// target picks the first origin.
+	var best []string
+	for _, source := range sources {
+		previous := hopTree(adjacencyMap, source)
+		for _, target := range targets {
+			// A pattern matching both ends isn't a request to stay put.
+			if source == target {
+				continue
+			}
+			if _, ok := previous[target]; !ok {
+				continue
+			}
+
+			route := routeFromTree(previous, source, target)
+			if best == nil || len(route) < len(best) || len(route) == len(best) && target < best[len(best)-1] {
+				best = route
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, graph.ErrTargetNotReachable
+	}
+	if maxHops > 0 && len(best)-1 > maxHops {
+		return nil, graph.ErrMaxHopsExceeded
+	}
+
+	return best, nil
+}
+
+// hopTree runs a breadth-first search from the source and returns the
+// predecessor of each airport reached on a route with the fewest connections.
+// The source is its own predecessor.
+func hopTree(adjacencyMap map[string]map[string]graph.Edge[string], source string) map[string]string {
+	previous := map[string]string{source: source}
+	queue := []string{source}
+
+	for len(queue) > 0 {
+		airport := queue[0]
+		queue = queue[1:]
+
+		for next := range adjacencyMap[airport] {
+			if _, ok := previous[next]; ok {
+				continue
+			}
+			previous[next] = airport
+			queue = append(queue, next)
+		}
+	}
+
+	return previous
+}
+
+// routeFromTree follows the predecessors returned by hopTree from the target
+// back to the source.
+func routeFromTree(previous map[string]string, source, target string) []string {
+	route := []string{target}
+	for airport := target; airport != source; {
+		airport = previous[airport]
+		route = append(route, airport)
+	}
+	slices.Reverse(route)
+
+	return route
+}
+
+// matchAirports returns the airports of the adjacency map matching the pattern
+// in alphabetical order, or an unknownAirportError if none match.
+func matchAirports(adjacencyMap map[string]map[string]graph.Edge[string], pattern string) ([]string, error) {
+	var airports []string
+	for airport := range adjacencyMap {
+		matched, err := path.Match(pattern, airport)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			airports = append(airports, airport)
+		}
+	}
+
+	if len(airports) == 0 {
+		return nil, &unknownAirportError{airport: pattern}
+	}
+
+	sort.Strings(airports)
+
+	return airports, nil
+}
+
+func isPattern(airport string) bool {
+	return strings.ContainsAny(airport, "*?[")
+}
+
+// Stats reports the number of airports and connections in the persistent graph
+// and the type of its store.
+func (c *GraphController) Stats(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	stats, err := c.Store.Stats()
+	c.mu.RUnlock()
+
+	if err != nil {
+		response.WriteJSONInternalServerError(w, r, err)
+		return
+	}
+
+	response.WriteJSONResponse(w, r, http.StatusOK, stats)
+}
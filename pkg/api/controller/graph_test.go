@@ -0,0 +1,463 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphExport(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		route           string
+		wantContentType string
+		wantSnippet     string
+		wantCode        int
+	}{
+		{
+			name:            "DOT by default",
+			route:           `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantContentType: "text/vnd.graphviz",
+			wantSnippet:     "\t\"SFO\" -> \"ATL\";\n",
+			wantCode:        200,
+		},
+		{
+			name:            "DOT",
+			accept:          "text/vnd.graphviz",
+			route:           `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantContentType: "text/vnd.graphviz",
+			wantSnippet:     "strict digraph {\n",
+			wantCode:        200,
+		},
+		{
+			name:            "GraphML",
+			accept:          "application/graphml+xml",
+			route:           `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantContentType: "application/graphml+xml",
+			wantSnippet:     `<edge source="SFO" target="ATL"></edge>`,
+			wantCode:        200,
+		},
+		{
+			name:            "Cycling routes",
+			route:           `[["SFO", "ATL"], ["ATL", "SFO"]]`,
+			wantContentType: "application/json",
+			wantSnippet:     `{"error":"edge would create a cycle"}`,
+			wantCode:        400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := GraphController{}
+			req := httptest.NewRequest("POST", "http://example.com/graph/export", strings.NewReader(test.route))
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+			controller.Export(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantContentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), test.wantSnippet)
+		})
+	}
+}
+
+func TestGraphPathPattern(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	for _, segment := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "EWR"}, {"ATL", "ELP"}, {"SEA", "ATL"}} {
+		assert.NoError(t, routes.AddEdge(segment[0], segment[1]))
+	}
+	controller := GraphController{Routes: routes}
+
+	tests := []struct {
+		name         string
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Pattern matching one target",
+			query:        "from=SFO&to=O*",
+			wantResponse: `{"path":["SFO","DEN","ORD"]}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Pattern matching multiple targets",
+			query:        "from=SFO&to=E*",
+			wantResponse: `{"path":["SFO","ATL","ELP"]}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Pattern matching multiple sources",
+			query:        "from=S??&to=ELP",
+			wantResponse: `{"path":["SEA","ATL","ELP"]}`,
+			wantCode:     200,
+		},
+		{
+			name:         "No matching airport",
+			query:        "from=SFO&to=X*",
+			wantResponse: `{"error":"unknown airport"}`,
+			wantCode:     404,
+		},
+		{
+			name:         "No route to matching airports",
+			query:        "from=E*&to=S*",
+			wantResponse: `{"error":"can't find route"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Invalid pattern",
+			query:        "from=SFO&to=[E",
+			wantResponse: `{"error":"invalid airport pattern"}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/graph/path?"+test.query, nil)
+			w := httptest.NewRecorder()
+			controller.Path(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestGraphPathPatternTooBroad(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	for i := 0; i <= maxPatternOrigins; i++ {
+		assert.NoError(t, routes.AddEdge(fmt.Sprintf("A%03d", i), "EWR"))
+	}
+	controller := GraphController{Routes: routes}
+
+	tests := []struct {
+		name         string
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Too many origins",
+			query:        "from=A*&to=EWR",
+			wantResponse: `{"error":"airport pattern matches too many airports"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Many targets",
+			query:        "from=A000&to=*",
+			wantResponse: `{"path":["A000","EWR"]}`,
+			wantCode:     200,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/graph/path?"+test.query, nil)
+			w := httptest.NewRecorder()
+			controller.Path(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestGraphPathUnknownAirport(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	assert.NoError(t, routes.AddEdge("SFO", "ATL"))
+	controller := GraphController{Routes: routes}
+
+	tests := []struct {
+		name         string
+		version      int
+		query        string
+		wantResponse string
+	}{
+		{
+			name:         "v1 unknown target",
+			version:      response.V1,
+			query:        "from=SFO&to=LAX",
+			wantResponse: `{"error":"unknown airport"}`,
+		},
+		{
+			name:         "v2 unknown target",
+			version:      response.V2,
+			query:        "from=SFO&to=LAX",
+			wantResponse: `{"error":{"code":"UNKNOWN_AIRPORT","message":"unknown airport","airport":"LAX"}}`,
+		},
+		{
+			name:         "v2 unknown source",
+			version:      response.V2,
+			query:        "from=JFK&to=LAX",
+			wantResponse: `{"error":{"code":"UNKNOWN_AIRPORT","message":"unknown airport","airport":"JFK"}}`,
+		},
+		{
+			name:         "v2 pattern matching no airport",
+			version:      response.V2,
+			query:        "from=SFO&to=X*",
+			wantResponse: `{"error":{"code":"UNKNOWN_AIRPORT","message":"unknown airport","airport":"X*"}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Path))
+			req := httptest.NewRequest("GET", "http://example.com/graph/path?"+test.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestGraphPathMaxHops(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	for _, segment := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "EWR"}} {
+		assert.NoError(t, routes.AddEdge(segment[0], segment[1]))
+	}
+	controller := GraphController{Routes: routes}
+
+	tests := []struct {
+		name         string
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Shorter valid path",
+			query:        "from=SFO&to=EWR&maxHops=2",
+			wantResponse: `{"path":["SFO","ATL","EWR"]}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Only path exceeds the limit",
+			query:        "from=SFO&to=ORD&maxHops=1",
+			wantResponse: `{"error":"can't find route within 1 hops"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Pattern exceeding the limit",
+			query:        "from=SFO&to=O*&maxHops=1",
+			wantResponse: `{"error":"can't find route within 1 hops"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Invalid limit",
+			query:        "from=SFO&to=ORD&maxHops=0",
+			wantResponse: `{"error":"invalid maxHops parameter \"0\""}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/graph/path?"+test.query, nil)
+			w := httptest.NewRecorder()
+			controller.Path(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestGraphPathNoRoute(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	for _, segment := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}} {
+		assert.NoError(t, routes.AddEdge(segment[0], segment[1]))
+	}
+	controller := GraphController{Routes: routes}
+
+	tests := []struct {
+		name         string
+		version      int
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "v1 not reachable",
+			version:      response.V1,
+			query:        "from=EWR&to=SFO",
+			wantResponse: `{"error":"can't find route"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "v1 max hops exceeded",
+			version:      response.V1,
+			query:        "from=SFO&to=EWR&maxHops=1",
+			wantResponse: `{"error":"can't find route within 1 hops"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "v2 not reachable",
+			version:      response.V2,
+			query:        "from=EWR&to=SFO",
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route"}}`,
+			wantCode:     422,
+		},
+		{
+			name:         "v2 max hops exceeded",
+			version:      response.V2,
+			query:        "from=SFO&to=EWR&maxHops=1",
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route within the maximum number of hops"}}`,
+			wantCode:     422,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Path))
+			req := httptest.NewRequest("GET", "http://example.com/graph/path?"+test.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestGraphAddEdgesProgress(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices(), graph.PreventCycles())
+	controller := GraphController{Routes: routes, ProgressInterval: 2}
+
+	addEdges := func(segments string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "http://example.com/graph/edges", strings.NewReader(segments))
+		req.Header.Set("Accept", ContentTypeEventStream)
+		w := httptest.NewRecorder()
+		controller.AddEdges(w, req)
+		return w
+	}
+
+	w := addEdges(`[["SFO", "ATL"], ["ATL", "EWR"], ["SFO", "ATL"], ["ATL", "EWR"], ["EWR", "IND"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ContentTypeEventStream, w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "event: progress\ndata: {\"processed\":2,\"total\":5}\n\n"+
+		"event: progress\ndata: {\"processed\":4,\"total\":5}\n\n"+
+		"event: progress\ndata: {\"processed\":5,\"total\":5}\n\n"+
+		"event: done\ndata: {\"added\":3}\n\n", w.Body.String())
+
+	// Failures after the first event are reported as an event.
+	w = addEdges(`[["IND", "ORD"], ["ORD", "LAX"], ["LAX", "SFO"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "event: progress\ndata: {\"processed\":2,\"total\":3}\n\n"+
+		"event: error\ndata: {\"code\":\"CYCLE\",\"message\":\"edge would create a cycle\"}\n\n", w.Body.String())
+
+	// The failed batch is removed completely.
+	_, err := routes.Edge("IND", "ORD")
+	assert.ErrorIs(t, err, graph.ErrEdgeNotFound)
+	for _, airport := range []string{"ORD", "LAX"} {
+		_, err = routes.Vertex(airport)
+		assert.ErrorIs(t, err, graph.ErrVertexNotFound)
+	}
+
+	// Other clients get the plain response.
+	w = httptest.NewRecorder()
+	controller.AddEdges(w, httptest.NewRequest("POST", "http://example.com/graph/edges", strings.NewReader(`[["IND", "ORD"], ["EWR", "SFO"]]`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"edge would create a cycle"}`+"\n", w.Body.String())
+
+	// Lone airports are only accepted by the search.
+	w = httptest.NewRecorder()
+	controller.AddEdges(w, httptest.NewRequest("POST", "http://example.com/graph/edges", strings.NewReader(`[["JFK"]]`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"each segment must have exactly two airports"}`+"\n", w.Body.String())
+
+	order, err := routes.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, order)
+}
+
+func TestGraphAddSegmentsUnlocked(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	controller := GraphController{Routes: routes, ProgressInterval: 2}
+
+	var reports [][2]int
+	res, err := controller.addSegments([]segment{
+		{Source: "SFO", Target: "ATL"}, {Source: "ATL", Target: "EWR"}, {Source: "SFO", Target: "ATL"},
+	}, func(processed, total int) {
+		// Path queries can run between the chunks.
+		assert.True(t, controller.mu.TryLock())
+		controller.mu.Unlock()
+		reports = append(reports, [2]int{processed, total})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, AddEdgesResponse{Added: 2}, res)
+	assert.Equal(t, [][2]int{{2, 3}, {3, 3}}, reports)
+}
+
+func TestGraphPathETag(t *testing.T) {
+	routes := graph.New(graph.StringHash, graph.Directed(), graph.AutoCreateVertices())
+	assert.NoError(t, routes.AddEdge("SFO", "ATL"))
+	controller := GraphController{Routes: routes}
+
+	path := func(query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://example.com/graph/path?"+query, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		controller.Path(w, req)
+		return w
+	}
+
+	w := path("from=SFO&to=ATL", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// The order of the parameters doesn't matter.
+	w = path("to=ATL&from=SFO", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+
+	w = path("from=SFO&to=ATL", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = path("from=SFO&to=ATL&maxHops=1", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// Errors aren't cached.
+	w = path("from=SFO&to=EWR", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	// A change of the graph invalidates the ETag, known segments don't.
+	add := func(segments string) {
+		w := httptest.NewRecorder()
+		controller.AddEdges(w, httptest.NewRequest("POST", "http://example.com/graph/edges", strings.NewReader(segments)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	add(`[["SFO", "ATL"]]`)
+	w = path("from=SFO&to=ATL", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	add(`[["ATL", "EWR"]]`)
+	w = path("from=SFO&to=ATL", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"path":["SFO","ATL"]}`+"\n", w.Body.String())
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// ETags of an earlier process don't match, even at the same version of
+	// the graph.
+	etag = w.Header().Get("ETag")
+	epoch := etagEpoch
+	defer func() {
+		etagEpoch = epoch
+	}()
+	etagEpoch++
+
+	w = path("from=SFO&to=ATL", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
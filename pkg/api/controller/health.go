@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"context"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const pingTimeout = 2 * time.Second
+
+type HealthController struct {
+	Logger *zap.Logger
+	// Store is the store of the persistent graph. If it implements
+	// graph.Pinger, readiness depends on it being reachable.
+	Store graph.Store[string, string]
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// Live reports that the process is up, it doesn't check any dependencies.
+func (c *HealthController) Live(w http.ResponseWriter, r *http.Request) {
+	response.WriteJSONResponse(w, r, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Ready reports whether the service can handle requests, which is the case if
+// the store of the persistent graph is reachable. Stores which don't implement
+// graph.Pinger are always considered reachable.
+func (c *HealthController) Ready(w http.ResponseWriter, r *http.Request) {
+	if pinger, ok := c.Store.(graph.Pinger); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+		defer cancel()
+
+		if err := pinger.Ping(ctx); err != nil {
+			if c.Logger != nil {
+				c.Logger.Warn("store unreachable", zap.Error(err))
+			}
+			response.WriteJSONResponse(w, r, http.StatusServiceUnavailable, response.ErrorResponse{Error: "store unreachable"})
+			return
+		}
+	}
+
+	response.WriteJSONResponse(w, r, http.StatusOK, HealthResponse{Status: "ok"})
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/graph"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+// unreachableStore is a store whose backend is down.
+type unreachableStore struct {
+	graph.Store[string, string]
+}
+
+func (s *unreachableStore) Ping(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealthReady(t *testing.T) {
+	tests := []struct {
+		name         string
+		store        graph.Store[string, string]
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Memory store",
+			store:        graph.NewMemoryStore[string, string](),
+			wantResponse: `{"status":"ok"}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Unreachable store",
+			store:        &unreachableStore{Store: graph.NewMemoryStore[string, string]()},
+			wantResponse: `{"error":"store unreachable"}`,
+			wantCode:     503,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := HealthController{Store: test.store}
+			w := httptest.NewRecorder()
+			controller.Ready(w, httptest.NewRequest("GET", "http://example.com/readyz", nil))
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestHealthLive(t *testing.T) {
+	controller := HealthController{Store: &unreachableStore{}}
+	w := httptest.NewRecorder()
+	controller.Live(w, httptest.NewRequest("GET", "http://example.com/healthz", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"status":"ok"}`+"\n", w.Body.String())
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// readMaxHops reads the optional "maxHops" query parameter, which limits the
+// number of connections of a route. 0 is returned if it's missing.
+func readMaxHops(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("maxHops")
+	if value == "" {
+		return 0, nil
+	}
+
+	maxHops, err := strconv.Atoi(value)
+	if err != nil || maxHops <= 0 {
+		return 0, fmt.Errorf("invalid maxHops parameter %q", value)
+	}
+
+	return maxHops, nil
+}
+
+// readBool reads an optional boolean query parameter. The second result reports
+// whether the parameter was given at all.
+func readBool(r *http.Request, name string) (bool, bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false, fmt.Errorf("invalid %s parameter %q", name, value)
+	}
+
+	return b, true, nil
+}
+
+// readInclude reads the optional "include" query parameter, a comma-separated
+// list of the paths to return: "short" for the route with the fewest
+// connections and "long" for the longest route. Only the long route is
+// returned if it's missing.
+func readInclude(r *http.Request) (short bool, long bool, err error) {
+	value := r.URL.Query().Get("include")
+	if value == "" {
+		return false, true, nil
+	}
+
+	for _, path := range strings.Split(value, ",") {
+		switch strings.TrimSpace(path) {
+		case "short":
+			short = true
+		case "long":
+			long = true
+		default:
+			return false, false, fmt.Errorf("invalid include parameter %q", value)
+		}
+	}
+
+	return short, long, nil
+}
+
+func maxHopsError(maxHops int) error {
+	return fmt.Errorf("%w within %d hops", errNoPath, maxHops)
+}
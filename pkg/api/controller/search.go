@@ -2,125 +2,617 @@ package controller
 
 import (
 	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/cache"
 	"artemb/flights-path/pkg/graph"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
-	"io"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const defaultSearchTimeout = 10 * time.Second
+
+// errNoPath is returned when valid segments don't make a route, which is
+// reported as NO_PATH rather than as a bad payload.
+var errNoPath = errors.New("can't find route")
+
+// errNotSingleChain is returned in fast chain mode for segments which don't
+// form a single chain.
+var errNotSingleChain = errors.New("segments don't form a single chain")
+
+const (
+	// strategyLongest finds the route with the most connections, it's the
+	// default.
+	strategyLongest = "longest"
+	// strategyLongestWeighted finds the route with the highest total weight of
+	// its segments, i.e. the most scenic one.
+	strategyLongestWeighted = "longest-weighted"
+)
+
 type SearchController struct {
 	Logger *zap.Logger
+	// Cache keeps responses of recent searches, keyed by the normalized
+	// segments. Caching is disabled when nil.
+	Cache *cache.LRU[string, SearchResponse]
+	// Network holds known connections between airports. When set, its edges
+	// are used to join the submitted segments into longer routes.
+	Network graph.Graph[string, string]
+	// Timeout limits the time spent on a search, it's 10 seconds if not set.
+	// Searches running out of time are answered with 504, searches cancelled
+	// by the client with 499.
+	Timeout time.Duration
+	// Strict rejects duplicated segments with 400 instead of ignoring them.
+	// It's the default for the "strict" query parameter.
+	Strict bool
+	// AllowEmpty answers an empty list of segments with 204 No Content
+	// instead of rejecting it with 400.
+	AllowEmpty bool
+	// RejectTies rejects segments with equally long routes from different
+	// start airports with 400 and the AMBIGUOUS_ROUTE code. By default, the
+	// route of the alphabetically first start airport is picked. It only
+	// applies to the default strategy.
+	RejectTies bool
+	// FastChain assumes the segments form a single chain, so the route is
+	// found by one traversal from its only start airport instead of one per
+	// segment. Segments which don't form a single chain are rejected with
+	// 400. It only applies to the default strategy.
+	FastChain bool
 }
 
 type SearchResponse struct {
 	ShortPath []string `json:"short_path"`
-	FullPath  []string `json:"full_path"`
+	// FullPath is the longest route through the segments. It's left out when
+	// the "include" query parameter doesn't list "long".
+	FullPath []string `json:"full_path,omitempty"`
+	// FewestHopsPath is the route with the fewest connections between the
+	// ends of FullPath. It's only reported when the "include" query parameter
+	// lists "short".
+	FewestHopsPath []string `json:"fewest_hops_path,omitempty"`
+	// SingleChain reports whether the segments form one clean itinerary. When
+	// false, the segments are disconnected or branching and FullPath is only
+	// the longest route found.
+	SingleChain bool `json:"single_chain"`
+	// Duplicates lists the repeated segments which were ignored. It's only
+	// reported when requested with the "reportDuplicates=true" query
+	// parameter.
+	Duplicates [][]string `json:"duplicates,omitempty"`
+	// Meta is only set when requested with the "meta=true" query parameter.
+	// It's never cached.
+	Meta *response.Meta `json:"meta,omitempty"`
+}
+
+// SearchResponseV2 is the SearchResponse of API v2, which names the paths
+// "shortest" and "route".
+type SearchResponseV2 struct {
+	Shortest    []string       `json:"shortest"`
+	Route       []string       `json:"route,omitempty"`
+	FewestHops  []string       `json:"fewest_hops,omitempty"`
+	SingleChain bool           `json:"single_chain"`
+	Duplicates  [][]string     `json:"duplicates,omitempty"`
+	Meta        *response.Meta `json:"meta,omitempty"`
+}
+
+// reversed returns the response for the return trip, with the paths from the
+// destination to the origin. The paths are copied, since they may be cached.
+func (res SearchResponse) reversed() SearchResponse {
+	res.ShortPath = reversePath(res.ShortPath)
+	res.FullPath = reversePath(res.FullPath)
+	res.FewestHopsPath = reversePath(res.FewestHopsPath)
+	return res
+}
+
+func reversePath(path []string) []string {
+	reversed := make([]string, len(path))
+	for i, airport := range path {
+		reversed[len(path)-1-i] = airport
+	}
+	return reversed
+}
+
+// versioned returns the response in the shape of the API version of the
+// request. The cache holds SearchResponse, so it serves all versions.
+func (res SearchResponse) versioned(r *http.Request) interface{} {
+	if response.Version(r.Context()) >= response.V2 {
+		return SearchResponseV2{
+			Shortest:    res.ShortPath,
+			Route:       res.FullPath,
+			FewestHops:  res.FewestHopsPath,
+			SingleChain: res.SingleChain,
+			Duplicates:  res.Duplicates,
+			Meta:        res.Meta,
+		}
+	}
+
+	return res
+}
+
+// searchOptions are the per-request settings of a search. They are part of the
+// cache key, since they change the result.
+type searchOptions struct {
+	strict   bool
+	maxHops  int
+	strategy string
+	// singleStart rejects segments with more than one possible start airport
+	// with an ambiguousStartError. It's set for API v2 only: v1 clients got
+	// the longest route for such segments before the check was added, so v1
+	// still picks the longest route, see Tie-break in the README.
+	singleStart bool
+	// isolated accepts lone airports like ["JFK"] in the segments, which are
+	// rejected otherwise, and reports a lone airport as a route of its own
+	// when the segments don't make a longer route.
+	isolated bool
+	// fewestHops also searches the route with the fewest connections between
+	// the ends of the longest route.
+	fewestHops bool
+}
+
+// ambiguousStartError reports segments which don't have a single origin, e.g.
+// because two itineraries were mixed up.
+type ambiguousStartError struct {
+	starts []string
+}
+
+func (e *ambiguousStartError) Error() string {
+	return fmt.Sprintf("segments have more than one start airport: %s", strings.Join(e.starts, ", "))
+}
+
+// ambiguousRouteError reports segments with equally long routes from several
+// start airports when ties are rejected.
+type ambiguousRouteError struct {
+	starts []string
+}
+
+func (e *ambiguousRouteError) Error() string {
+	return fmt.Sprintf("segments have equally long routes from %s", strings.Join(e.starts, ", "))
+}
+
+// DryRunResponse summarizes the graph of the segments for "dryRun=true"
+// searches, which validate the segments without searching a route.
+type DryRunResponse struct {
+	// Order is the number of airports.
+	Order int `json:"order"`
+	// Size is the number of distinct segments.
+	Size int `json:"size"`
+	// Sources are the airports without incoming segments, i.e. the possible
+	// origins, in alphabetical order.
+	Sources []string `json:"sources"`
+	// Sinks are the airports without outgoing segments, i.e. the possible
+	// destinations, in alphabetical order.
+	Sinks []string `json:"sinks"`
+	// Acyclic reports whether the segments don't loop. A search of segments
+	// with a loop is rejected.
+	Acyclic bool `json:"acyclic"`
+}
+
+type searchResult struct {
+	path        []string
+	fewestHops  []string
+	singleChain bool
+	duplicates  []segment
 }
 
 func (c *SearchController) Search(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	withMeta, _ := strconv.ParseBool(r.URL.Query().Get("meta"))
 
-	// TODO not using validator here, since it's simple structure
-	body, err := io.ReadAll(r.Body)
+	opts, err := c.readOptions(r)
 	if err != nil {
 		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
 		return
 	}
-	if len(body) == 0 {
-		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "empty payload"})
+
+	// Reversing, reporting duplicates and leaving out the long route don't
+	// change the search, so they're applied to the cached response instead
+	// of being part of the search options.
+	reverse, _, err := readBool(r, "reverse")
+	if err != nil {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+	reportDuplicates, _, err := readBool(r, "reportDuplicates")
+	if err != nil {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	var segments [][]string
-	err = json.Unmarshal(body, &segments)
+	includeShort, includeLong, err := readInclude(r)
 	if err != nil {
-		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "wrong payload"})
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
 		return
 	}
+	opts.fewestHops = includeShort
 
+	dryRun, _, err := readBool(r, "dryRun")
+	if err != nil {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	segments, ok := readSegments(w, r, c.AllowEmpty, opts.isolated)
+	if !ok {
+		return
+	}
 	if len(segments) == 0 {
-		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "wrong segments in payload"})
+		// Nothing to route, which is only accepted with AllowEmpty.
+		response.HandleNoContentResponse(w)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*10))
+	if dryRun {
+		c.dryRun(w, r, segments, opts)
+		return
+	}
+
+	start := time.Now()
+
+	var key string
+	if c.Cache != nil {
+		key = cacheKey(segments, opts)
+		if res, ok := c.Cache.Get(key); ok {
+			if c.Logger != nil {
+				stats := c.Cache.Stats()
+				c.Logger.Debug("search served from cache", zap.Uint64("hits", stats.Hits), zap.Uint64("misses", stats.Misses))
+			}
+			if reverse {
+				res = res.reversed()
+			}
+			if !reportDuplicates {
+				res.Duplicates = nil
+			}
+			if !includeLong {
+				res.FullPath = nil
+			}
+			if withMeta {
+				res.Meta = response.NewMeta(start)
+			}
+			response.WriteJSONResponse(w, r, http.StatusOK, res.versioned(r))
+			return
+		}
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultSearchTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
 	defer cancel()
 
-	result, err := c.calculate(ctx, segments)
-	if err != nil {
-		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	result, err := c.calculate(ctx, segments, opts)
+	var ambiguousStart *ambiguousStartError
+	var ambiguousRoute *ambiguousRouteError
+	switch {
+	case errors.Is(err, context.Canceled):
+		if c.Logger != nil {
+			c.Logger.Info("search cancelled by the client", zap.Duration("elapsed", time.Since(start)))
+		}
+		response.WriteJSONResponse(w, r, response.StatusClientClosedRequest, response.ErrorResponse{Error: "client closed request"})
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		if c.Logger != nil {
+			c.Logger.Warn("search timed out", zap.Duration("elapsed", time.Since(start)), zap.Duration("timeout", timeout))
+		}
+		response.WriteJSONResponse(w, r, http.StatusGatewayTimeout, response.ErrorResponse{Error: "search timed out"})
+		return
+	case errors.As(err, &ambiguousStart):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{
+			Error:  "segments have more than one start airport",
+			Code:   "AMBIGUOUS_START",
+			Starts: ambiguousStart.starts,
+		})
+		return
+	case errors.As(err, &ambiguousRoute):
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{
+			Error:  "segments have more than one longest route",
+			Code:   "AMBIGUOUS_ROUTE",
+			Starts: ambiguousRoute.starts,
+		})
+		return
+	case errors.Is(err, errNoPath):
+		// The payload is fine, the segments just don't make a route.
+		response.WriteJSONResponse(w, r, http.StatusUnprocessableEntity, response.ErrorResponse{Error: err.Error(), Code: "NO_PATH"})
+		return
+	case err != nil:
+		if !writeGraphError(w, r, err) {
+			response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		}
 		return
 	}
 
-	if len(result) == 0 {
-		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "can't find route"})
-		return
+	res := SearchResponse{
+		FullPath:       result.path,
+		ShortPath:      []string{result.path[0], result.path[len(result.path)-1]},
+		FewestHopsPath: result.fewestHops,
+		SingleChain:    result.singleChain,
+	}
+	if len(result.path) == 1 {
+		// The route of a lone airport starts and ends there.
+		res.ShortPath = result.path
+	}
+	for _, duplicate := range result.duplicates {
+		res.Duplicates = append(res.Duplicates, []string{duplicate.Source, duplicate.Target})
+	}
+	if c.Cache != nil {
+		c.Cache.Add(key, res)
+	}
+	if reverse {
+		res = res.reversed()
+	}
+	if !reportDuplicates {
+		res.Duplicates = nil
+	}
+	if !includeLong {
+		res.FullPath = nil
+	}
+	if withMeta {
+		res.Meta = response.NewMeta(start)
 	}
 
-	response.WriteJSONResponse(w, r, http.StatusOK, SearchResponse{FullPath: result, ShortPath: []string{result[0], result[len(result)-1]}})
+	response.WriteJSONResponse(w, r, http.StatusOK, res.versioned(r))
 }
 
-func (c *SearchController) calculate(ctx context.Context, segments [][]string) ([]string, error) {
-	sort.Slice(segments, func(i, j int) bool {
-		if segments[i][0] < segments[j][0] {
-			return true
-		}
-		if segments[i][0] > segments[j][0] {
-			return false
+// readOptions reads the search options from the query parameters, falling back
+// to the controller defaults.
+func (c *SearchController) readOptions(r *http.Request) (searchOptions, error) {
+	opts := searchOptions{
+		strict:      c.Strict,
+		strategy:    strategyLongest,
+		singleStart: response.Version(r.Context()) >= response.V2,
+	}
+
+	strict, ok, err := readBool(r, "strict")
+	if err != nil {
+		return opts, err
+	}
+	if ok {
+		opts.strict = strict
+	}
+
+	opts.isolated, _, err = readBool(r, "isolated")
+	if err != nil {
+		return opts, err
+	}
+
+	maxHops, err := readMaxHops(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.maxHops = maxHops
+
+	if value := r.URL.Query().Get("strategy"); value != "" {
+		if value != strategyLongest && value != strategyLongestWeighted {
+			return opts, fmt.Errorf("invalid strategy parameter %q", value)
 		}
-		return segments[i][1] < segments[j][1]
-	})
+		opts.strategy = value
+	}
 
-	var err error
-	g := graph.New(graph.StringHash, graph.Directed(), graph.PreventCycles())
-	for _, el := range segments {
-		source := el[0]
-		target := el[1]
+	return opts, nil
+}
 
-		_, err = g.Vertex(source)
-		if err != nil {
-			err = g.AddVertex(source)
-			if err != nil {
-				return nil, err
-			}
+// cacheKey hashes the segments independently of their order, so the same
+// flights submitted in a different order hit the same cache entry.
+func cacheKey(segments []segment, opts searchOptions) string {
+	normalized := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		el, _ := json.Marshal(segment)
+		normalized = append(normalized, string(el))
+	}
+	sort.Strings(normalized)
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%+v", opts)
+	for _, el := range normalized {
+		h.Write([]byte(el))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *SearchController) calculate(ctx context.Context, segments []segment, opts searchOptions) (*searchResult, error) {
+	g, duplicates, err := buildGraph(segments, opts.strict, graph.PreventCycles())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Network != nil {
+		if err = augmentGraph(g, c.Network); err != nil {
+			return nil, err
 		}
+	}
 
-		_, err = g.Vertex(target)
+	if opts.singleStart {
+		starts, err := g.Sources()
 		if err != nil {
-			err = g.AddVertex(target)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
+		if len(starts) > 1 {
+			sort.Strings(starts)
+			return nil, &ambiguousStartError{starts: starts}
+		}
+	}
+
+	// The chain check is shared by the fast chain mode and the response.
+	singleChain, start, _, err := graph.IsPath(g)
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = g.Edge(source, target)
+	var route []string
+	switch {
+	case opts.strategy == strategyLongestWeighted:
+		route, err = graph.LongestWeightedPath(g)
+	case c.FastChain:
+		route, err = chainVisit(ctx, g, singleChain, start)
+	default:
+		route, err = longestVisit(ctx, g, segments, c.RejectTies)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(route) == 1 && opts.isolated:
+		// A lone airport is reported as a route of its own.
+	case len(route) < 2:
+		return nil, errNoPath
+	}
+
+	if opts.maxHops > 0 && len(route)-1 > opts.maxHops {
+		return nil, maxHopsError(opts.maxHops)
+	}
+
+	result := &searchResult{path: route, singleChain: singleChain, duplicates: duplicates}
+	if opts.fewestHops {
+		result.fewestHops, err = fewestHops(g, route[0], route[len(route)-1])
 		if err != nil {
-			err = g.AddEdge(source, target)
-			if err != nil {
-				return nil, err
-			}
+			return nil, err
 		}
 	}
 
+	return result, nil
+}
+
+// fewestHops returns the route with the fewest connections from source to
+// target, regardless of the weights of the segments. It follows the tree of a
+// breadth-first search, which visits airports alphabetically so ties are
+// broken the same way between requests.
+func fewestHops(g graph.Graph[string, string], source, target string) ([]string, error) {
+	tree, err := graph.SpanningTree(g, source, true, graph.VisitOrder(func(a, b string) bool {
+		return a < b
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// The tree has a single path to each airport, so its shortest path is the
+	// one found by the breadth-first search.
+	return graph.ShortestPath(tree, source, target)
+}
+
+// dryRun answers with the summary of the graph of the segments instead of
+// searching a route. The graph isn't joined with the network, so the summary
+// describes the submitted segments only.
+func (c *SearchController) dryRun(w http.ResponseWriter, r *http.Request, segments []segment, opts searchOptions) {
+	// Cycles are reported rather than rejected.
+	g, _, err := buildGraph(segments, opts.strict)
+	if err != nil {
+		if !writeGraphError(w, r, err) {
+			response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	res, err := summarize(g)
+	if err != nil {
+		response.WriteJSONInternalServerError(w, r, err)
+		return
+	}
+
+	response.WriteJSONResponse(w, r, http.StatusOK, res)
+}
+
+func summarize(g graph.Graph[string, string]) (DryRunResponse, error) {
+	var res DryRunResponse
+	var err error
+
+	if res.Order, err = g.Order(); err != nil {
+		return res, err
+	}
+	if res.Size, err = g.Size(); err != nil {
+		return res, err
+	}
+	if res.Sources, err = g.Sources(); err != nil {
+		return res, err
+	}
+	if res.Sinks, err = g.Sinks(); err != nil {
+		return res, err
+	}
+	sort.Strings(res.Sources)
+	sort.Strings(res.Sinks)
+
+	_, err = graph.TopologicalSort(g)
+	switch {
+	case err == nil:
+		res.Acyclic = true
+	case !errors.Is(err, graph.ErrGraphHasCycle):
+		return res, err
+	}
+
+	return res, nil
+}
+
+// longestVisit traverses the graph from the source of each segment and returns
+// the longest of the visiting orders. The segments are sorted, so of equally
+// long orders the one of the alphabetically first start airport is returned,
+// unless rejectTies is set, in which case an ambiguousRouteError is returned.
+func longestVisit(ctx context.Context, g graph.Graph[string, string], segments []segment, rejectTies bool) ([]string, error) {
 	var dfs []string
+	// ties are the start airports of the orders as long as dfs.
+	var ties []string
 	for _, el := range segments {
-		start := el[0]
+		start := el.Source
+		if len(ties) > 0 && ties[len(ties)-1] == start {
+			// Segments of the same airport share the visiting order.
+			continue
+		}
 		var innerDfs []string
-		err = graph.DFS(g, start, func(value string) bool {
+		err := graph.DFS(g, start, func(value string) bool {
 			innerDfs = append(innerDfs, value)
-			return false
-		})
+			// Stop traversing once the search is cancelled or timed out.
+			return ctx.Err() != nil
+		}, graph.VisitOrder(func(a, b string) bool {
+			// Visiting airports alphabetically makes the chosen route
+			// stable between requests when there are ties.
+			return a < b
+		}))
 		if err != nil {
 			println(err)
 		}
-		if len(dfs) < len(innerDfs) {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		switch {
+		case len(dfs) < len(innerDfs):
 			dfs = innerDfs
+			ties = []string{start}
+		case len(dfs) == len(innerDfs):
+			ties = append(ties, start)
 		}
 	}
 
+	if rejectTies && len(ties) > 1 {
+		return nil, &ambiguousRouteError{starts: ties}
+	}
+
 	return dfs, nil
 }
+
+// chainVisit returns the route of a graph forming a single chain, found by one
+// traversal from its start airport. singleChain and start are the result of
+// graph.IsPath for g. errNotSingleChain is returned for any other graph, e.g.
+// one with several start airports or a branch.
+func chainVisit(ctx context.Context, g graph.Graph[string, string], singleChain bool, start string) ([]string, error) {
+	if !singleChain {
+		return nil, errNotSingleChain
+	}
+
+	var route []string
+	err := graph.DFS(g, start, func(value string) bool {
+		route = append(route, value)
+		// Stop traversing once the search is cancelled or timed out.
+		return ctx.Err() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
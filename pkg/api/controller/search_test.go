@@ -1,18 +1,27 @@
 package controller
 
 import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/cache"
+	"artemb/flights-path/pkg/graph"
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSearchController(t *testing.T) {
-	var err error
-
 	tests := []struct {
 		name      string
 		route     string
@@ -51,15 +60,14 @@ func TestSearchController(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			var segments [][]string
-			err = json.Unmarshal([]byte(test.route), &segments)
+			segments, err := parseSegments([]byte(test.route), false)
 			assert.NoError(t, err)
 
 			controller := SearchController{}
-			res, err := controller.calculate(context.Background(), segments)
+			res, err := controller.calculate(context.Background(), segments, searchOptions{})
 			if !test.wantErr {
 				assert.NoError(t, err)
-				assert.True(t, reflect.DeepEqual(res, test.wantRoute))
+				assert.True(t, reflect.DeepEqual(res.path, test.wantRoute))
 			} else {
 				assert.Error(t, err)
 			}
@@ -67,6 +75,171 @@ func TestSearchController(t *testing.T) {
 	}
 }
 
+func TestParseSegmentsWeight(t *testing.T) {
+	tests := []struct {
+		name       string
+		route      string
+		wantWeight float64
+		wantErr    error
+	}{
+		{
+			name:       "Number",
+			route:      `[["SFO", "ATL", 12.5]]`,
+			wantWeight: 12.5,
+		},
+		{
+			name:       "Numeric string",
+			route:      `[["SFO", "ATL", "12.50"]]`,
+			wantWeight: 12.5,
+		},
+		{
+			name:    "Non-numeric string",
+			route:   `[["SFO", "ATL", "12.50 USD"]]`,
+			wantErr: errInvalidWeight,
+		},
+		{
+			name:    "Malformed numeric string",
+			route:   `[["SFO", "ATL", "1.2.3"]]`,
+			wantErr: errInvalidWeight,
+		},
+		{
+			name:    "Third airport",
+			route:   `[["SFO", "ATL", "EWR"]]`,
+			wantErr: errSegmentAirports,
+		},
+		{
+			name:    "Negative numeric string",
+			route:   `[["SFO", "ATL", "-1"]]`,
+			wantErr: errSegmentWeight,
+		},
+		{
+			name:    "Boolean",
+			route:   `[["SFO", "ATL", true]]`,
+			wantErr: errSegmentWeight,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segments, err := parseSegments([]byte(test.route), false)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.Len(t, segments, 1) && assert.NotNil(t, segments[0].Weight) {
+				assert.Equal(t, test.wantWeight, *segments[0].Weight)
+			}
+		})
+	}
+}
+
+func TestParseSegmentsArrows(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantSegments [][]string
+		wantErr      string
+	}{
+		{
+			name:         "Arrow lines",
+			input:        "SFO->ATL\nATL->EWR\n",
+			wantSegments: [][]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+		},
+		{
+			name:         "Whitespace and blank lines",
+			input:        "  SFO -> ATL \r\n\n\tATL->  EWR\n   \nEWR ->IND",
+			wantSegments: [][]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}},
+		},
+		{
+			name: "Empty",
+		},
+		{
+			name:    "Missing arrow",
+			input:   "SFO->ATL\nATL EWR\n",
+			wantErr: "line 2: each line must be two airports joined by an arrow",
+		},
+		{
+			name:    "Missing target",
+			input:   "SFO->ATL\n\nATL->\n",
+			wantErr: "line 3: each line must be two airports joined by an arrow",
+		},
+		{
+			name:    "Missing source",
+			input:   "-> ATL",
+			wantErr: "line 1: each line must be two airports joined by an arrow",
+		},
+		{
+			name:    "Chained arrows",
+			input:   "SFO->ATL->EWR",
+			wantErr: "line 1: each line must be two airports joined by an arrow",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segments, err := ParseSegmentsArrows(strings.NewReader(test.input))
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantSegments, segments)
+		})
+	}
+}
+
+func TestSearchArrows(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Arrow lines",
+			contentType:  "text/plain; charset=utf-8",
+			route:        "IND->EWR\nSFO -> ATL\nGSO->IND\nATL->GSO\n",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "Malformed line",
+			contentType:  "text/plain",
+			route:        "SFO->ATL\nATL,EWR\n",
+			wantResponse: `{"error":"line 2: each line must be two airports joined by an arrow"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "No lines",
+			contentType:  "text/plain",
+			route:        "\n\n",
+			wantResponse: `{"error":"wrong segments in payload"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Arrows aren't JSON",
+			route:        "SFO->ATL\n",
+			wantResponse: `{"error":"wrong payload"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://example.com/test", strings.NewReader(test.route))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			w := httptest.NewRecorder()
+			controller := SearchController{}
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
 func TestSearchHttpResponses(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -77,25 +250,25 @@ func TestSearchHttpResponses(t *testing.T) {
 		{
 			name:         "Single route",
 			route:        `[["SFO", "EWR"]]`,
-			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","EWR"]}`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","EWR"],"single_chain":true}`,
 			wantCode:     200,
 		},
 		{
 			name:         "Few routes",
 			route:        `[["ATL", "EWR"], ["SFO", "ATL"]]`,
-			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"]}`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
 			wantCode:     200,
 		},
 		{
 			name:         "Multiple routes",
 			route:        `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
-			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"]}`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
 			wantCode:     200,
 		},
 		{
 			name:         "Duplicated routes",
 			route:        `[["IND", "EWR"], ["SFO", "ATL"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
-			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"]}`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
 			wantCode:     200,
 		},
 		{
@@ -122,10 +295,70 @@ func TestSearchHttpResponses(t *testing.T) {
 			wantResponse: `{"error":"wrong payload"}`,
 			wantCode:     400,
 		},
+		{
+			name:         "trailing data",
+			route:        `[["SFO", "ATL"]] garbage`,
+			wantResponse: `{"error":"malformed payload"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "trailing segments",
+			route:        `[["SFO", "ATL"]][["ATL", "EWR"]]`,
+			wantResponse: `{"error":"malformed payload"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "trailing object with extra fields",
+			route:        `[["SFO", "ATL"]] {"segments": [["ATL", "EWR"]]}`,
+			wantResponse: `{"error":"malformed payload"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "object with extra fields",
+			route:        `{"segments": [["SFO", "ATL"]], "extra": true}`,
+			wantResponse: `{"error":"malformed payload"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "segment object",
+			route:        `[{"source": "SFO", "target": "ATL"}]`,
+			wantResponse: `{"error":"malformed payload"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "trailing whitespace",
+			route:        "[[\"SFO\", \"ATL\"]]\n\t ",
+			wantResponse: `{"short_path":["SFO","ATL"],"full_path":["SFO","ATL"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "empty segment",
+			route:        `[["SFO", "ATL"], []]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "segment with one airport",
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "segment with three airports",
+			route:        `[["SFO", "ATL", "EWR"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "segment with empty airport",
+			route:        `[["SFO", ""]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
 		{
 			name:         "disconnected routes will take random",
 			route:        `[["IND", "FDF"], ["DAD", "EED"]]`,
-			wantResponse: `{"short_path":["DAD","EED"],"full_path":["DAD","EED"]}`,
+			wantResponse: `{"short_path":["DAD","EED"],"full_path":["DAD","EED"],"single_chain":false}`,
 			wantCode:     200,
 		},
 	}
@@ -142,3 +375,1170 @@ func TestSearchHttpResponses(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchSingleChain(t *testing.T) {
+	tests := []struct {
+		name            string
+		route           string
+		wantSingleChain bool
+	}{
+		{
+			name:            "Clean chain",
+			route:           `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+			wantSingleChain: true,
+		},
+		{
+			name:            "Disconnected routes",
+			route:           `[["IND", "FDF"], ["DAD", "EED"]]`,
+			wantSingleChain: false,
+		},
+		{
+			name:            "Branching routes",
+			route:           `[["SFO", "ATL"], ["SFO", "EWR"]]`,
+			wantSingleChain: false,
+		},
+		{
+			name:            "Merging routes",
+			route:           `[["SFO", "ATL"], ["EWR", "ATL"]]`,
+			wantSingleChain: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segments, err := parseSegments([]byte(test.route), false)
+			assert.NoError(t, err)
+
+			controller := SearchController{}
+			res, err := controller.calculate(context.Background(), segments, searchOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantSingleChain, res.singleChain)
+		})
+	}
+}
+
+func TestSearchCache(t *testing.T) {
+	controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+
+	search := func(route string) string {
+		req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(route))
+		w := httptest.NewRecorder()
+		controller.Search(w, req)
+		assert.Equal(t, 200, w.Code)
+		return w.Body.String()
+	}
+
+	first := search(`[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`)
+	assert.Equal(t, cache.Stats{Hits: 0, Misses: 1}, controller.Cache.Stats())
+
+	// Same segments in a different order are served from the cache.
+	second := search(`[["ATL", "GSO"], ["GSO", "IND"], ["SFO", "ATL"], ["IND", "EWR"]]`)
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1}, controller.Cache.Stats())
+	assert.Equal(t, first, second)
+
+	other := search(`[["ATL", "EWR"], ["SFO", "ATL"]]`)
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 2}, controller.Cache.Stats())
+	assert.Equal(t, `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`+"\n", other)
+	assert.Equal(t, 2, controller.Cache.Len())
+}
+
+func TestSearchWithNetwork(t *testing.T) {
+	network := graph.New(graph.StringHash, graph.Directed())
+	for _, v := range []string{"SFO", "ATL", "GSO", "EWR", "LAX"} {
+		assert.NoError(t, network.AddVertex(v))
+	}
+	assert.NoError(t, network.AddEdge("ATL", "GSO"))
+	assert.NoError(t, network.AddEdge("EWR", "SFO"))
+	assert.NoError(t, network.AddEdge("EWR", "LAX"))
+
+	controller := SearchController{Network: network}
+	req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(`[["SFO", "ATL"], ["GSO", "EWR"]]`))
+	w := httptest.NewRecorder()
+	controller.Search(w, req)
+
+	// ATL -> GSO joins the segments, EWR -> SFO would create a cycle and
+	// LAX isn't part of the submitted segments.
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","EWR"],"single_chain":true}`+"\n", w.Body.String())
+}
+
+func TestSearchMeta(t *testing.T) {
+	controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+
+	search := func(url string) map[string]json.RawMessage {
+		req := httptest.NewRequest("GET", url, strings.NewReader(`[["ATL", "EWR"], ["SFO", "ATL"]]`))
+		w := httptest.NewRecorder()
+		controller.Search(w, req)
+		assert.Equal(t, 200, w.Code)
+
+		var body map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := search("http://example.com/test")
+	assert.NotContains(t, body, "meta")
+
+	for _, url := range []string{"http://example.com/test?meta=true", "http://example.com/test?meta=1"} {
+		body = search(url)
+		assert.Contains(t, body, "meta")
+
+		var meta response.Meta
+		assert.NoError(t, json.Unmarshal(body["meta"], &meta))
+		assert.GreaterOrEqual(t, meta.ElapsedMs, int64(0))
+		assert.Contains(t, string(body["meta"]), `"elapsed_ms"`)
+	}
+
+	// Meta isn't cached along with the response.
+	body = search("http://example.com/test")
+	assert.NotContains(t, body, "meta")
+}
+
+func TestSearchStrict(t *testing.T) {
+	const duplicated = `[["IND", "EWR"], ["SFO", "ATL"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`
+
+	tests := []struct {
+		name         string
+		strict       bool
+		url          string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Lenient by default",
+			url:          "http://example.com/test",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Strict query parameter",
+			url:          "http://example.com/test?strict=true",
+			wantResponse: `{"error":"duplicate segment [\"SFO\", \"ATL\"]"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Strict by config",
+			strict:       true,
+			url:          "http://example.com/test",
+			wantResponse: `{"error":"duplicate segment [\"SFO\", \"ATL\"]"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Query parameter overrides config",
+			strict:       true,
+			url:          "http://example.com/test?strict=false",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Invalid query parameter",
+			url:          "http://example.com/test?strict=yes",
+			wantResponse: `{"error":"invalid strict parameter \"yes\""}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{Strict: test.strict}
+			req := httptest.NewRequest("GET", test.url, strings.NewReader(duplicated))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchStrictNotServedFromCache(t *testing.T) {
+	controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+	const duplicated = `[["SFO", "ATL"], ["SFO", "ATL"]]`
+
+	req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(duplicated))
+	w := httptest.NewRecorder()
+	controller.Search(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	req = httptest.NewRequest("GET", "http://example.com/test?strict=true", strings.NewReader(duplicated))
+	w = httptest.NewRecorder()
+	controller.Search(w, req)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestSearchDeterministic(t *testing.T) {
+	var first string
+	for i := 0; i < 20; i++ {
+		controller := SearchController{}
+		req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(`[["SFO", "EWR"], ["SFO", "ATL"], ["ATL", "IND"], ["EWR", "GSO"]]`))
+		w := httptest.NewRecorder()
+		controller.Search(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		if i == 0 {
+			first = w.Body.String()
+
+			var res SearchResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, []string{"SFO", "GSO"}, res.ShortPath)
+			assert.ElementsMatch(t, []string{"SFO", "ATL", "IND", "EWR", "GSO"}, res.FullPath)
+			assert.Equal(t, "SFO", res.FullPath[0])
+			assert.False(t, res.SingleChain)
+		}
+		assert.Equal(t, first, w.Body.String())
+	}
+}
+
+func TestSearchMaxHops(t *testing.T) {
+	const route = `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`
+
+	tests := []struct {
+		name         string
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Within the limit",
+			query:        "maxHops=4",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Exceeding the limit",
+			query:        "maxHops=3",
+			wantResponse: `{"error":"can't find route within 3 hops"}`,
+			wantCode:     422,
+		},
+		{
+			name:         "Invalid limit",
+			query:        "maxHops=many",
+			wantResponse: `{"error":"invalid maxHops parameter \"many\""}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			// Warm up the cache without the limit, it mustn't be used.
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(route))
+			controller.Search(httptest.NewRecorder(), req)
+
+			req = httptest.NewRequest("GET", "http://example.com/test?"+test.query, strings.NewReader(route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func FuzzSearch(f *testing.F) {
+	for _, seed := range []string{
+		`[["SFO", "EWR"]]`,
+		`[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+		`[["SFO", "SFO"]]`,
+		`[["SFO"]]`,
+		`[[]]`,
+		`[]`,
+		`["IND", "EWR"]`,
+		``,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, payload string) {
+		controller := SearchController{}
+		req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(payload))
+		w := httptest.NewRecorder()
+		controller.Search(w, req)
+
+		// Any payload may be rejected, but only with one of the documented
+		// client errors and never with a server error.
+		switch w.Code {
+		case http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict,
+			http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		default:
+			t.Errorf("unexpected status %d for payload %q", w.Code, payload)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("invalid JSON response %q for payload %q", w.Body.String(), payload)
+		}
+	})
+}
+
+func TestSearchValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      int
+		route        string
+		csv          bool
+		wantResponse string
+	}{
+		{
+			name:         "v1 reports the first error",
+			version:      response.V1,
+			route:        `[["SFO", "ATL"], ["ATL", "EWR", -1], ["EWR", "IND", "GSO", "LAX"]]`,
+			wantResponse: `{"error":"segment weight must be a non-negative number"}`,
+		},
+		{
+			name:    "v2 reports all errors",
+			version: response.V2,
+			route:   `[["SFO", "ATL"], ["ATL", "EWR", -1], ["EWR", "IND", "GSO", "LAX"], ["IND", ""]]`,
+			wantResponse: `{"error":{"code":"VALIDATION","message":"segment weight must be a non-negative number","fields":[` +
+				`{"index":1,"reason":"segment weight must be a non-negative number"},` +
+				`{"index":2,"reason":"each segment must have exactly two airports"},` +
+				`{"index":3,"reason":"each segment must have exactly two airports"}]}}`,
+		},
+		{
+			name:    "v2 lone airports",
+			version: response.V2,
+			route:   `[["SFO", "ATL"], ["JFK"], ["ATL", "EWR"], ["LAX"]]`,
+			wantResponse: `{"error":{"code":"VALIDATION","message":"each segment must have exactly two airports","fields":[` +
+				`{"index":1,"reason":"each segment must have exactly two airports"},` +
+				`{"index":3,"reason":"each segment must have exactly two airports"}]}}`,
+		},
+		{
+			name:    "v2 CSV",
+			version: response.V2,
+			route:   "SFO,ATL\nATL,EWR,abc\nEWR,IND,1,2\n",
+			csv:     true,
+			wantResponse: `{"error":{"code":"VALIDATION","message":"segment weight must be a number or a numeric string","fields":[` +
+				`{"index":1,"reason":"segment weight must be a number or a numeric string"},` +
+				`{"index":2,"reason":"each segment must have exactly two airports"}]}}`,
+		},
+		{
+			name:         "v2 malformed payload isn't a validation error",
+			version:      response.V2,
+			route:        `[["SFO", "ATL"]`,
+			wantResponse: `{"error":{"code":"BAD_REQUEST","message":"wrong payload"}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{}
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Search))
+
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			if test.csv {
+				var body bytes.Buffer
+				mw := multipart.NewWriter(&body)
+				part, err := mw.CreateFormFile(SegmentsFormField, "segments.csv")
+				assert.NoError(t, err)
+				_, err = part.Write([]byte(test.route))
+				assert.NoError(t, err)
+				assert.NoError(t, mw.Close())
+
+				req = httptest.NewRequest("POST", "http://example.com/test", &body)
+				req.Header.Set("Content-Type", mw.FormDataContentType())
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchNoPath(t *testing.T) {
+	const route = `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`
+
+	tests := []struct {
+		name         string
+		version      int
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "v1 no route",
+			version:      response.V1,
+			route:        route,
+			wantResponse: `{"error":"can't find route within 2 hops"}`,
+			wantCode:     http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "v2 no route",
+			version:      response.V2,
+			route:        route,
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route within 2 hops"}}`,
+			wantCode:     http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "v2 bad payload",
+			version:      response.V2,
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":{"code":"VALIDATION","message":"each segment must have exactly two airports","fields":[{"index":0,"reason":"each segment must have exactly two airports"}]}}`,
+			wantCode:     http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{}
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Search))
+			req := httptest.NewRequest("GET", "http://example.com/test?maxHops=2", strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchStrategy(t *testing.T) {
+	// The route through ATL has the most connections, the one through LAX
+	// covers the longest distance. The default strategy reports the order in
+	// which the airports were visited, so only its airports are checked.
+	const route = `[["SFO", "ATL", 100], ["ATL", "GSO", 100], ["GSO", "EWR", 100], ["SFO", "LAX", 2000], ["LAX", "EWR", 1000]]`
+
+	tests := []struct {
+		name         string
+		route        string
+		query        string
+		wantResponse string
+		wantVisited  []string
+		wantCode     int
+	}{
+		{
+			name:        "Default",
+			route:       route,
+			wantVisited: []string{"SFO", "ATL", "GSO", "EWR", "LAX"},
+			wantCode:    200,
+		},
+		{
+			name:        "Longest",
+			route:       route,
+			query:       "strategy=longest",
+			wantVisited: []string{"SFO", "ATL", "GSO", "EWR", "LAX"},
+			wantCode:    200,
+		},
+		{
+			name:         "Longest weighted",
+			route:        route,
+			query:        "strategy=longest-weighted",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","LAX","EWR"],"single_chain":false}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Unweighted segments",
+			route:        `[["SFO", "ATL"], ["ATL", "EWR"]]`,
+			query:        "strategy=longest-weighted",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Cyclic segments",
+			route:        `[["SFO", "ATL", 100], ["ATL", "SFO", 100]]`,
+			query:        "strategy=longest-weighted",
+			wantResponse: `{"error":"edge would create a cycle"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Negative weight",
+			route:        `[["SFO", "ATL", -100]]`,
+			query:        "strategy=longest-weighted",
+			wantResponse: `{"error":"segment weight must be a non-negative number"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Invalid strategy",
+			route:        route,
+			query:        "strategy=shortest",
+			wantResponse: `{"error":"invalid strategy parameter \"shortest\""}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			// Warm up the cache with the default strategy, it mustn't be used.
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			controller.Search(httptest.NewRecorder(), req)
+
+			req = httptest.NewRequest("GET", "http://example.com/test?"+test.query, strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			if test.wantVisited == nil {
+				assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+				return
+			}
+			var res SearchResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.ElementsMatch(t, test.wantVisited, res.FullPath)
+			if assert.NotEmpty(t, res.FullPath) {
+				assert.Equal(t, test.wantVisited[0], res.FullPath[0])
+				assert.Equal(t, []string{res.FullPath[0], res.FullPath[len(res.FullPath)-1]}, res.ShortPath)
+			}
+			assert.False(t, res.SingleChain)
+		})
+	}
+}
+
+func TestSearchVersions(t *testing.T) {
+	const route = `[["ATL", "EWR"], ["SFO", "ATL"]]`
+
+	tests := []struct {
+		name         string
+		version      int
+		wantResponse string
+	}{
+		{
+			name:         "v1",
+			version:      response.V1,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
+		},
+		{
+			name:         "v2",
+			version:      response.V2,
+			wantResponse: `{"shortest":["SFO","EWR"],"route":["SFO","ATL","EWR"],"single_chain":true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Search))
+
+			// The second response is served from the cache.
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(route))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchAmbiguousStart(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      int
+		route        string
+		query        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "v2 two starts",
+			version:      response.V2,
+			route:        `[["SFO", "ATL"], ["EWR", "ATL"], ["ATL", "IND"]]`,
+			wantResponse: `{"error":{"code":"AMBIGUOUS_START","message":"segments have more than one start airport","starts":["EWR","SFO"]}}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "v2 single start",
+			version:      response.V2,
+			route:        `[["ATL", "IND"], ["SFO", "ATL"]]`,
+			wantResponse: `{"shortest":["SFO","IND"],"route":["SFO","ATL","IND"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "v1 two starts",
+			version:      response.V1,
+			route:        `[["SFO", "ATL"], ["EWR", "ATL"], ["ATL", "IND"]]`,
+			wantResponse: `{"short_path":["EWR","IND"],"full_path":["EWR","ATL","IND"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "v1 two separate trips",
+			version:      response.V1,
+			route:        `[["SFO", "ATL"], ["EWR", "IND"]]`,
+			wantResponse: `{"short_path":["EWR","IND"],"full_path":["EWR","IND"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "v1 strict two starts",
+			version:      response.V1,
+			route:        `[["SFO", "ATL"], ["EWR", "ATL"], ["ATL", "IND"]]`,
+			query:        "strict=true",
+			wantResponse: `{"short_path":["EWR","IND"],"full_path":["EWR","ATL","IND"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The cache is shared by the versions, the v1 result mustn't be
+			// served to v2.
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			controller.Search(httptest.NewRecorder(), req)
+
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Search))
+			req = httptest.NewRequest("GET", "http://example.com/test?"+test.query, strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchReverse(t *testing.T) {
+	const route = `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`
+
+	search := func(controller *SearchController, query string) (int, SearchResponse) {
+		req := httptest.NewRequest("GET", "http://example.com/test?"+query, strings.NewReader(route))
+		w := httptest.NewRecorder()
+		controller.Search(w, req)
+
+		var res SearchResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &res)
+		return w.Code, res
+	}
+
+	controller := &SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+	code, forward := search(controller, "")
+	assert.Equal(t, http.StatusOK, code)
+
+	// Served from the cache, which must not be reversed in place.
+	for i := 0; i < 2; i++ {
+		code, backward := search(controller, "reverse=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"EWR", "SFO"}, backward.ShortPath)
+		assert.Equal(t, []string{"EWR", "IND", "GSO", "ATL", "SFO"}, backward.FullPath)
+		assert.Equal(t, reversePath(forward.FullPath), backward.FullPath)
+		assert.Equal(t, reversePath(forward.ShortPath), backward.ShortPath)
+	}
+
+	_, res := search(controller, "reverse=false")
+	assert.Equal(t, forward, res)
+
+	code, _ = search(controller, "reverse=maybe")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestSearchMultipart(t *testing.T) {
+	tests := []struct {
+		name         string
+		field        string
+		filename     string
+		contentType  string
+		content      string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "JSON file",
+			field:        SegmentsFormField,
+			filename:     "segments.json",
+			content:      `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "CSV file",
+			field:        SegmentsFormField,
+			filename:     "segments.csv",
+			content:      "IND,EWR\nSFO,ATL\nGSO, IND\nATL,GSO,1000\n",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "CSV content type",
+			field:        SegmentsFormField,
+			filename:     "segments",
+			contentType:  "text/csv",
+			content:      "SFO,ATL\nATL,EWR\n",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Wrong CSV segment",
+			field:        SegmentsFormField,
+			filename:     "segments.csv",
+			content:      "SFO,ATL\nEWR\n",
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Missing file",
+			field:        "file",
+			filename:     "segments.json",
+			content:      `[["SFO", "ATL"]]`,
+			wantResponse: `{"error":"missing \"segments\" file in form"}`,
+			wantCode:     400,
+		},
+		{
+			name:         "Too large",
+			field:        SegmentsFormField,
+			filename:     "segments.csv",
+			content:      strings.Repeat("SFO,ATL\n", MaxPayloadSize/8+1),
+			wantResponse: `{"error":"payload exceeds 10485760 bytes"}`,
+			wantCode:     413,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, test.field, test.filename))
+			if test.contentType != "" {
+				header.Set("Content-Type", test.contentType)
+			}
+			part, err := mw.CreatePart(header)
+			assert.NoError(t, err)
+			_, err = part.Write([]byte(test.content))
+			assert.NoError(t, err)
+			assert.NoError(t, mw.Close())
+
+			req := httptest.NewRequest("POST", "http://example.com/test", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			controller := SearchController{}
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchPayloadTooLarge(t *testing.T) {
+	body := "[" + strings.Repeat(`["SFO", "ATL"],`, MaxPayloadSize/15) + `["SFO", "ATL"]]`
+	req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	controller := SearchController{}
+	controller.Search(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, `{"error":"payload exceeds 10485760 bytes"}`+"\n", w.Body.String())
+}
+
+func TestSearchReportDuplicates(t *testing.T) {
+	const route = `[["IND", "EWR"], ["SFO", "ATL"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"], ["SFO", "ATL"]]`
+
+	tests := []struct {
+		name         string
+		query        string
+		version      int
+		wantResponse string
+	}{
+		{
+			name:         "Not requested",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+		},
+		{
+			name:         "Requested",
+			query:        "reportDuplicates=true",
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true,"duplicates":[["SFO","ATL"],["SFO","ATL"]]}`,
+		},
+		{
+			name:         "Requested in v2",
+			query:        "reportDuplicates=true",
+			version:      response.V2,
+			wantResponse: `{"shortest":["SFO","EWR"],"route":["SFO","ATL","GSO","IND","EWR"],"single_chain":true,"duplicates":[["SFO","ATL"],["SFO","ATL"]]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The duplicates are cached regardless of the parameter.
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			for i := 0; i < 2; i++ {
+				handler := http.Handler(http.HandlerFunc(controller.Search))
+				if test.version != 0 {
+					handler = response.WithVersion(test.version)(handler)
+				}
+
+				req := httptest.NewRequest("GET", "http://example.com/test?"+test.query, strings.NewReader(route))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchInclude(t *testing.T) {
+	// The longest route takes the detour through ATL, the direct segment is
+	// the route with the fewest connections.
+	const route = `[["SFO", "ATL"], ["ATL", "EWR"], ["SFO", "EWR"]]`
+
+	tests := []struct {
+		name         string
+		query        string
+		version      int
+		wantCode     int
+		wantResponse string
+	}{
+		{
+			name:         "Not requested",
+			wantCode:     http.StatusOK,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":false}`,
+		},
+		{
+			name:         "Long only",
+			query:        "include=long",
+			wantCode:     http.StatusOK,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":false}`,
+		},
+		{
+			name:         "Short and long",
+			query:        "include=short,long",
+			wantCode:     http.StatusOK,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"fewest_hops_path":["SFO","EWR"],"single_chain":false}`,
+		},
+		{
+			name:         "Short only",
+			query:        "include=short",
+			wantCode:     http.StatusOK,
+			wantResponse: `{"short_path":["SFO","EWR"],"fewest_hops_path":["SFO","EWR"],"single_chain":false}`,
+		},
+		{
+			name:         "Short and long reversed",
+			query:        "include=long,short&reverse=true",
+			wantCode:     http.StatusOK,
+			wantResponse: `{"short_path":["EWR","SFO"],"full_path":["EWR","ATL","SFO"],"fewest_hops_path":["EWR","SFO"],"single_chain":false}`,
+		},
+		{
+			name:         "Short and long in v2",
+			query:        "include=short,long",
+			version:      response.V2,
+			wantCode:     http.StatusOK,
+			wantResponse: `{"shortest":["SFO","EWR"],"route":["SFO","ATL","EWR"],"fewest_hops":["SFO","EWR"],"single_chain":false}`,
+		},
+		{
+			name:         "Unknown path",
+			query:        "include=short,medium",
+			wantCode:     http.StatusBadRequest,
+			wantResponse: `{"error":"invalid include parameter \"short,medium\""}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The second search is served from the cache.
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			for i := 0; i < 2; i++ {
+				handler := http.Handler(http.HandlerFunc(controller.Search))
+				if test.version != 0 {
+					handler = response.WithVersion(test.version)(handler)
+				}
+
+				req := httptest.NewRequest("GET", "http://example.com/test?"+test.query, strings.NewReader(route))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				assert.Equal(t, test.wantCode, w.Code)
+				assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchEmptySegments(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowEmpty   bool
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Rejected by default",
+			route:        `[]`,
+			wantResponse: `{"error":"wrong segments in payload"}` + "\n",
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:       "Allowed",
+			allowEmpty: true,
+			route:      `[]`,
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:         "Empty body is still wrong",
+			allowEmpty:   true,
+			route:        ``,
+			wantResponse: `{"error":"empty payload"}` + "\n",
+			wantCode:     http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{AllowEmpty: test.allowEmpty}
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse, w.Body.String())
+		})
+	}
+}
+
+func TestSearchTieBreak(t *testing.T) {
+	// Two separate trips of two flights each, so both start airports have an
+	// equally long route.
+	const route = `[["SFO", "ATL"], ["IND", "EWR"], ["ATL", "GSO"], ["EWR", "LAX"]]`
+
+	tests := []struct {
+		name         string
+		rejectTies   bool
+		version      int
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "First alphabetically",
+			version:      response.V1,
+			route:        route,
+			wantResponse: `{"short_path":["IND","LAX"],"full_path":["IND","EWR","LAX"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "Error",
+			rejectTies:   true,
+			version:      response.V1,
+			route:        route,
+			wantResponse: `{"error":"segments have more than one longest route"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Error v2",
+			rejectTies:   true,
+			version:      response.V2,
+			route:        route,
+			wantResponse: `{"error":{"code":"AMBIGUOUS_START","message":"segments have more than one start airport","starts":["IND","SFO"]}}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Error without a tie",
+			rejectTies:   true,
+			version:      response.V1,
+			route:        `[["SFO", "ATL"], ["ATL", "GSO"], ["SFO", "EWR"], ["IND", "ATL"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","EWR"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{RejectTies: test.rejectTies}
+			handler := response.WithVersion(test.version)(http.HandlerFunc(controller.Search))
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchFastChain(t *testing.T) {
+	tests := []struct {
+		name         string
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Single chain",
+			route:        `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","GSO","IND","EWR"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "Branch",
+			route:        `[["SFO", "ATL"], ["ATL", "GSO"], ["ATL", "EWR"]]`,
+			wantResponse: `{"error":"segments don't form a single chain"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Separate trips",
+			route:        `[["SFO", "ATL"], ["IND", "EWR"]]`,
+			wantResponse: `{"error":"segments don't form a single chain"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Trips joining",
+			route:        `[["SFO", "ATL"], ["IND", "ATL"], ["ATL", "EWR"]]`,
+			wantResponse: `{"error":"segments don't form a single chain"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{FastChain: true}
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+
+			if test.wantCode != http.StatusOK {
+				return
+			}
+
+			// A single chain gets the same route as without the fast path.
+			var defaultController SearchController
+			req = httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(test.route))
+			w = httptest.NewRecorder()
+			defaultController.Search(w, req)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchIsolated(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Lone airport rejected by default",
+			route:        `[["JFK"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Lone airport",
+			query:        "?isolated=true",
+			route:        `[["JFK"]]`,
+			wantResponse: `{"short_path":["JFK"],"full_path":["JFK"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "Longer route wins",
+			query:        "?isolated=true",
+			route:        `[["JFK"], ["SFO", "ATL"], ["ATL", "EWR"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":false}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "Lone airport on a route",
+			query:        "?isolated=true",
+			route:        `[["SFO", "ATL"], ["ATL"]]`,
+			wantResponse: `{"short_path":["SFO","ATL"],"full_path":["SFO","ATL"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{}
+			req := httptest.NewRequest("GET", "http://example.com/test"+test.query, strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchDryRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Single chain",
+			route:        `[["IND", "EWR"], ["SFO", "ATL"], ["GSO", "IND"], ["ATL", "GSO"]]`,
+			wantResponse: `{"order":5,"size":4,"sources":["SFO"],"sinks":["EWR"],"acyclic":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Branching with a duplicate",
+			route:        `[["SFO", "ATL"], ["EWR", "ATL"], ["ATL", "IND"], ["ATL", "GSO"], ["SFO", "ATL"]]`,
+			wantResponse: `{"order":5,"size":4,"sources":["EWR","SFO"],"sinks":["GSO","IND"],"acyclic":true}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Cycle",
+			route:        `[["SFO", "ATL"], ["ATL", "EWR"], ["EWR", "SFO"]]`,
+			wantResponse: `{"order":3,"size":3,"sources":[],"sinks":[],"acyclic":false}`,
+			wantCode:     200,
+		},
+		{
+			name:         "Wrong payload",
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := SearchController{Cache: cache.NewLRU[string, SearchResponse](10)}
+			req := httptest.NewRequest("GET", "http://example.com/test?dryRun=true", strings.NewReader(test.route))
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+			// No route was searched, so there's nothing to cache.
+			assert.Equal(t, 0, controller.Cache.Len())
+		})
+	}
+}
+
+func TestSearchCancellation(t *testing.T) {
+	const route = `[["ATL", "EWR"], ["SFO", "ATL"]]`
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantLog      string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "Client cancelled",
+			ctx:          cancelled,
+			wantLog:      "search cancelled by the client",
+			wantResponse: `{"error":"client closed request"}`,
+			wantCode:     499,
+		},
+		{
+			name:         "Server timeout",
+			ctx:          expired,
+			wantLog:      "search timed out",
+			wantResponse: `{"error":"search timed out"}`,
+			wantCode:     504,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs strings.Builder
+			logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logs), zap.DebugLevel))
+
+			controller := SearchController{Logger: logger}
+			req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(route)).WithContext(test.ctx)
+			w := httptest.NewRecorder()
+			controller.Search(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+			assert.Contains(t, logs.String(), test.wantLog)
+		})
+	}
+}
+
+func TestSearchTimeout(t *testing.T) {
+	// A negative timeout expires immediately.
+	controller := SearchController{Timeout: -time.Second}
+	req := httptest.NewRequest("GET", "http://example.com/test", strings.NewReader(`[["ATL", "EWR"], ["SFO", "ATL"]]`))
+	w := httptest.NewRecorder()
+	controller.Search(w, req)
+
+	assert.Equal(t, 504, w.Code)
+}
@@ -0,0 +1,530 @@
+package controller
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/graph"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	errDuplicateSegment = errors.New("duplicate segment")
+	errWrongPayload     = errors.New("wrong payload")
+	errMalformedPayload = errors.New("malformed payload")
+	errNoSegments       = errors.New("wrong segments in payload")
+	errSegmentAirports  = errors.New("each segment must have exactly two airports")
+	errSegmentWeight    = errors.New("segment weight must be a non-negative number")
+	errInvalidWeight    = errors.New("segment weight must be a number or a numeric string")
+	errArrowLine        = errors.New("each line must be two airports joined by an arrow")
+	errPayloadTooLarge  = fmt.Errorf("payload exceeds %d bytes", MaxPayloadSize)
+)
+
+// segment is a flight from the source to the target airport. Weight is only set
+// if the payload gives it as a third element, e.g. ["SFO", "ATL", 2139].
+//
+// A payload element with a single airport, e.g. ["JFK"], is a lone airport
+// without flights, which has no Target.
+type segment struct {
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Weight *float64 `json:"weight,omitempty"`
+}
+
+// lone reports whether the segment is a lone airport rather than a flight.
+func (s segment) lone() bool {
+	return s.Target == ""
+}
+
+// segmentError reports why the segment at index of the payload is invalid.
+type segmentError struct {
+	index int
+	err   error
+}
+
+// validationError collects the errors of all invalid segments of a payload, so
+// clients can fix them at once. Its message is the one of the first error,
+// which it also unwraps to.
+type validationError struct {
+	errors []segmentError
+}
+
+func (e *validationError) Error() string {
+	return e.errors[0].err.Error()
+}
+
+func (e *validationError) Unwrap() error {
+	return e.errors[0].err
+}
+
+// add records the error of the segment at index.
+func (e *validationError) add(index int, err error) {
+	e.errors = append(e.errors, segmentError{index: index, err: err})
+}
+
+// orNil returns the error if any segment is invalid, and nil otherwise.
+func (e *validationError) orNil() error {
+	if len(e.errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// writeSegmentsError writes the 400 response of segments which can't be
+// searched. A validationError lists the invalid segments in the structured
+// error with the "VALIDATION" code.
+func writeSegmentsError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *validationError
+	if !errors.As(err, &invalid) {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	fields := make([]response.FieldError, 0, len(invalid.errors))
+	for _, e := range invalid.errors {
+		fields = append(fields, response.FieldError{Index: e.index, Reason: e.err.Error()})
+	}
+	response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{
+		Error:  err.Error(),
+		Code:   "VALIDATION",
+		Fields: fields,
+	})
+}
+
+// SegmentsFormField is the name of the file field holding the segments in
+// multipart/form-data uploads.
+const SegmentsFormField = "segments"
+
+// MaxPayloadSize is the maximum size of a request body holding segments,
+// including multipart/form-data bodies with the segments file.
+const MaxPayloadSize = 10 << 20
+
+// readSegments reads the flight segments from the request body and makes sure
+// each of them consists of a source and a target airport. The segments may
+// also be sent as text/plain lines like "SFO->ATL", or uploaded as a JSON or
+// CSV file in a multipart/form-data body. On failure it writes the error
+// response itself and returns false.
+//
+// An empty list of segments is an error, unless allowEmpty is set, in which
+// case no segments are returned. Lone airports like ["JFK"] are invalid
+// segments, unless allowLone is set.
+func readSegments(w http.ResponseWriter, r *http.Request, allowEmpty, allowLone bool) ([]segment, bool) {
+	// TODO not using validator here, since it's simple structure
+	body, parse, err := readPayload(w, r)
+	if errors.Is(err, errPayloadTooLarge) {
+		response.WriteJSONResponse(w, r, http.StatusRequestEntityTooLarge, response.ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	if len(body) == 0 {
+		response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: "empty payload"})
+		return nil, false
+	}
+
+	segments, err := parse(body, allowLone)
+	if errors.Is(err, errNoSegments) && allowEmpty {
+		return nil, true
+	}
+	if err != nil {
+		writeSegmentsError(w, r, err)
+		return nil, false
+	}
+
+	return segments, true
+}
+
+// readPayload returns the raw request body, or the content of the uploaded
+// segments file for multipart/form-data requests, along with the parser of its
+// format. A text/plain body holds arrow lines, any other body is JSON. A file
+// is CSV if its name ends with ".csv" or its part has the text/csv content
+// type, JSON otherwise. Bodies larger than MaxPayloadSize fail with
+// errPayloadTooLarge.
+func readPayload(w http.ResponseWriter, r *http.Request) ([]byte, func([]byte, bool) ([]segment, error), error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxPayloadSize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		if mediaType == "text/plain" {
+			return body, parseArrowSegments, payloadError(err)
+		}
+		return body, parseSegments, payloadError(err)
+	}
+
+	file, header, err := r.FormFile(SegmentsFormField)
+	if errors.Is(payloadError(err), errPayloadTooLarge) {
+		return nil, nil, errPayloadTooLarge
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing %q file in form", SegmentsFormField)
+	}
+	defer file.Close()
+
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	partType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	if partType == "text/csv" || strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+		return body, parseCSVSegments, nil
+	}
+
+	return body, parseSegments, nil
+}
+
+// payloadError replaces the error of reading a body cut off by
+// http.MaxBytesReader with errPayloadTooLarge.
+func payloadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errPayloadTooLarge
+	}
+	return err
+}
+
+// ParseSegmentsArrows parses segments written as one "SOURCE->TARGET" line
+// each, the way routes are often noted by hand:
+//
+//	SFO->ATL
+//	ATL -> EWR
+//
+// Whitespace around the airports and blank lines are ignored. The segments are
+// returned as pairs of the source and target airport. A malformed line fails
+// the whole input with an error naming its line number, starting at 1.
+func ParseSegmentsArrows(r io.Reader) ([][]string, error) {
+	var segments [][]string
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		source, target, ok := strings.Cut(text, "->")
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if !ok || source == "" || target == "" || strings.Contains(target, "->") {
+			return nil, fmt.Errorf("line %d: %w", line, errArrowLine)
+		}
+
+		segments = append(segments, []string{source, target})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// parseArrowSegments parses a text/plain body of arrow lines with
+// ParseSegmentsArrows. Arrow lines always join two airports, so lone airports
+// can't be given and lone is ignored.
+func parseArrowSegments(body []byte, lone bool) ([]segment, error) {
+	pairs, err := ParseSegmentsArrows(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs) == 0 {
+		return nil, errNoSegments
+	}
+
+	segments := make([]segment, 0, len(pairs))
+	for _, pair := range pairs {
+		segments = append(segments, segment{Source: pair[0], Target: pair[1]})
+	}
+
+	return segments, nil
+}
+
+// parseCSVSegments parses CSV records of the source and target airport followed
+// by an optional numeric weight, or of a lone airport if lone is set, like the
+// elements of the JSON payload:
+//
+//	SFO,ATL
+//	ATL,EWR,746
+//	JFK
+//
+// Invalid records are reported together in a validationError.
+func parseCSVSegments(body []byte, lone bool) ([]segment, error) {
+	cr := csv.NewReader(bytes.NewReader(body))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errWrongPayload
+	}
+
+	if len(records) == 0 {
+		return nil, errNoSegments
+	}
+
+	segments := make([]segment, 0, len(records))
+	var errs validationError
+	for i, record := range records {
+		s, err := parseCSVSegment(record, lone)
+		if err != nil {
+			errs.add(i, err)
+			continue
+		}
+		segments = append(segments, s)
+	}
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func parseCSVSegment(record []string, lone bool) (segment, error) {
+	if len(record) > 3 || len(record) == 1 && !lone {
+		return segment{}, errSegmentAirports
+	}
+
+	if len(record) == 1 {
+		source := strings.TrimSpace(record[0])
+		if source == "" {
+			return segment{}, errSegmentAirports
+		}
+		return segment{Source: source}, nil
+	}
+
+	source, target := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+	if source == "" || target == "" {
+		return segment{}, errSegmentAirports
+	}
+
+	s := segment{Source: source, Target: target}
+	if len(record) == 3 {
+		weight, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil {
+			return segment{}, errInvalidWeight
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return segment{}, errSegmentWeight
+		}
+		s.Weight = &weight
+	}
+
+	return s, nil
+}
+
+// parseSegments parses a JSON array of segments, each of which is an array of
+// the source and target airport followed by an optional numeric weight, or an
+// array of a lone airport if lone is set. Invalid segments are reported
+// together in a validationError.
+func parseSegments(body []byte, lone bool) ([]segment, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var elements [][]interface{}
+	if err := d.Decode(&elements); err != nil {
+		// The segments are arrays, so all fields of an object are unknown.
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Value == "object" {
+			return nil, errMalformedPayload
+		}
+		return nil, errWrongPayload
+	}
+	// Anything but whitespace after the segments, like a second array, is
+	// rejected rather than ignored.
+	if _, err := d.Token(); !errors.Is(err, io.EOF) {
+		return nil, errMalformedPayload
+	}
+
+	if len(elements) == 0 {
+		return nil, errNoSegments
+	}
+
+	segments := make([]segment, 0, len(elements))
+	var errs validationError
+	for i, el := range elements {
+		s, err := parseSegment(el, lone)
+		if err != nil {
+			errs.add(i, err)
+			continue
+		}
+		segments = append(segments, s)
+	}
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+func parseSegment(el []interface{}, lone bool) (segment, error) {
+	if len(el) < 1 || len(el) > 3 || len(el) == 1 && !lone {
+		return segment{}, errSegmentAirports
+	}
+
+	source, ok := el[0].(string)
+	if !ok || source == "" {
+		return segment{}, errSegmentAirports
+	}
+	if len(el) == 1 {
+		return segment{Source: source}, nil
+	}
+
+	target, ok := el[1].(string)
+	if !ok || target == "" {
+		return segment{}, errSegmentAirports
+	}
+
+	s := segment{Source: source, Target: target}
+	if len(el) == 3 {
+		var number json.Number
+		switch value := el[2].(type) {
+		case json.Number:
+			number = value
+		case string:
+			// Weights like prices may be sent as strings to keep their
+			// precision. A string which doesn't even start like a number
+			// is a third airport.
+			if err := json.Unmarshal([]byte(value), &number); err != nil {
+				if value != "" && strings.ContainsRune("+-.0123456789", rune(value[0])) {
+					return segment{}, errInvalidWeight
+				}
+				return segment{}, errSegmentAirports
+			}
+		default:
+			return segment{}, errSegmentWeight
+		}
+
+		weight, err := number.Float64()
+		if err != nil || weight < 0 {
+			return segment{}, errSegmentWeight
+		}
+		s.Weight = &weight
+	}
+
+	return s, nil
+}
+
+// buildGraph sorts the segments and builds a directed graph of them with the
+// given additional traits, like PreventCycles. Duplicated segments are added
+// only once and the skipped repetitions are returned, unless strict is set, in
+// which case they are reported with errDuplicateSegment.
+//
+// If any segment has a weight, the graph is weighted and segments without a
+// weight get a weight of 1. Lone airports are added as vertices without edges,
+// unless they're part of another segment, and are never duplicates.
+func buildGraph(segments []segment, strict bool, traits ...func(*graph.Traits)) (graph.Graph[string, string], []segment, error) {
+	sort.Slice(segments, func(i, j int) bool {
+		if segments[i].Source != segments[j].Source {
+			return segments[i].Source < segments[j].Source
+		}
+		return segments[i].Target < segments[j].Target
+	})
+
+	options := append([]func(*graph.Traits){graph.Directed(), graph.AutoCreateVertices()}, traits...)
+	weighted := false
+	for _, s := range segments {
+		if s.Weight != nil {
+			weighted = true
+			options = append(options, graph.Weighted())
+			break
+		}
+	}
+
+	g := graph.New(graph.StringHash, options...)
+	var duplicates []segment
+	for _, s := range segments {
+		if s.lone() {
+			if err := g.AddVertex(s.Source); err != nil && !errors.Is(err, graph.ErrVertexAlreadyExists) {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if _, err := g.Edge(s.Source, s.Target); err == nil {
+			if strict {
+				return nil, nil, fmt.Errorf("%w [%q, %q]", errDuplicateSegment, s.Source, s.Target)
+			}
+			duplicates = append(duplicates, s)
+			continue
+		}
+
+		var edgeOptions []func(*graph.EdgeProperties)
+		if weighted {
+			weight := 1.0
+			if s.Weight != nil {
+				weight = *s.Weight
+			}
+			edgeOptions = append(edgeOptions, graph.EdgeWeight(weight))
+		}
+
+		if err := g.AddEdge(s.Source, s.Target, edgeOptions...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return g, duplicates, nil
+}
+
+// augmentGraph adds the connections of the network between airports which are
+// already part of the graph. Connections which would create a cycle are
+// skipped, so the graph stays a valid itinerary.
+//
+// The graph of a search is small compared to the network, so the connections
+// are looked up between its airports rather than by listing the network. g
+// has to prevent cycles: its memory store checks them with the CreatesCycle
+// fast path, which only walks g.
+func augmentGraph(g, network graph.Graph[string, string]) error {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return err
+	}
+
+	// Add the connections in a stable order, since skipping cycles depends on
+	// which edges have been added before.
+	airports := make([]string, 0, len(adjacencyMap))
+	for airport := range adjacencyMap {
+		airports = append(airports, airport)
+	}
+	sort.Strings(airports)
+
+	for _, source := range airports {
+		for _, target := range airports {
+			if source == target {
+				continue
+			}
+
+			_, err = network.Edge(source, target)
+			switch {
+			case err == nil:
+			case errors.Is(err, graph.ErrEdgeNotFound), errors.Is(err, graph.ErrVertexNotFound):
+				continue
+			default:
+				return err
+			}
+
+			err = g.AddEdge(source, target)
+			switch {
+			case err == nil,
+				errors.Is(err, graph.ErrEdgeAlreadyExists),
+				errors.Is(err, graph.ErrEdgeCreatesCycle):
+			default:
+				return err
+			}
+		}
+	}
+
+	return nil
+}
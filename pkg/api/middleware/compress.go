@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Compress gzips responses for clients accepting it. Responses smaller than
+// minSize bytes are sent as they are, since compressing them costs more CPU
+// than it saves bandwidth. Level gzip.NoCompression turns compression off.
+//
+// All responses get "Vary: Accept-Encoding", also those sent uncompressed, so
+// caches don't serve a response to clients with a different Accept-Encoding.
+func Compress(level, minSize int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if level == gzip.NoCompression {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, level: level, minSize: minSize}
+			defer func() {
+				if err := cw.finish(); err != nil {
+					zap.L().Error("can't write compressed response", zap.Error(err))
+				}
+			}()
+			next.ServeHTTP(cw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.Split(encoding, ";")[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter buffers the response until it reaches minSize bytes, and only
+// then switches to gzip. The status code is held back until it's known whether
+// the response gets compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	level   int
+	minSize int
+
+	code        int
+	wroteHeader bool
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	// flushed is set when the response is flushed before reaching minSize,
+	// it's sent uncompressed from then on.
+	flushed bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.code == 0 {
+		cw.code = code
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	if cw.flushed {
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf.Write(p)
+	if cw.buf.Len() < cw.minSize || cw.Header().Get("Content-Encoding") != "" {
+		return len(p), nil
+	}
+
+	gz, err := gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+	if err != nil {
+		return 0, err
+	}
+
+	cw.Header().Set("Content-Encoding", "gzip")
+	cw.Header().Del("Content-Length")
+	cw.writeHeader()
+
+	cw.gz = gz
+	if _, err := cw.gz.Write(cw.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	cw.buf.Reset()
+
+	return len(p), nil
+}
+
+func (cw *compressWriter) writeHeader() {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.code)
+}
+
+// Flush sends what's written so far to the client, e.g. the events of a
+// stream. A response still below minSize isn't compressed at all, since
+// compression can't be switched on halfway through.
+func (cw *compressWriter) Flush() {
+	if cw.gz != nil {
+		if err := cw.gz.Flush(); err != nil {
+			zap.L().Error("can't flush compressed response", zap.Error(err))
+		}
+	} else {
+		cw.flushed = true
+		cw.writeHeader()
+		if cw.buf.Len() > 0 {
+			if _, err := cw.ResponseWriter.Write(cw.buf.Bytes()); err != nil {
+				zap.L().Error("can't flush response", zap.Error(err))
+			}
+			cw.buf.Reset()
+		}
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressWriter) finish() error {
+	if cw.gz != nil {
+		return cw.gz.Close()
+	}
+
+	cw.writeHeader()
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	return err
+}
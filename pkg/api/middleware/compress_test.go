@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		acceptEncoding string
+		wantCompressed bool
+	}{
+		{
+			name:           "small response",
+			body:           `{"short_path":["SFO","EWR"]}`,
+			acceptEncoding: "gzip, deflate",
+		},
+		{
+			name:           "large response",
+			body:           `{"full_path":["` + strings.Repeat(`SFO","`, 500) + `EWR"]}`,
+			acceptEncoding: "gzip, deflate",
+			wantCompressed: true,
+		},
+		{
+			name: "gzip not accepted",
+			body: `{"full_path":["` + strings.Repeat(`SFO","`, 500) + `EWR"]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Compress(5, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				// Write in chunks to cover switching to gzip mid-response.
+				for body := test.body; len(body) > 0; {
+					n := 100
+					if n > len(body) {
+						n = len(body)
+					}
+					_, _ = io.WriteString(w, body[:n])
+					body = body[n:]
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "http://example.com/test", nil)
+			req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			if !test.wantCompressed {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+				assert.Equal(t, test.body, w.Body.String())
+				return
+			}
+
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Less(t, w.Body.Len(), len(test.body))
+
+			gz, err := gzip.NewReader(w.Body)
+			assert.NoError(t, err)
+			body, err := io.ReadAll(gz)
+			assert.NoError(t, err)
+			assert.Equal(t, test.body, string(body))
+		})
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	body := `{"full_path":["` + strings.Repeat(`SFO","`, 500) + `EWR"]}`
+	handler := Compress(gzip.NoCompression, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest("GET", "http://example.com/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestCompressEmptyResponse(t *testing.T) {
+	handler := Compress(5, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest("GET", "http://example.com/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestCompressFlush(t *testing.T) {
+	large := strings.Repeat("data: SFO\n\n", 200)
+	w := httptest.NewRecorder()
+
+	handler := Compress(5, 1024)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(rw, "data: start\n\n")
+		assert.NoError(t, http.NewResponseController(rw).Flush())
+
+		// The flushed part reached the client right away.
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "data: start\n\n", w.Body.String())
+
+		_, _ = io.WriteString(rw, large)
+	}))
+
+	req := httptest.NewRequest("GET", "http://example.com/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, req)
+
+	// Once flushed, the response isn't compressed even if it grows large.
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "data: start\n\n"+large, w.Body.String())
+}
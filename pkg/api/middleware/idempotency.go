@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client chosen
+	// key of a mutation.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry. The
+// response to the first request with a key is stored for ttl, and requests
+// repeating the key within that time get the stored response instead of being
+// handled again. At most size responses are stored, once there are more the
+// oldest ones are dropped early.
+//
+// Keys are scoped to the client address, as set by middleware.RealIP, so
+// clients can't replay each other's responses by guessing their keys.
+//
+// A key reused for a different request (method, path or body) is rejected with
+// 422, a key whose first request is still being handled with 409. Responses
+// with a 5xx status aren't stored, so the request can be retried. Requests
+// without the header are passed through.
+func Idempotency(ttl time.Duration, size int) func(next http.Handler) http.Handler {
+	store := &idempotencyStore{ttl: ttl, size: size, entries: make(map[string]*idempotencyEntry), done: list.New()}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key = clientAddress(r) + " " + key
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				response.WriteJSONResponse(w, r, http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := requestFingerprint(r, body)
+			entry, ok := store.begin(key, fingerprint)
+			switch {
+			case !ok:
+				response.WriteJSONResponse(w, r, http.StatusConflict, response.ErrorResponse{Error: "request with this Idempotency-Key is in progress"})
+				return
+			case entry == nil:
+			case entry.fingerprint != fingerprint:
+				response.WriteJSONResponse(w, r, http.StatusUnprocessableEntity, response.ErrorResponse{Error: "Idempotency-Key reused for a different request"})
+				return
+			default:
+				entry.replay(w)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// Don't block retries of a request which panicked.
+				if !completed {
+					store.forget(key)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			completed = true
+
+			store.finish(key, fingerprint, rw)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientAddress returns the host of the remote address of the request.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func requestFingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	_, _ = h.Write(body)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// idempotencyStore keeps the responses by their idempotency key. The completed
+// entries are listed in done in the order they expire, so expired entries,
+// and the oldest ones once there are too many, are removed from its front.
+type idempotencyStore struct {
+	ttl  time.Duration
+	size int
+
+	lock    sync.Mutex
+	entries map[string]*idempotencyEntry
+	done    *list.List
+}
+
+type idempotencyEntry struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	// element is the entry in idempotencyStore.done, it's nil while the
+	// request is in progress.
+	element *list.Element
+	expires time.Time
+
+	code   int
+	header http.Header
+	body   []byte
+}
+
+// begin returns the completed entry of the key, or nil if the key is new, in
+// which case it's marked as in progress. It returns false if the key is
+// already in progress.
+func (s *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (*idempotencyEntry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeExpired(time.Now())
+
+	if entry, ok := s.entries[key]; ok {
+		if entry.element == nil {
+			return nil, false
+		}
+		return entry, true
+	}
+
+	s.entries[key] = &idempotencyEntry{key: key, fingerprint: fingerprint}
+
+	return nil, true
+}
+
+// finish stores the recorded response of the key, or forgets the key if the
+// request failed on the server side.
+func (s *idempotencyStore) finish(key string, fingerprint [sha256.Size]byte, rw *recordingWriter) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if rw.status() >= http.StatusInternalServerError {
+		delete(s.entries, key)
+		return
+	}
+
+	for s.done.Len() > 0 && s.done.Len() >= s.size {
+		s.remove(s.done.Front())
+	}
+
+	entry := &idempotencyEntry{
+		key:         key,
+		fingerprint: fingerprint,
+		expires:     time.Now().Add(s.ttl),
+		code:        rw.status(),
+		header:      rw.header,
+		body:        rw.body.Bytes(),
+	}
+	entry.element = s.done.PushBack(entry)
+	s.entries[key] = entry
+}
+
+func (s *idempotencyStore) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.entries, key)
+}
+
+// removeExpired removes the completed entries which expired before now. The
+// caller must hold the lock.
+func (s *idempotencyStore) removeExpired(now time.Time) {
+	for element := s.done.Front(); element != nil; element = s.done.Front() {
+		if now.Before(element.Value.(*idempotencyEntry).expires) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove removes a completed entry. The caller must hold the lock.
+func (s *idempotencyStore) remove(element *list.Element) {
+	entry := s.done.Remove(element).(*idempotencyEntry)
+	delete(s.entries, entry.key)
+}
+
+func (e *idempotencyEntry) replay(w http.ResponseWriter) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(e.code)
+	_, _ = w.Write(e.body)
+}
+
+// recordingWriter passes the response through and keeps a copy of it. The
+// headers are copied before they're passed on, so headers added by the outer
+// middlewares while writing, like Content-Encoding, aren't replayed.
+type recordingWriter struct {
+	http.ResponseWriter
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.code == 0 {
+		rw.code = code
+		rw.header = rw.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush passes the flush on, so streamed responses reach the client while
+// they're recorded.
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *recordingWriter) status() int {
+	if rw.code == 0 {
+		return http.StatusOK
+	}
+	return rw.code
+}
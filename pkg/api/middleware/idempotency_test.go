@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(time.Hour, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"call":%d}`, n)
+	}))
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("a", `[["SFO", "ATL"]]`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"call":1}`, w.Body.String())
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+
+	// The retry gets the original response without calling the handler.
+	w = do("a", `[["SFO", "ATL"]]`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"call":1}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(1), calls.Load())
+
+	w = do("a", `[["SFO", "EWR"]]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, int32(1), calls.Load())
+
+	w = do("b", `[["SFO", "ATL"]]`)
+	assert.Equal(t, `{"call":2}`, w.Body.String())
+
+	w = do("", `[["SFO", "ATL"]]`)
+	assert.Equal(t, `{"call":3}`, w.Body.String())
+	w = do("", `[["SFO", "ATL"]]`)
+	assert.Equal(t, `{"call":4}`, w.Body.String())
+}
+
+func TestIdempotencyFlush(t *testing.T) {
+	w := httptest.NewRecorder()
+	handler := Idempotency(time.Hour, 100)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(rw, "event: progress\n\n")
+		assert.NoError(t, http.NewResponseController(rw).Flush())
+		assert.True(t, w.Flushed)
+		_, _ = fmt.Fprint(rw, "event: done\n\n")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", strings.NewReader(`[["SFO", "ATL"]]`))
+	req.Header.Set(IdempotencyKeyHeader, "a")
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "event: progress\n\nevent: done\n\n", w.Body.String())
+
+	// The flushed parts are recorded as well.
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", strings.NewReader(`[["SFO", "ATL"]]`))
+	req.Header.Set(IdempotencyKeyHeader, "a")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "event: progress\n\nevent: done\n\n", w.Body.String())
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	var calls int
+	handler := Idempotency(time.Millisecond, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", nil)
+		req.Header.Set(IdempotencyKeyHeader, "a")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	var calls int
+	handler := Idempotency(time.Hour, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	// A failed request isn't stored, so it can be retried with the same key.
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", nil)
+		req.Header.Set(IdempotencyKeyHeader, "a")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Idempotency(time.Hour, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", nil)
+		req.Header.Set(IdempotencyKeyHeader, "a")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest())
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	<-done
+}
+
+func TestIdempotencySize(t *testing.T) {
+	var calls int
+	handler := Idempotency(time.Hour, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	// The response to "a" is dropped to make room for the one to "c".
+	for _, key := range []string{"a", "b", "c", "b", "c", "a"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", nil)
+		req.Header.Set(IdempotencyKeyHeader, key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotencyClients(t *testing.T) {
+	var calls int
+	handler := Idempotency(time.Hour, 100)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for _, remoteAddr := range []string{"192.0.2.1:1234", "192.0.2.1:5678", "192.0.2.2:1234"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/graph/edges", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(IdempotencyKeyHeader, "a")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Only the second request of the first client is replayed, the port of
+	// the client doesn't matter.
+	assert.Equal(t, 2, calls)
+}
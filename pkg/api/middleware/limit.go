@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"net/http"
+	"time"
+)
+
+// Limit allows at most limit requests to be handled at the same time. Further
+// requests wait up to wait for a slot to become free, and are rejected with
+// 503 if none does, so a burst of expensive requests can't overload the
+// server. The wait is cut short when the client goes away.
+func Limit(limit int, wait time.Duration) func(next http.Handler) http.Handler {
+	slots := make(chan struct{}, limit)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !acquire(r, slots, wait) {
+				w.Header().Set("Retry-After", "1")
+				response.WriteJSONResponse(w, r, http.StatusServiceUnavailable, response.ErrorResponse{Error: "server busy"})
+				return
+			}
+			defer func() {
+				<-slots
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func acquire(r *http.Request, slots chan struct{}, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	const limit = 3
+
+	tests := []struct {
+		name     string
+		wait     time.Duration
+		release  bool
+		wantCode int
+	}{
+		{
+			name:     "rejected immediately",
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "rejected after waiting",
+			wait:     10 * time.Millisecond,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "slot freed while waiting",
+			wait:     time.Minute,
+			release:  true,
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			started := make(chan struct{}, limit)
+			release := make(chan struct{})
+			handler := Limit(limit, test.wait)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/busy" {
+					started <- struct{}{}
+					<-release
+				}
+			}))
+
+			var wg sync.WaitGroup
+			for i := 0; i < limit; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/busy", nil))
+				}()
+			}
+			for i := 0; i < limit; i++ {
+				<-started
+			}
+
+			if test.release {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					release <- struct{}{}
+				}()
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/calculate", nil))
+			assert.Equal(t, test.wantCode, w.Code)
+			if test.wantCode == http.StatusServiceUnavailable {
+				assert.Equal(t, `{"error":"server busy"}`+"\n", w.Body.String())
+			}
+
+			close(release)
+			wg.Wait()
+
+			// All slots are free again.
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/calculate", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
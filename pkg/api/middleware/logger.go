@@ -2,12 +2,40 @@ package middleware
 
 import (
 	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 	"net/http"
 	"time"
 )
 
+// latencyBuckets are the upper bounds of the latency buckets of the access
+// logs, so dashboards can count the requests per bucket instead of computing
+// percentiles of the raw latencies.
+var latencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// latencyBucket returns the upper bound of the smallest bucket holding the
+// latency, e.g. "100ms", or "+Inf" if it exceeds all of them.
+func latencyBucket(latency time.Duration) string {
+	for _, bound := range latencyBuckets {
+		if latency <= bound {
+			return bound.String()
+		}
+	}
+	return "+Inf"
+}
+
+// Logger writes a structured access log entry for each request, with its
+// status, response size, latency and latency bucket, and the chi route pattern
+// it matched, e.g. "/v1/graph/path". The pattern groups requests by endpoint
+// regardless of their parameters. It's empty if no route matched.
 func Logger(logger *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -15,11 +43,18 @@ func Logger(logger *zap.Logger) func(next http.Handler) http.Handler {
 			t1 := time.Now()
 			defer func() {
 				status := ww.Status()
+				elapsed := time.Since(t1)
+				var route string
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					route = rctx.RoutePattern()
+				}
 				reqLogger := logger.With(
 					zap.String("proto", r.Proto),
 					zap.String("path", r.URL.Path),
+					zap.String("route", route),
 					zap.String("requestID", middleware.GetReqID(r.Context())),
-					zap.Duration("elapsed", time.Since(t1)),
+					zap.Duration("elapsed", elapsed),
+					zap.String("latencyBucket", latencyBucket(elapsed)),
 					zap.Int("status", ww.Status()),
 					zap.Int("size", ww.BytesWritten()),
 				)
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		latency    time.Duration
+		wantBucket string
+	}{
+		{latency: 0, wantBucket: "10ms"},
+		{latency: 10 * time.Millisecond, wantBucket: "10ms"},
+		{latency: 11 * time.Millisecond, wantBucket: "50ms"},
+		{latency: 700 * time.Millisecond, wantBucket: "1s"},
+		{latency: time.Minute, wantBucket: "+Inf"},
+	}
+	for _, test := range tests {
+		t.Run(test.latency.String(), func(t *testing.T) {
+			assert.Equal(t, test.wantBucket, latencyBucket(test.latency))
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	r := chi.NewRouter()
+	r.Use(Logger(zap.New(core)))
+	r.Route("/v1", func(r chi.Router) {
+		r.Get("/airports/{code}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("SFO"))
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/airports/SFO", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "202 OK", entries[0].Message)
+	assert.Equal(t, "/v1/airports/{code}", fields["route"])
+	assert.Equal(t, "/v1/airports/SFO", fields["path"])
+	assert.Equal(t, int64(http.StatusAccepted), fields["status"])
+	assert.Equal(t, int64(3), fields["size"])
+	assert.NotEmpty(t, fields["latencyBucket"])
+	assert.Contains(t, fields, "elapsed")
+
+	fields = entries[1].ContextMap()
+	assert.Equal(t, "404 Client error", entries[1].Message)
+	assert.Equal(t, "", fields["route"])
+}
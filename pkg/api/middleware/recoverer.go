@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"artemb/flights-path/pkg/api/response"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recoverer recovers from panics in the handlers, logs the panic along with
+// its stack trace and answers with 500. The panic detail is only part of the
+// response in debug mode, otherwise the client gets the generic internal
+// server error message, so internals like file paths or values don't leak.
+//
+// Panics with http.ErrAbortHandler are passed on, since they're the way to
+// abort a response on purpose.
+func Recoverer(logger *zap.Logger, debugMode bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				logger.Error("panic in handler",
+					zap.String("path", r.URL.Path),
+					zap.Any("panic", rvr),
+					zap.ByteString("stack", debug.Stack()),
+				)
+
+				message := response.MsgInternalServerError
+				if debugMode {
+					message = fmt.Sprintf("panic: %v", rvr)
+				}
+				response.WriteJSONResponse(w, r, http.StatusInternalServerError, response.ErrorResponse{Error: message})
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverer(t *testing.T) {
+	tests := []struct {
+		name         string
+		debug        bool
+		wantResponse string
+	}{
+		{
+			name:         "Production",
+			wantResponse: `{"error":"internal server error"}`,
+		},
+		{
+			name:         "Debug",
+			debug:        true,
+			wantResponse: `{"error":"panic: secret at /srv/app/graph.go"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.ErrorLevel)
+			handler := Recoverer(zap.New(core), test.debug)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("secret at /srv/app/graph.go")
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calculate", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+
+			// The full detail is logged in both modes.
+			entries := logs.AllUntimed()
+			assert.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+			assert.Equal(t, "secret at /srv/app/graph.go", fields["panic"])
+			assert.Contains(t, fields["stack"], "recoverer_test.go")
+		})
+	}
+}
+
+func TestRecovererAbortHandler(t *testing.T) {
+	handler := Recoverer(zap.NewNop(), true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calculate", nil))
+	})
+}
@@ -1,5 +1,64 @@
 package response
 
+import (
+	"net/http"
+	"strings"
+)
+
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Code is a machine-readable error code like "NO_PATH". It's only part of
+	// the structured errors of V2, and derived from the HTTP status if empty.
+	Code string `json:"-"`
+	// Starts lists the possible start airports of an "AMBIGUOUS_START" or
+	// "AMBIGUOUS_ROUTE" error. Like Code, it's only part of the structured
+	// errors.
+	Starts []string `json:"-"`
+	// Airport names the airport of an "UNKNOWN_AIRPORT" error. Like Code, it's
+	// only part of the structured errors.
+	Airport string `json:"-"`
+	// Fields lists the invalid parts of the payload of a "VALIDATION" error.
+	// Like Code, it's only part of the structured errors.
+	Fields []FieldError `json:"-"`
+	// RequestID is filled in by WriteJSONResponse, so errors reported by
+	// clients can be correlated with the logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// StructuredErrorResponse is the error shape of V2, which WriteJSONResponse
+// writes instead of ErrorResponse for V2 requests:
+//
+//	{"error":{"code":"BAD_REQUEST","message":"wrong payload"}}
+type StructuredErrorResponse struct {
+	Error     StructuredError `json:"error"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+type StructuredError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Starts  []string     `json:"starts,omitempty"`
+	Airport string       `json:"airport,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError reports why the element at Index of the payload, e.g. a segment,
+// is invalid.
+type FieldError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// structured converts the error to the V2 shape, deriving a missing code from
+// the HTTP status, e.g. "NOT_FOUND" for 404.
+func (e ErrorResponse) structured(status int) StructuredErrorResponse {
+	code := e.Code
+	if code == "" {
+		code = strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	}
+
+	return StructuredErrorResponse{
+		Error:     StructuredError{Code: code, Message: e.Error, Starts: e.Starts, Airport: e.Airport, Fields: e.Fields},
+		RequestID: e.RequestID,
+	}
 }
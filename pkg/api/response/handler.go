@@ -2,6 +2,7 @@ package response
 
 import (
 	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 	"io"
 	"net/http"
@@ -9,6 +10,13 @@ import (
 
 const (
 	MsgInternalServerError = "internal server error"
+
+	// ContentTypeJSON is the content type of WriteJSONResponse.
+	ContentTypeJSON = "application/json"
+
+	// StatusClientClosedRequest is the non-standard status code used by nginx
+	// for requests cancelled by the client before the response was written.
+	StatusClientClosedRequest = 499
 )
 
 func WriteJSONInternalServerError(w http.ResponseWriter, r *http.Request, err error) {
@@ -16,8 +24,25 @@ func WriteJSONInternalServerError(w http.ResponseWriter, r *http.Request, err er
 	WriteJSONResponse(w, r, http.StatusInternalServerError, ErrorResponse{Error: MsgInternalServerError})
 }
 
-func WriteJSONResponse(w http.ResponseWriter, _ *http.Request, code int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+// WriteJSONResponse writes data as JSON. ErrorResponse values get the request
+// ID and are converted to the structured error shape for V2 requests.
+func WriteJSONResponse(w http.ResponseWriter, r *http.Request, code int, data interface{}) {
+	requestID := middleware.GetReqID(r.Context())
+	if requestID != "" {
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+	}
+
+	if e, ok := data.(ErrorResponse); ok {
+		if e.RequestID == "" {
+			e.RequestID = requestID
+		}
+		data = e
+		if Version(r.Context()) >= V2 {
+			data = e.structured(code)
+		}
+	}
+
+	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(code)
 	if data == nil {
@@ -32,14 +57,21 @@ func WriteJSONResponse(w http.ResponseWriter, _ *http.Request, code int, data in
 	}
 }
 
+func WriteResponse(w http.ResponseWriter, _ *http.Request, code int, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	if _, err := w.Write(data); err != nil {
+		zap.L().Error("can't write response", zap.Error(err))
+	}
+}
+
 func HandleNotFoundError(w http.ResponseWriter, r *http.Request) {
-	WriteJSONResponse(w, r, http.StatusNotFound, struct {
-		Error string `json:"error"`
-	}{http.StatusText(http.StatusNotFound)})
+	WriteJSONResponse(w, r, http.StatusNotFound, ErrorResponse{Error: http.StatusText(http.StatusNotFound)})
 }
 
 func HandleNoContentResponse(w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", ContentTypeJSON)
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(http.StatusNoContent)
 }
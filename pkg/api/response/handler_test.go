@@ -0,0 +1,110 @@
+package response
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSONResponseRequestID(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSONResponse(w, r, http.StatusBadRequest, ErrorResponse{Error: "wrong payload"})
+	}))
+
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{
+			name:          "request ID from the client",
+			requestID:     "client-id-1",
+			wantRequestID: "client-id-1",
+		},
+		{
+			name: "generated request ID",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/test", nil)
+			if test.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, test.requestID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var res ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, "wrong payload", res.Error)
+			assert.NotEmpty(t, res.RequestID)
+			assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), res.RequestID)
+			if test.wantRequestID != "" {
+				assert.Equal(t, test.wantRequestID, res.RequestID)
+			}
+		})
+	}
+}
+
+func TestWriteJSONResponseWithoutRequestID(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/test", nil)
+	w := httptest.NewRecorder()
+	WriteJSONResponse(w, req, http.StatusBadRequest, ErrorResponse{Error: "wrong payload"})
+
+	assert.Equal(t, `{"error":"wrong payload"}`+"\n", w.Body.String())
+	assert.Empty(t, w.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestWriteJSONResponseStructuredError(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      int
+		err          ErrorResponse
+		wantResponse string
+	}{
+		{
+			name:         "v1",
+			version:      V1,
+			err:          ErrorResponse{Error: "can't find route", Code: "NO_PATH"},
+			wantResponse: `{"error":"can't find route"}`,
+		},
+		{
+			name:         "v2 with code",
+			version:      V2,
+			err:          ErrorResponse{Error: "can't find route", Code: "NO_PATH"},
+			wantResponse: `{"error":{"code":"NO_PATH","message":"can't find route"}}`,
+		},
+		{
+			name:         "v2 code from status",
+			version:      V2,
+			err:          ErrorResponse{Error: "wrong payload"},
+			wantResponse: `{"error":{"code":"BAD_REQUEST","message":"wrong payload"}}`,
+		},
+		{
+			name:         "v1 with starts",
+			version:      V1,
+			err:          ErrorResponse{Error: "ambiguous start", Code: "AMBIGUOUS_START", Starts: []string{"EWR", "SFO"}},
+			wantResponse: `{"error":"ambiguous start"}`,
+		},
+		{
+			name:         "v2 with starts",
+			version:      V2,
+			err:          ErrorResponse{Error: "ambiguous start", Code: "AMBIGUOUS_START", Starts: []string{"EWR", "SFO"}},
+			wantResponse: `{"error":{"code":"AMBIGUOUS_START","message":"ambiguous start","starts":["EWR","SFO"]}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := WithVersion(test.version)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				WriteJSONResponse(w, r, http.StatusBadRequest, test.err)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/test", nil))
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
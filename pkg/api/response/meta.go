@@ -0,0 +1,14 @@
+package response
+
+import "time"
+
+// Meta carries details about how the response was produced. It's added to
+// responses on request, for ad-hoc debugging of the client side performance.
+type Meta struct {
+	ElapsedMs int64 `json:"elapsed_ms"`
+}
+
+// NewMeta returns the meta of a response whose computation started at start.
+func NewMeta(start time.Time) *Meta {
+	return &Meta{ElapsedMs: time.Since(start).Milliseconds()}
+}
@@ -0,0 +1,33 @@
+package response
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	V1 = 1
+	V2 = 2
+)
+
+type versionKey struct{}
+
+// WithVersion is a middleware storing the API version of the routes it's used
+// on in the request context, so responses can be shaped accordingly.
+func WithVersion(version int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), versionKey{}, version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Version returns the API version of the request, which is V1 unless set by
+// WithVersion.
+func Version(ctx context.Context) int {
+	if version, ok := ctx.Value(versionKey{}).(int); ok {
+		return version
+	}
+	return V1
+}
@@ -2,51 +2,232 @@ package routes
 
 import (
 	"artemb/flights-path/pkg/api/controller"
+	mw "artemb/flights-path/pkg/api/middleware"
+	"artemb/flights-path/pkg/api/response"
+	"artemb/flights-path/pkg/cache"
 	"artemb/flights-path/pkg/config"
+	"artemb/flights-path/pkg/graph"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"time"
 )
 
 const (
-	baseRoute = "/"
-	calculate = "/calculate"
+	baseRoute  = "/"
+	v1Route    = "/v1"
+	v2Route    = "/v2"
+	calculate  = "/calculate"
+	graphRoute = "/graph"
+	export     = "/export"
+	edges      = "/edges"
+	path       = "/path"
+	stats      = "/stats"
+	healthz    = "/healthz"
+	readyz     = "/readyz"
+)
+
+var (
+	// graphContentTypes are the content types of the segments accepted by
+	// the graph routes.
+	graphContentTypes = []string{"application/json", "multipart/form-data"}
+	// searchContentTypes are the content types of the segments accepted by
+	// the search, which also parses text/plain arrow lines.
+	searchContentTypes = []string{"application/json", "multipart/form-data", "text/plain"}
 )
 
 type dependencies struct {
-	logger *zap.Logger
+	logger      *zap.Logger
+	searchCache *cache.LRU[string, controller.SearchResponse]
+	network     graph.Graph[string, string]
+	strict      bool
+	allowEmpty  bool
+	rejectTies  bool
+	fastChain   bool
+	timeout     time.Duration
+	routes      graph.Graph[string, string]
+	routesStore graph.Store[string, string]
+	graphStats  bool
+	// idempotency is shared by all versions of the routes, so a key can't be
+	// reused through another version.
+	idempotency func(http.Handler) http.Handler
+	// searchLimit limits the concurrent searches of all versions together.
+	// It's nil if unlimited.
+	searchLimit func(http.Handler) http.Handler
 }
 
+// MakeRoutes mounts the API under a version prefix, e.g. /v1/calculate. The
+// unversioned routes are kept as an alias of v1 for existing clients. All
+// versions share the same controllers, and thereby the persistent graph.
+//
+// The routes are mounted under the configured base path, e.g.
+// /flights/v1/calculate for "/flights".
 func MakeRoutes(router chi.Router, cfg *config.Config, logger *zap.Logger) error {
-	return makeV1Routes(router, cfg, logger)
-}
-
-func makeV1Routes(router chi.Router, cfg *config.Config, logger *zap.Logger) error {
 	deps, err := makeDeps(cfg, logger)
 	if err != nil {
 		return err
 	}
 
 	searchController := makeSearchController(deps)
-	router.
-		Route(baseRoute, func(r chi.Router) {
-			r.Route(calculate, makeSearchRoutes(searchController))
-		})
+	graphController := makeGraphController(deps)
+	routes := func(r chi.Router) {
+		r.Route(calculate, makeSearchRoutes(searchController, deps.searchLimit))
+		r.Route(graphRoute, makeGraphRoutes(graphController, deps.graphStats, deps.idempotency))
+	}
+
+	versions := func(r chi.Router) {
+		r.Route(v1Route, makeVersionRoutes(response.V1, routes))
+		r.Route(v2Route, makeVersionRoutes(response.V2, routes))
+		r.Group(routes)
+	}
+
+	if cfg.Api.BasePath == "" || cfg.Api.BasePath == baseRoute {
+		versions(router)
+	} else {
+		router.Route(cfg.Api.BasePath, versions)
+	}
+
+	// The probes are called by the orchestrator directly, not through the
+	// gateway, so they're mounted at the root.
+	healthController := makeHealthController(deps)
+	router.Get(healthz, healthController.Live)
+	router.Get(readyz, healthController.Ready)
 
 	return nil
 }
 
-func makeSearchRoutes(ctrl *controller.SearchController) func(r chi.Router) {
+func makeVersionRoutes(version int, routes func(r chi.Router)) func(r chi.Router) {
 	return func(r chi.Router) {
+		r.Use(response.WithVersion(version))
+		routes(r)
+	}
+}
+
+func makeSearchRoutes(ctrl *controller.SearchController, limit func(http.Handler) http.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		// Unsupported payloads are rejected before waiting for a slot.
+		r.Use(middleware.AllowContentType(searchContentTypes...))
+		if limit != nil {
+			r.Use(limit)
+		}
 		r.Get(baseRoute, ctrl.Search)
+		r.Post(baseRoute, ctrl.Search)
+	}
+}
+
+func makeGraphRoutes(ctrl *controller.GraphController, withStats bool, idempotency func(http.Handler) http.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(middleware.AllowContentType(graphContentTypes...))
+		r.Post(export, ctrl.Export)
+		r.With(idempotency).Post(edges, ctrl.AddEdges)
+		r.Get(path, ctrl.Path)
+		if withStats {
+			r.Get(stats, ctrl.Stats)
+		}
 	}
 }
 
 func makeSearchController(deps *dependencies) *controller.SearchController {
 	return &controller.SearchController{
+		Logger:     deps.logger,
+		Cache:      deps.searchCache,
+		Network:    deps.network,
+		Strict:     deps.strict,
+		AllowEmpty: deps.allowEmpty,
+		RejectTies: deps.rejectTies,
+		FastChain:  deps.fastChain,
+		Timeout:    deps.timeout,
+	}
+}
+
+func makeGraphController(deps *dependencies) *controller.GraphController {
+	return &controller.GraphController{
+		Logger: deps.logger,
+		Routes: deps.routes,
+		Store:  deps.routesStore,
+	}
+}
+
+func makeHealthController(deps *dependencies) *controller.HealthController {
+	return &controller.HealthController{
 		Logger: deps.logger,
+		Store:  deps.routesStore,
 	}
 }
 
 func makeDeps(cfg *config.Config, logger *zap.Logger) (*dependencies, error) {
-	return &dependencies{logger: logger}, nil
+	deps := &dependencies{
+		logger:     logger,
+		strict:     cfg.Api.Strict,
+		allowEmpty: cfg.Api.AllowEmptySegments,
+		rejectTies: cfg.Api.TieBreak == config.TieBreakError,
+		fastChain:  cfg.Api.FastChainMode,
+		timeout:    cfg.Api.Timeouts.Search,
+	}
+
+	store, err := newRoutesStore(cfg.Graph)
+	if err != nil {
+		return nil, err
+	}
+	deps.routesStore = store
+	deps.routes = graph.NewWithStore(graph.StringHash, store, graph.Directed(), graph.AutoCreateVertices())
+	deps.graphStats = cfg.Graph.Stats
+	deps.idempotency = mw.Idempotency(cfg.Graph.IdempotencyTTL, cfg.Graph.IdempotencySize)
+
+	if cfg.Api.Concurrency.Limit > 0 {
+		deps.searchLimit = mw.Limit(cfg.Api.Concurrency.Limit, cfg.Api.Concurrency.Wait)
+	}
+
+	if cfg.Api.Cache.Enabled {
+		deps.searchCache = cache.NewLRU[string, controller.SearchResponse](cfg.Api.Cache.Size)
+	}
+
+	if cfg.Network.File != "" {
+		network, err := loadNetwork(cfg.Network.File)
+		if err != nil {
+			return nil, err
+		}
+		deps.network = network
+
+		order, _ := network.Order()
+		size, _ := network.Size()
+		logger.Info("Network loaded", zap.String("file", cfg.Network.File), zap.Int("airports", order), zap.Int("connections", size))
+	}
+
+	return deps, nil
+}
+
+// newRoutesStore creates the configured store of the persistent graph.
+func newRoutesStore(cfg config.Graph) (graph.Store[string, string], error) {
+	switch cfg.Store {
+	case config.StoreMemory:
+		return graph.NewMemoryStore[string, string](), nil
+	default:
+		return nil, fmt.Errorf("unknown graph store %q", cfg.Store)
+	}
+}
+
+func loadNetwork(file string) (graph.Graph[string, string], error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open network: %w", err)
+	}
+	defer f.Close()
+
+	store := graph.NewMemoryStore[string, string]()
+	if err := graph.LoadEdgeList(f, store); err != nil {
+		return nil, fmt.Errorf("failed to load network %s: %w", file, err)
+	}
+
+	// The network never changes once loaded, so it can be read concurrently
+	// without locking.
+	snapshot, err := graph.NewImmutableStore(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load network %s: %w", file, err)
+	}
+
+	return graph.NewWithStore(graph.StringHash, snapshot, graph.Directed()), nil
 }
@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"artemb/flights-path/pkg/api/controller"
+	"artemb/flights-path/pkg/config"
+	"bytes"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadNetwork(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "network.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("SFO ATL\nATL EWR\n"), 0o600))
+
+	network, err := loadNetwork(file)
+	assert.NoError(t, err)
+
+	_, err = network.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+	_, err = network.Edge("ATL", "EWR")
+	assert.NoError(t, err)
+
+	size, err := network.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	_, err = loadNetwork(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestGraphEdgesAndPath(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/graph/edges", `[["SFO", "ATL"], ["ATL", "EWR"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"added":2}`+"\n", w.Body.String())
+
+	w = do(http.MethodGet, "/graph/path?from=SFO&to=EWR", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"path":["SFO","ATL","EWR"]}`+"\n", w.Body.String())
+
+	// Later requests extend the same graph, known segments are ignored.
+	w = do(http.MethodPost, "/graph/edges", `[["SFO", "ATL"], ["SFO", "EWR"], ["EWR", "IND"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"added":2}`+"\n", w.Body.String())
+
+	w = do(http.MethodGet, "/graph/path?from=SFO&to=IND", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"path":["SFO","EWR","IND"]}`+"\n", w.Body.String())
+
+	w = do(http.MethodGet, "/graph/path?from=IND&to=SFO", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"can't find route"}`+"\n", w.Body.String())
+
+	w = do(http.MethodGet, "/graph/path?from=SFO&to=LAX", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `{"error":"unknown airport"}`+"\n", w.Body.String())
+
+	w = do(http.MethodGet, "/graph/path?from=SFO", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGraphStats(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory, Stats: true}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	req := httptest.NewRequest(http.MethodPost, "/graph/edges", strings.NewReader(`[["SFO", "ATL"], ["ATL", "EWR"], ["SFO", "EWR"]]`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/graph/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"type":"memory","vertices":3,"edges":3}`+"\n", w.Body.String())
+
+	// The endpoint is only mounted when enabled.
+	router = chi.NewRouter()
+	cfg.Graph.Stats = false
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graph/stats", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGraphEdgesIdempotency(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory, Stats: true, IdempotencyTTL: time.Hour, IdempotencySize: 100}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	addEdges := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graph/edges", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "4f2b4c5e")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := addEdges(`[["SFO", "ATL"], ["ATL", "EWR"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"added":2}`+"\n", w.Body.String())
+
+	// Without the key, the retry would report no added segments.
+	w = addEdges(`[["SFO", "ATL"], ["ATL", "EWR"]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"added":2}`+"\n", w.Body.String())
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	w = addEdges(`[["EWR", "IND"]]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graph/stats", nil))
+	assert.Equal(t, `{"type":"memory","vertices":3,"edges":2}`+"\n", w.Body.String())
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	tests := []struct {
+		name         string
+		url          string
+		route        string
+		wantResponse string
+		wantCode     int
+	}{
+		{
+			name:         "unversioned alias",
+			url:          "/calculate",
+			route:        `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "v1",
+			url:          "/v1/calculate",
+			route:        `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantResponse: `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "v2",
+			url:          "/v2/calculate",
+			route:        `[["ATL", "EWR"], ["SFO", "ATL"]]`,
+			wantResponse: `{"shortest":["SFO","EWR"],"route":["SFO","ATL","EWR"],"single_chain":true}`,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "unversioned error",
+			url:          "/calculate",
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "v1 error",
+			url:          "/v1/calculate",
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":"each segment must have exactly two airports"}`,
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "v2 structured error",
+			url:          "/v2/calculate",
+			route:        `[["SFO"]]`,
+			wantResponse: `{"error":{"code":"VALIDATION","message":"each segment must have exactly two airports","fields":[{"index":0,"reason":"each segment must have exactly two airports"}]}}`,
+			wantCode:     http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, strings.NewReader(test.route))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+			assert.Equal(t, test.wantResponse+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestSearchUpload(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, err := mw.CreateFormFile(controller.SegmentsFormField, "segments.csv")
+			assert.NoError(t, err)
+			_, err = part.Write([]byte("ATL,EWR\nSFO,ATL\n"))
+			assert.NoError(t, err)
+			assert.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(method, "/calculate", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, `{"short_path":["SFO","EWR"],"full_path":["SFO","ATL","EWR"],"single_chain":true}`+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{BasePath: "/flights"}, Graph: config.Graph{Store: config.StoreMemory}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	tests := []struct {
+		url      string
+		wantCode int
+	}{
+		{url: "/flights/calculate", wantCode: http.StatusOK},
+		{url: "/flights/v1/calculate", wantCode: http.StatusOK},
+		{url: "/flights/v2/calculate", wantCode: http.StatusOK},
+		{url: "/calculate", wantCode: http.StatusNotFound},
+		{url: "/v1/calculate", wantCode: http.StatusNotFound},
+		{url: "/healthz", wantCode: http.StatusOK},
+		{url: "/readyz", wantCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.url, strings.NewReader(`[["SFO", "ATL"]]`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.wantCode, w.Code)
+		})
+	}
+}
+
+func TestVersionsShareGraph(t *testing.T) {
+	router := chi.NewRouter()
+	cfg := &config.Config{Api: &config.Api{}, Graph: config.Graph{Store: config.StoreMemory}}
+	assert.NoError(t, MakeRoutes(router, cfg, zap.NewNop()))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/graph/edges", strings.NewReader(`[["SFO", "ATL"]]`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/graph/path?from=SFO&to=ATL", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"path":["SFO","ATL"]}`+"\n", w.Body.String())
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats holds the hit and miss counters of a cache.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// LRU is a fixed-size cache safe for concurrent use. Once the cache is full,
+// adding a new key evicts the least recently used one.
+type LRU[K comparable, V any] struct {
+	lock  sync.Mutex
+	size  int
+	items map[K]*list.Element
+	order *list.List // front is the most recently used entry
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLRU creates a cache holding at most size entries.
+func NewLRU[K comparable, V any](size int) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// Get returns the value stored for the key and marks it as recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+
+	c.hits.Add(1)
+	c.order.MoveToFront(el)
+
+	return el.Value.(*entry[K, V]).value, true
+}
+
+// Add stores the value for the key, evicting the least recently used entry if
+// the cache is full.
+func (c *LRU[K, V]) Add(key K, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry[K, V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[K, V]).key)
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+}
+
+// Len returns the number of cached entries.
+func (c *LRU[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
+
+// Stats returns the hit and miss counters.
+func (c *LRU[K, V]) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
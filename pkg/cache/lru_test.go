@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	// "b" is the least recently used entry now.
+	c.Add("c", 3)
+	assert.Equal(t, 2, c.Len())
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	v, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	c.Add("c", 4)
+	v, _ = c.Get("c")
+	assert.Equal(t, 4, v)
+	assert.Equal(t, 2, c.Len())
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 1}, c.Stats())
+}
+
+func TestLRUZeroSize(t *testing.T) {
+	c := NewLRU[string, int](0)
+	c.Add("a", 1)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
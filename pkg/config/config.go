@@ -1,19 +1,112 @@
 package config
 
 import (
+	"compress/gzip"
+	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCompressionLevel  = 5
+	defaultReadTimeout       = 15 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 15 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+	defaultSearchTimeout     = 10 * time.Second
+	defaultIdempotencyTTL    = 24 * time.Hour
+	defaultIdempotencySize   = 10000
+	defaultBasePath          = "/"
+
+	fetchTimeout = 10 * time.Second
+)
+
+// StoreMemory keeps the graph in memory, so it's lost on restart.
+const StoreMemory = "memory"
+
+const (
+	// TieBreakFirstAlpha picks the route of the alphabetically first start
+	// airport when several routes are equally long.
+	TieBreakFirstAlpha = "first-alpha"
+	// TieBreakError rejects segments with several equally long routes.
+	TieBreakError = "error"
+)
+
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
 )
 
 type Config struct {
 	AppName string   `yaml:"appName"`
 	Api     *Api     `yaml:"api"`
 	Logging *Logging `yaml:"logging"`
+	Network Network  `yaml:"network"`
+	Graph   Graph    `yaml:"graph"`
 }
 type Api struct {
-	Port int  `yaml:"port"`
-	Cors Cors `yaml:"cors"`
+	Port int `yaml:"port"`
+	// BasePath is the URL prefix all routes are mounted under, e.g. "/flights"
+	// when deployed behind a gateway. It's "/" by default.
+	BasePath    string      `yaml:"basePath"`
+	Cors        Cors        `yaml:"cors"`
+	Cache       Cache       `yaml:"cache"`
+	Compression Compression `yaml:"compression"`
+	Timeouts    Timeouts    `yaml:"timeouts"`
+	Concurrency Concurrency `yaml:"concurrency"`
+	// Strict rejects duplicated segments in search requests instead of
+	// ignoring them. Requests can override it with the "strict" parameter.
+	Strict bool `yaml:"strict"`
+	// AllowEmptySegments answers searches with an empty list of segments with
+	// 204 No Content instead of rejecting them with 400.
+	AllowEmptySegments bool `yaml:"allowEmptySegments"`
+	// TieBreak decides between equally long routes found from different
+	// start airports, it's TieBreakFirstAlpha or TieBreakError. It's
+	// TieBreakFirstAlpha by default.
+	TieBreak string `yaml:"tieBreak"`
+	// FastChainMode assumes the segments of a search form a single chain and
+	// finds the route with one traversal. Searches of other segments are
+	// rejected with 400, so it's only suitable for clients sending clean
+	// itineraries.
+	FastChainMode bool `yaml:"fastChainMode"`
+	// Debug includes the detail of panics in the 500 responses. It should be
+	// off in production, where the detail is only logged.
+	Debug bool `yaml:"debug"`
+}
+
+// Timeouts configures the HTTP server timeouts, given as durations like "5s".
+// Missing values fall back to defaults, so connections are never held open
+// indefinitely by slow clients. Search limits the time spent on computing a
+// route.
+type Timeouts struct {
+	Read       time.Duration `yaml:"read"`
+	ReadHeader time.Duration `yaml:"readHeader"`
+	Write      time.Duration `yaml:"write"`
+	Idle       time.Duration `yaml:"idle"`
+	Search     time.Duration `yaml:"search"`
+}
+
+// Concurrency limits the number of searches computed at the same time. Up to
+// Limit searches run concurrently, further ones wait up to Wait for a free
+// slot and are rejected with 503 otherwise. A Limit of 0 disables the limit.
+type Concurrency struct {
+	Limit int           `yaml:"limit"`
+	Wait  time.Duration `yaml:"wait"`
+}
+
+type Cache struct {
+	Enabled bool `yaml:"enabled"`
+	Size    int  `yaml:"size"`
 }
 
 type Cors struct {
@@ -25,30 +118,339 @@ type Cors struct {
 	MaxAge           int      `yaml:"maxAge"`
 }
 
+// Compression configures gzip compression of responses. Level is a gzip level
+// from 1 (fastest) to 9 (best compression), or 0 to turn compression off. It's
+// 5 if missing. Responses smaller than MinSize bytes are not compressed.
+type Compression struct {
+	Level   *int `yaml:"level"`
+	MinSize int  `yaml:"minSize"`
+}
+
+// GzipLevel returns the configured level, or the default one if it's missing.
+func (c Compression) GzipLevel() int {
+	if c.Level == nil {
+		return defaultCompressionLevel
+	}
+	return *c.Level
+}
+
+// Network configures a reference flight network loaded at start-up. File is
+// the path of an edge list with one "SOURCE TARGET" pair per line.
+type Network struct {
+	File string `yaml:"file"`
+}
+
+// Graph configures the persistent graph which is updated with POST
+// /graph/edges and queried with GET /graph/path. Store selects the storage
+// backend, only StoreMemory is supported for now. Stats enables the GET
+// /graph/stats debug endpoint. IdempotencyTTL is how long the responses to
+// updates with an Idempotency-Key header are kept for replaying, a day by
+// default, and IdempotencySize how many of them are kept at most, 10000 by
+// default.
+type Graph struct {
+	Store           string        `yaml:"store"`
+	Stats           bool          `yaml:"stats"`
+	IdempotencyTTL  time.Duration `yaml:"idempotencyTTL"`
+	IdempotencySize int           `yaml:"idempotencySize"`
+}
+
+// Logging configures the logger. Encoding is EncodingJSON or EncodingConsole
+// (the default) and Output is "stdout" (the default), "stderr" or the path of
+// a file to append the logs to.
 type Logging struct {
-	Level string `yaml:"level"`
+	Level    string `yaml:"level"`
+	Encoding string `yaml:"encoding"`
+	Output   string `yaml:"output"`
+}
+
+// Validate checks the encoding and output of the logs.
+func (l *Logging) Validate() error {
+	if l.Encoding != EncodingJSON && l.Encoding != EncodingConsole {
+		return fmt.Errorf("encoding: unknown encoding %q", l.Encoding)
+	}
+
+	if strings.TrimSpace(l.Output) == "" {
+		return errors.New("output: empty output")
+	}
+
+	return nil
+}
+
+// Read loads the config from the given source or stops the program if that
+// fails. The source is a file path, "-" to read the config from stdin, or an
+// http(s):// URL to fetch it from a config server.
+func Read(source string) *Config {
+	return ReadAll([]string{source})
 }
 
-func Read(file string) *Config {
-	f, err := os.Open(file)
+// ReadAll loads the config layered from the given sources, like Read, e.g. a
+// base config followed by an overlay for the environment. Later sources
+// override the values of earlier ones: nested sections are merged key by key,
+// while lists like the allowed origins are replaced as a whole. The defaults
+// are filled in and the result is validated once all sources are merged, so a
+// source only needs to hold the values it changes.
+func ReadAll(sources []string) *Config {
+	cfg, err := loadAll(sources)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	d := yaml.NewDecoder(f)
+	return cfg
+}
 
+// ReadFrom decodes the YAML config from the reader, fills in the defaults and
+// validates the result.
+func ReadFrom(r io.Reader) (*Config, error) {
 	var cfg Config
 
-	err = d.Decode(&cfg)
+	err := decode(&cfg, r)
 	if err != nil {
-		_ = f.Close()
-		log.Fatal(err)
+		return nil, err
 	}
 
-	err = f.Close()
+	err = cfg.finish()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// decode decodes the YAML config from the reader into cfg. Values missing in
+// the YAML are left as they are, which merges the config into cfg.
+func decode(cfg *Config, r io.Reader) error {
+	return yaml.NewDecoder(r).Decode(cfg)
+}
+
+// finish fills in the defaults of the decoded config and validates it.
+func (c *Config) finish() error {
+	c.setDefaults()
+
+	return c.Validate()
+}
+
+func load(source string) (*Config, error) {
+	return loadAll([]string{source})
+}
+
+func loadAll(sources []string) (*Config, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no config source")
+	}
+
+	var cfg Config
+	for _, source := range sources {
+		if err := loadInto(&cfg, source); err != nil {
+			if len(sources) > 1 {
+				return nil, fmt.Errorf("%s: %w", source, err)
+			}
+			return nil, err
+		}
+	}
+
+	if err := cfg.finish(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// loadInto decodes the config from the given source into cfg.
+func loadInto(cfg *Config, source string) error {
+	r, err := open(source)
+	if err != nil {
+		return err
+	}
+
+	err = decode(cfg, r)
+	if err != nil {
+		_ = r.Close()
+		return err
+	}
+
+	return r.Close()
+}
+
+func open(source string) (io.ReadCloser, error) {
+	if source == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	client := http.Client{Timeout: fetchTimeout}
+	res, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", source, res.Status)
+	}
+
+	return res.Body, nil
+}
+
+// setDefaults fills in the optional values missing in the config file.
+func (c *Config) setDefaults() {
+	if c.Graph.Store == "" {
+		c.Graph.Store = StoreMemory
+	}
+	if c.Graph.IdempotencyTTL == 0 {
+		c.Graph.IdempotencyTTL = defaultIdempotencyTTL
+	}
+	if c.Graph.IdempotencySize == 0 {
+		c.Graph.IdempotencySize = defaultIdempotencySize
+	}
+
+	if c.Logging != nil {
+		if c.Logging.Encoding == "" {
+			c.Logging.Encoding = EncodingConsole
+		}
+		if c.Logging.Output == "" {
+			c.Logging.Output = OutputStdout
+		}
+	}
+
+	if c.Api == nil {
+		return
+	}
+
+	if c.Api.BasePath == "" {
+		c.Api.BasePath = defaultBasePath
+	}
+
+	if c.Api.TieBreak == "" {
+		c.Api.TieBreak = TieBreakFirstAlpha
+	}
+
+	timeouts := &c.Api.Timeouts
+	if timeouts.Read == 0 {
+		timeouts.Read = defaultReadTimeout
+	}
+	if timeouts.ReadHeader == 0 {
+		timeouts.ReadHeader = defaultReadHeaderTimeout
+	}
+	if timeouts.Write == 0 {
+		timeouts.Write = defaultWriteTimeout
+	}
+	if timeouts.Idle == 0 {
+		timeouts.Idle = defaultIdleTimeout
+	}
+	if timeouts.Search == 0 {
+		timeouts.Search = defaultSearchTimeout
+	}
+}
+
+// Validate checks the configuration for values which would make the service
+// misbehave or run insecurely.
+func (c *Config) Validate() error {
+	if c.Api == nil {
+		return errors.New("api: section is required")
+	}
+
+	if basePath := c.Api.BasePath; basePath != "" && (!strings.HasPrefix(basePath, "/") || (basePath != "/" && strings.HasSuffix(basePath, "/"))) {
+		return fmt.Errorf("api: basePath %q must start and mustn't end with a slash", basePath)
+	}
+
+	if err := c.Api.Cors.Validate(); err != nil {
+		return fmt.Errorf("api.cors: %w", err)
+	}
+
+	if c.Api.Cache.Enabled && c.Api.Cache.Size <= 0 {
+		return errors.New("api.cache: size must be positive")
+	}
+
+	if level := c.Api.Compression.GzipLevel(); level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return fmt.Errorf("api.compression: invalid level %d", level)
+	}
+
+	if c.Api.Compression.MinSize < 0 {
+		return errors.New("api.compression: minSize can't be negative")
+	}
+
+	timeouts := c.Api.Timeouts
+	if timeouts.Read < 0 || timeouts.ReadHeader < 0 || timeouts.Write < 0 || timeouts.Idle < 0 || timeouts.Search < 0 {
+		return errors.New("api.timeouts: timeouts can't be negative")
+	}
+
+	if c.Api.Concurrency.Limit < 0 || c.Api.Concurrency.Wait < 0 {
+		return errors.New("api.concurrency: limit and wait can't be negative")
+	}
+
+	if c.Api.TieBreak != TieBreakFirstAlpha && c.Api.TieBreak != TieBreakError {
+		return fmt.Errorf("api: unknown tieBreak %q", c.Api.TieBreak)
+	}
+
+	if c.Logging != nil {
+		if err := c.Logging.Validate(); err != nil {
+			return fmt.Errorf("logging: %w", err)
+		}
+	}
+
+	if c.Graph.Store != StoreMemory {
+		return fmt.Errorf("graph: unknown store %q", c.Graph.Store)
+	}
+
+	if c.Graph.IdempotencyTTL < 0 {
+		return errors.New("graph: idempotencyTTL can't be negative")
+	}
+	if c.Graph.IdempotencySize < 0 {
+		return errors.New("graph: idempotencySize can't be negative")
+	}
+
+	return nil
+}
+
+// Validate checks the max age and the allowed origins. Every origin must be
+// either "*" or an absolute "scheme://host[:port]" value, where the host may
+// contain a single "*" wildcard (e.g. "https://*.example.com") to match
+// subdomains.
+//
+// A "*" origin together with AllowCredentials is rejected: it would let any
+// site issue credentialed cross-origin requests on behalf of the user.
+func (c *Cors) Validate() error {
+	if c.MaxAge < 0 {
+		return errors.New("maxAge can't be negative")
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if strings.TrimSpace(origin) == "" {
+			return errors.New("allowedOrigins: empty origin")
+		}
+
+		if origin == "*" {
+			if c.AllowCredentials {
+				return errors.New("allowedOrigins: \"*\" can't be used with allowCredentials")
+			}
+			continue
+		}
+
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("allowedOrigins: %q has more than one wildcard", origin)
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("allowedOrigins: %q is not a valid origin", origin)
+		}
+	}
+
+	return nil
+}
+
+// Warnings returns the settings which are valid but likely unintended, so they
+// can be logged on start-up.
+func (c *Config) Warnings() []string {
+	var warnings []string
+
+	if c.Api != nil && len(c.Api.Cors.AllowedOrigins) > 0 && c.Api.Cors.MaxAge == 0 {
+		warnings = append(warnings, "api.cors: maxAge is 0, so browsers send a preflight request before every "+
+			"cross-origin request; consider caching preflights for a few minutes")
 	}
 
-	return &cfg
+	return warnings
 }
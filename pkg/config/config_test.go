@@ -0,0 +1,278 @@
+package config
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCorsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cors    Cors
+		wantErr bool
+	}{
+		{
+			name: "wildcard without credentials",
+			cors: Cors{AllowedOrigins: []string{"*"}},
+		},
+		{
+			name:    "wildcard with credentials",
+			cors:    Cors{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			wantErr: true,
+		},
+		{
+			name: "explicit origins with credentials",
+			cors: Cors{AllowedOrigins: []string{"https://example.com", "http://localhost:3000"}, AllowCredentials: true},
+		},
+		{
+			name: "subdomain wildcard",
+			cors: Cors{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+		},
+		{
+			name:    "empty origin",
+			cors:    Cors{AllowedOrigins: []string{"https://example.com", " "}},
+			wantErr: true,
+		},
+		{
+			name:    "missing scheme",
+			cors:    Cors{AllowedOrigins: []string{"example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "origin with path",
+			cors:    Cors{AllowedOrigins: []string{"https://example.com/app"}},
+			wantErr: true,
+		},
+		{
+			name:    "multiple wildcards",
+			cors:    Cors{AllowedOrigins: []string{"https://*.*.example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "Negative max age",
+			cors:    Cors{AllowedOrigins: []string{"*"}, MaxAge: -1},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cors.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{Api: &Api{Cors: Cors{AllowedOrigins: []string{"*"}, AllowCredentials: true}}}
+	assert.EqualError(t, cfg.Validate(), `api.cors: allowedOrigins: "*" can't be used with allowCredentials`)
+
+	cfg = Config{}
+	assert.Error(t, cfg.Validate())
+
+	cfg = Config{Api: &Api{}, Graph: Graph{Store: "redis"}}
+	cfg.setDefaults()
+	assert.EqualError(t, cfg.Validate(), `graph: unknown store "redis"`)
+
+	for _, basePath := range []string{"flights", "/flights/"} {
+		cfg = Config{Api: &Api{BasePath: basePath}}
+		cfg.setDefaults()
+		assert.EqualError(t, cfg.Validate(), fmt.Sprintf("api: basePath %q must start and mustn't end with a slash", basePath))
+	}
+
+	cfg = Config{Api: &Api{Concurrency: Concurrency{Limit: -1}}}
+	cfg.setDefaults()
+	assert.EqualError(t, cfg.Validate(), "api.concurrency: limit and wait can't be negative")
+
+	cfg = Config{Api: &Api{TieBreak: "random"}}
+	cfg.setDefaults()
+	assert.EqualError(t, cfg.Validate(), `api: unknown tieBreak "random"`)
+
+	cfg = Config{Api: &Api{}, Graph: Graph{IdempotencyTTL: -time.Second}}
+	cfg.setDefaults()
+	assert.EqualError(t, cfg.Validate(), "graph: idempotencyTTL can't be negative")
+
+	cfg = Config{Api: &Api{}, Graph: Graph{IdempotencySize: -1}}
+	cfg.setDefaults()
+	assert.EqualError(t, cfg.Validate(), "graph: idempotencySize can't be negative")
+
+	cfg = Config{Api: &Api{}}
+	cfg.setDefaults()
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, StoreMemory, cfg.Graph.Store)
+	assert.Equal(t, defaultIdempotencyTTL, cfg.Graph.IdempotencyTTL)
+	assert.Equal(t, defaultIdempotencySize, cfg.Graph.IdempotencySize)
+	assert.Equal(t, "/", cfg.Api.BasePath)
+	assert.Equal(t, TieBreakFirstAlpha, cfg.Api.TieBreak)
+}
+
+func TestConfigWarnings(t *testing.T) {
+	cfg := Config{Api: &Api{Cors: Cors{AllowedOrigins: []string{"*"}, MaxAge: 300}}}
+	assert.Empty(t, cfg.Warnings())
+
+	cfg.Api.Cors.MaxAge = 0
+	warnings := cfg.Warnings()
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "api.cors: maxAge is 0")
+
+	// Without allowed origins there are no cross-origin requests to preflight.
+	cfg.Api.Cors.AllowedOrigins = nil
+	assert.Empty(t, cfg.Warnings())
+}
+
+func TestLoggingValidate(t *testing.T) {
+	cfg := Config{Api: &Api{}, Logging: &Logging{Level: "info"}}
+	cfg.setDefaults()
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, Logging{Level: "info", Encoding: EncodingConsole, Output: OutputStdout}, *cfg.Logging)
+
+	cfg.Logging.Encoding = "xml"
+	assert.EqualError(t, cfg.Validate(), `logging: encoding: unknown encoding "xml"`)
+
+	cfg.Logging = &Logging{Level: "info", Encoding: EncodingJSON, Output: " "}
+	assert.EqualError(t, cfg.Validate(), "logging: output: empty output")
+}
+
+func TestReadTimeouts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+api:
+  port: 8080
+  timeouts:
+    read: 20s
+    idle: 2m
+`), 0o600))
+
+	cfg := Read(file)
+	assert.Equal(t, Timeouts{
+		Read:       20 * time.Second,
+		ReadHeader: defaultReadHeaderTimeout,
+		Write:      defaultWriteTimeout,
+		Idle:       2 * time.Minute,
+		Search:     defaultSearchTimeout,
+	}, cfg.Api.Timeouts)
+}
+
+func TestReadCompressionLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("api:\n  port: 8080\n"), 0o600))
+	assert.Equal(t, defaultCompressionLevel, Read(file).Api.Compression.GzipLevel())
+
+	// Level 0 turns compression off rather than falling back to the default.
+	assert.NoError(t, os.WriteFile(file, []byte("api:\n  port: 8080\n  compression:\n    level: 0\n"), 0o600))
+	cfg := Read(file)
+	assert.Equal(t, 0, cfg.Api.Compression.GzipLevel())
+	assert.NoError(t, cfg.Validate())
+}
+
+const testConfig = `
+appName: flightspath-api
+api:
+  port: 9090
+  cors:
+    allowedOrigins: [ "https://example.com" ]
+`
+
+func TestLoadFromStdin(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stdin")
+	assert.NoError(t, os.WriteFile(file, []byte(testConfig), 0o600))
+
+	f, err := os.Open(file)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() {
+		os.Stdin = stdin
+	}()
+
+	cfg, err := load("-")
+	assert.NoError(t, err)
+	assert.Equal(t, "flightspath-api", cfg.AppName)
+	assert.Equal(t, 9090, cfg.Api.Port)
+}
+
+func TestLoadFromURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, testConfig)
+	}))
+	defer srv.Close()
+
+	cfg, err := load(srv.URL + "/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, cfg.Api.Port)
+	assert.Equal(t, []string{"https://example.com"}, cfg.Api.Cors.AllowedOrigins)
+
+	_, err = load(srv.URL + "/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestLoadFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(testConfig), 0o600))
+
+	cfg, err := load(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, cfg.Api.Port)
+
+	_, err = load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestLoadAll(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	assert.NoError(t, os.WriteFile(base, []byte(testConfig), 0o600))
+	override := filepath.Join(dir, "prod.yaml")
+	assert.NoError(t, os.WriteFile(override, []byte(`
+api:
+  strict: true
+  cors:
+    allowedOrigins: [ "https://flights.example.com" ]
+  timeouts:
+    search: 3s
+`), 0o600))
+
+	cfg, err := loadAll([]string{base, override})
+	assert.NoError(t, err)
+	// Overridden values.
+	assert.True(t, cfg.Api.Strict)
+	assert.Equal(t, []string{"https://flights.example.com"}, cfg.Api.Cors.AllowedOrigins)
+	assert.Equal(t, 3*time.Second, cfg.Api.Timeouts.Search)
+	// Values of the base config, also in the sections of the override, and
+	// defaults.
+	assert.Equal(t, "flightspath-api", cfg.AppName)
+	assert.Equal(t, 9090, cfg.Api.Port)
+	assert.Equal(t, defaultReadTimeout, cfg.Api.Timeouts.Read)
+
+	// The merged config is validated, not the layers.
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`
+api:
+  cors:
+    maxAge: -1
+`), 0o600))
+	_, err = loadAll([]string{base, invalid})
+	assert.EqualError(t, err, "api.cors: maxAge can't be negative")
+
+	_, err = loadAll([]string{base, filepath.Join(dir, "missing.yaml")})
+	assert.ErrorContains(t, err, "missing.yaml")
+
+	_, err = loadAll(nil)
+	assert.Error(t, err)
+}
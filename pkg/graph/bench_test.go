@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// The benchmarks cover the core graph operations:
+//
+//	go test ./pkg/graph -run '^$' -bench . -benchmem
+
+// benchmarkVertices is the size of the sparse graph used by the benchmarks,
+// which resembles a flight network: most airports have a few connections and
+// every tenth one is a sink.
+const benchmarkVertices = 10000
+
+func newBenchmarkGraph(b *testing.B, options ...func(*Traits)) Graph[string, string] {
+	b.Helper()
+
+	g := New(StringHash, options...)
+	for i := 0; i < benchmarkVertices; i++ {
+		if err := g.AddVertex(strconv.Itoa(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for i := 0; i < benchmarkVertices; i++ {
+		if i%10 == 9 {
+			continue
+		}
+		for _, j := range []int{i + 1, i * 7 % benchmarkVertices, i * 13 % benchmarkVertices} {
+			if j == i || j >= benchmarkVertices {
+				continue
+			}
+			err := g.AddEdge(strconv.Itoa(i), strconv.Itoa(j))
+			if err != nil && !errors.Is(err, ErrEdgeAlreadyExists) {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	return g
+}
+
+func BenchmarkAddEdge(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		newBenchmarkGraph(b, Directed())
+	}
+}
+
+func BenchmarkAdjacencyMap(b *testing.B) {
+	g := newBenchmarkGraph(b, Directed())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := g.AdjacencyMap(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPredecessorMap(b *testing.B) {
+	g := newBenchmarkGraph(b, Directed())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := g.PredecessorMap(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUndirectedAdjacencyMap(b *testing.B) {
+	g := newBenchmarkGraph(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := g.AdjacencyMap(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkShortestPath(b *testing.B) {
+	g := newBenchmarkGraph(b, Directed())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ShortestPath(g, "1", "9998"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDFS(b *testing.B) {
+	g := newBenchmarkGraph(b, Directed())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := DFS(g, "0", func(string) bool {
+			return false
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// adjacencyList is the binary representation of the adjacency structure of a
+// graph. Edges are stored as flattened pairs of indices into Vertices, which gob
+// encodes as varints, so each edge takes only a few bytes.
+type adjacencyList[K comparable] struct {
+	Vertices []K
+	Edges    []int
+}
+
+// MarshalBinary encodes the vertex hashes and edges of the graph using gob, so
+// K must be gob-encodable. Only the adjacency structure is encoded: vertex
+// values, attributes and edge properties are left out.
+func (d *directed[K, T]) MarshalBinary() ([]byte, error) {
+	hashes, err := d.store.ListVertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	edges, err := d.store.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	list := adjacencyList[K]{
+		Vertices: hashes,
+		Edges:    make([]int, 0, 2*len(edges)),
+	}
+
+	indices := make(map[K]int, len(hashes))
+	for i, hash := range hashes {
+		indices[hash] = i
+	}
+
+	for _, edge := range edges {
+		list.Edges = append(list.Edges, indices[edge.Source], indices[edge.Target])
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(list); err != nil {
+		return nil, fmt.Errorf("failed to encode graph: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary adds the vertices and edges encoded by MarshalBinary to the
+// graph, which should usually be empty. Since vertex values aren't encoded, the
+// hashes are used as values, which requires K and T to be the same type, like
+// for graphs created with StringHash or IntHash.
+//
+// The data is decoded into a temporary store first, so the graph is left
+// unchanged if it's invalid. If the graph's store rejects a vertex or edge,
+// the ones added before are removed again.
+func (d *directed[K, T]) UnmarshalBinary(data []byte) error {
+	decoded, err := decodeAdjacencyList[K, T](data)
+	if err != nil {
+		return err
+	}
+
+	hashes, err := decoded.ListVertices()
+	if err != nil {
+		return fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	edges, err := decoded.ListEdges()
+	if err != nil {
+		return fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	var addedVertices []K
+	var addedEdges []Edge[K]
+	rollback := func() {
+		for i := len(addedEdges) - 1; i >= 0; i-- {
+			_ = d.store.RemoveEdge(addedEdges[i].Source, addedEdges[i].Target)
+		}
+		removeVertices(d.store, addedVertices)
+	}
+
+	for _, hash := range hashes {
+		value, err := decoded.Vertex(hash)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+
+		if err := d.store.AddVertex(hash, value); err != nil {
+			rollback()
+			return fmt.Errorf("failed to add vertex %v: %w", hash, err)
+		}
+		addedVertices = append(addedVertices, hash)
+	}
+
+	for _, edge := range edges {
+		if err := d.store.AddEdge(edge.Source, edge.Target, edge); err != nil {
+			rollback()
+			return fmt.Errorf("failed to add edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+		addedEdges = append(addedEdges, edge)
+	}
+
+	return nil
+}
+
+// decodeAdjacencyList decodes the data written by MarshalBinary into a new
+// memory store.
+func decodeAdjacencyList[K comparable, T any](data []byte) (Store[K, T], error) {
+	var list adjacencyList[K]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode graph: %w", err)
+	}
+
+	if len(list.Edges)%2 != 0 {
+		return nil, fmt.Errorf("failed to decode graph: odd number of edge indices")
+	}
+
+	store := NewMemoryStore[K, T]()
+
+	for _, hash := range list.Vertices {
+		value, ok := any(hash).(T)
+		if !ok {
+			return nil, fmt.Errorf("vertex hash %v can't be used as vertex value", hash)
+		}
+
+		if err := store.AddVertex(hash, value); err != nil {
+			return nil, fmt.Errorf("failed to add vertex %v: %w", hash, err)
+		}
+	}
+
+	for i := 0; i < len(list.Edges); i += 2 {
+		source, target := list.Edges[i], list.Edges[i+1]
+		if source < 0 || source >= len(list.Vertices) || target < 0 || target >= len(list.Vertices) {
+			return nil, fmt.Errorf("failed to decode graph: edge index out of range")
+		}
+
+		edge := Edge[K]{
+			Source: list.Vertices[source],
+			Target: list.Vertices[target],
+		}
+		if err := store.AddEdge(edge.Source, edge.Target, edge); err != nil {
+			return nil, fmt.Errorf("failed to add edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+	}
+
+	return store, nil
+}
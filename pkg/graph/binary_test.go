@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDirectedBinaryRoundTrip(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}}, Directed())
+	assert.NoError(t, g.AddVertex("IND"))
+
+	data, err := g.(encoding.BinaryMarshaler).MarshalBinary()
+	assert.NoError(t, err)
+
+	decoded := New(StringHash, Directed())
+	assert.NoError(t, decoded.(encoding.BinaryUnmarshaler).UnmarshalBinary(data))
+
+	wantAdjacencyMap, err := g.AdjacencyMap()
+	assert.NoError(t, err)
+	adjacencyMap, err := decoded.AdjacencyMap()
+	assert.NoError(t, err)
+	assert.Equal(t, wantAdjacencyMap, adjacencyMap)
+
+	value, err := decoded.Vertex("IND")
+	assert.NoError(t, err)
+	assert.Equal(t, "IND", value)
+
+	assert.Error(t, decoded.(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte("garbage")))
+}
+
+func TestDirectedBinarySize(t *testing.T) {
+	g := New(StringHash, Directed())
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, g.AddVertex(fmt.Sprintf("A%02d", i)))
+	}
+	for i := 0; i < 100; i++ {
+		for j := 1; j <= 10; j++ {
+			assert.NoError(t, g.AddEdge(fmt.Sprintf("A%02d", i), fmt.Sprintf("A%02d", (i+j)%100)))
+		}
+	}
+
+	data, err := g.(encoding.BinaryMarshaler).MarshalBinary()
+	assert.NoError(t, err)
+
+	edges, err := g.Edges()
+	assert.NoError(t, err)
+	jsonData, err := json.Marshal(edges)
+	assert.NoError(t, err)
+
+	// The 1000 edges take only a fraction of their JSON size.
+	assert.Less(t, len(data), len(jsonData)/4)
+}
+
+func TestDirectedUnmarshalBinaryUnchanged(t *testing.T) {
+	data, err := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}}, Directed()).(encoding.BinaryMarshaler).MarshalBinary()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		edges [][2]string
+		data  []byte
+	}{
+		{
+			name: "invalid data",
+			data: []byte("garbage"),
+		},
+		{
+			name: "truncated data",
+			data: data[:len(data)-1],
+		},
+		{
+			name:  "existing edge",
+			edges: [][2]string{{"IND", "GSO"}, {"ATL", "EWR"}},
+			data:  data,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+			want, err := g.AdjacencyMap()
+			assert.NoError(t, err)
+
+			assert.Error(t, g.(encoding.BinaryUnmarshaler).UnmarshalBinary(test.data))
+
+			adjacencyMap, err := g.AdjacencyMap()
+			assert.NoError(t, err)
+			assert.Equal(t, want, adjacencyMap)
+		})
+	}
+}
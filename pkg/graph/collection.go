@@ -0,0 +1,85 @@
+package graph
+
+import "container/heap"
+
+// priorityQueue is a minimum priority queue of vertex hashes. Vertices with
+// the lowest priority are popped first. A vertex can be pushed again with a
+// lower priority, which updates its position in the queue.
+type priorityQueue[K comparable] struct {
+	items *minHeap[K]
+	cache map[K]*priorityItem[K]
+}
+
+type priorityItem[K comparable] struct {
+	value    K
+	priority float64
+	index    int
+}
+
+func newPriorityQueue[K comparable]() *priorityQueue[K] {
+	return &priorityQueue[K]{
+		items: &minHeap[K]{},
+		cache: make(map[K]*priorityItem[K]),
+	}
+}
+
+func (p *priorityQueue[K]) Len() int {
+	return p.items.Len()
+}
+
+// Push adds the vertex with the given priority or lowers the priority of a
+// queued vertex. Raising the priority of a queued vertex is ignored.
+func (p *priorityQueue[K]) Push(value K, priority float64) {
+	if item, ok := p.cache[value]; ok {
+		if priority < item.priority {
+			item.priority = priority
+			heap.Fix(p.items, item.index)
+		}
+		return
+	}
+
+	item := &priorityItem[K]{value: value, priority: priority}
+	heap.Push(p.items, item)
+	p.cache[value] = item
+}
+
+// Pop removes and returns the vertex with the lowest priority.
+func (p *priorityQueue[K]) Pop() K {
+	item := heap.Pop(p.items).(*priorityItem[K])
+	delete(p.cache, item.value)
+
+	return item.value
+}
+
+// minHeap implements heap.Interface for the priority items.
+type minHeap[K comparable] []*priorityItem[K]
+
+func (h minHeap[K]) Len() int {
+	return len(h)
+}
+
+func (h minHeap[K]) Less(i, j int) bool {
+	return h[i].priority < h[j].priority
+}
+
+func (h minHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *minHeap[K]) Push(x any) {
+	item := x.(*priorityItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *minHeap[K]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return item
+}
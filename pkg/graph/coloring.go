@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GreedyColoring assigns a color to each vertex such that no adjacent vertices
+// share a color, e.g. to schedule flights sharing a gate into distinct time
+// slots. Colors are indices starting at 0, so the number of colors used is the
+// highest index plus one.
+//
+// The vertices are colored in the order of their degree, largest first, and
+// each gets the lowest color not used by its already colored neighbors. This
+// heuristic doesn't guarantee the minimal number of colors. Edge directions
+// are ignored.
+func GreedyColoring[K comparable, T any](g Graph[K, T]) (map[K]int, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	neighbors := make(map[K]map[K]bool, len(adjacencyMap))
+	vertices := make([]K, 0, len(adjacencyMap))
+	for vertex := range adjacencyMap {
+		vertices = append(vertices, vertex)
+		neighbors[vertex] = make(map[K]bool)
+		for adjacency := range adjacencyMap[vertex] {
+			neighbors[vertex][adjacency] = true
+		}
+		for predecessor := range predecessorMap[vertex] {
+			neighbors[vertex][predecessor] = true
+		}
+		delete(neighbors[vertex], vertex)
+	}
+
+	// Order vertices of the same degree by their hash to keep the coloring
+	// stable between calls.
+	sort.Slice(vertices, func(i, j int) bool {
+		if len(neighbors[vertices[i]]) != len(neighbors[vertices[j]]) {
+			return len(neighbors[vertices[i]]) > len(neighbors[vertices[j]])
+		}
+		return fmt.Sprint(vertices[i]) < fmt.Sprint(vertices[j])
+	})
+
+	colors := make(map[K]int, len(vertices))
+	for _, vertex := range vertices {
+		used := make(map[int]bool)
+		for neighbor := range neighbors[vertex] {
+			if color, ok := colors[neighbor]; ok {
+				used[color] = true
+			}
+		}
+
+		color := 0
+		for used[color] {
+			color++
+		}
+		colors[vertex] = color
+	}
+
+	return colors, nil
+}
+
+// IsBipartite reports whether the vertices can be split into two groups such
+// that every edge joins vertices of different groups, e.g. whether a network
+// only connects hubs with regional airports. Edge directions are ignored, and a
+// self-loop makes the graph non-bipartite.
+//
+// The graph is 2-colored by a breadth-first search of each component. If it is
+// bipartite, the returned map assigns each vertex to group 0 or 1, otherwise
+// it's nil. The first vertex of each component, in the order of the hashes, is
+// put in group 0, which keeps the result stable between calls.
+func IsBipartite[K comparable, T any](g Graph[K, T]) (bool, map[K]int, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	vertices := make([]K, 0, len(adjacencyMap))
+	for vertex := range adjacencyMap {
+		vertices = append(vertices, vertex)
+	}
+	sort.Slice(vertices, func(i, j int) bool {
+		return fmt.Sprint(vertices[i]) < fmt.Sprint(vertices[j])
+	})
+
+	groups := make(map[K]int, len(vertices))
+	for _, vertex := range vertices {
+		if _, ok := groups[vertex]; ok {
+			continue
+		}
+
+		groups[vertex] = 0
+		queue := []K{vertex}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			for _, neighbors := range []map[K]Edge[K]{adjacencyMap[current], predecessorMap[current]} {
+				for neighbor := range neighbors {
+					group, ok := groups[neighbor]
+					if !ok {
+						groups[neighbor] = 1 - groups[current]
+						queue = append(queue, neighbor)
+						continue
+					}
+					if group == groups[current] {
+						return false, nil, nil
+					}
+				}
+			}
+		}
+	}
+
+	return true, groups, nil
+}
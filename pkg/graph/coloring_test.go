@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGreedyColoring(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      [][2]string
+		options    []func(*Traits)
+		wantColors int
+	}{
+		{
+			name:       "bipartite",
+			edges:      [][2]string{{"A1", "B1"}, {"A1", "B2"}, {"A2", "B1"}, {"A2", "B3"}, {"A3", "B2"}, {"A3", "B3"}},
+			wantColors: 2,
+		},
+		{
+			name:       "triangle",
+			edges:      [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			wantColors: 3,
+		},
+		{
+			name:       "directed triangle",
+			edges:      [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}},
+			options:    []func(*Traits){Directed()},
+			wantColors: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			colors, err := GreedyColoring(g)
+			assert.NoError(t, err)
+
+			used := make(map[int]bool)
+			for _, color := range colors {
+				used[color] = true
+			}
+			assert.Len(t, used, test.wantColors)
+
+			for _, edge := range test.edges {
+				assert.NotEqual(t, colors[edge[0]], colors[edge[1]], "%s and %s share a color", edge[0], edge[1])
+			}
+		})
+	}
+}
+
+func TestIsBipartite(t *testing.T) {
+	tests := []struct {
+		name          string
+		edges         [][2]string
+		options       []func(*Traits)
+		wantBipartite bool
+	}{
+		{
+			name:          "Hubs and regional airports",
+			edges:         [][2]string{{"A1", "B1"}, {"A1", "B2"}, {"A2", "B1"}, {"A2", "B3"}, {"A3", "B2"}, {"A3", "B3"}},
+			wantBipartite: true,
+		},
+		{
+			name:          "Even cycle",
+			edges:         [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "SFO"}},
+			wantBipartite: true,
+		},
+		{
+			name:          "Directed edges are treated as undirected",
+			edges:         [][2]string{{"SFO", "ATL"}, {"EWR", "ATL"}, {"EWR", "IND"}},
+			options:       []func(*Traits){Directed()},
+			wantBipartite: true,
+		},
+		{
+			name:          "Disconnected",
+			edges:         [][2]string{{"SFO", "ATL"}, {"EWR", "IND"}},
+			wantBipartite: true,
+		},
+		{
+			name:  "Odd cycle",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}, {"GSO", "SFO"}},
+		},
+		{
+			name:    "Directed odd cycle",
+			edges:   [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}},
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:  "Self-loop",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "ATL"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			bipartite, groups, err := IsBipartite(g)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantBipartite, bipartite)
+			if !test.wantBipartite {
+				assert.Nil(t, groups)
+				return
+			}
+
+			order, err := g.Order()
+			assert.NoError(t, err)
+			assert.Len(t, groups, order)
+			for _, edge := range test.edges {
+				assert.NotEqual(t, groups[edge[0]], groups[edge[1]], "%s and %s are in the same group", edge[0], edge[1])
+			}
+		})
+	}
+}
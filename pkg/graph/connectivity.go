@@ -0,0 +1,454 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrDirectedGraph = errors.New("operation is only supported for undirected graphs")
+
+// Bridges returns the edges of an undirected graph whose removal would
+// increase the number of connected components. In a flight network, those
+// connections are single points of failure.
+//
+// Bridges uses the DFS low-link algorithm of Tarjan, which runs in O(V+E) time.
+// The edges are returned in no particular order. ErrDirectedGraph is returned
+// for directed graphs.
+func Bridges[K comparable, T any](g Graph[K, T]) ([]Edge[K], error) {
+	if g.Traits().IsDirected {
+		return nil, ErrDirectedGraph
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	bridges := make([]Edge[K], 0)
+
+	lowLinkSearch(adjacencyMap, func(vertex, child K, isRoot bool, discovery, low map[K]int) {
+		// The child and its descendants can't reach the vertex or its
+		// ancestors without the edge, so it's a bridge.
+		if low[child] > discovery[vertex] {
+			bridges = append(bridges, adjacencyMap[vertex][child])
+		}
+	})
+
+	return bridges, nil
+}
+
+// lowLinkFrame is a vertex on the stack of lowLinkSearch with the adjacencies
+// it hasn't looked at yet.
+type lowLinkFrame[K comparable] struct {
+	vertex      K
+	parent      K
+	isRoot      bool
+	adjacencies []K
+}
+
+// lowLinkSearch runs a DFS through every component of an undirected graph and
+// computes the discovery time of each vertex and its low-link, the lowest
+// discovery time reachable from its subtree through a single back edge.
+// Whenever the subtree of a child is done, finished is called with the edge
+// of the DFS tree leading to it.
+//
+// lowLinkSearch is non-recursive and maintains a stack instead, so it can
+// handle long chains of vertices.
+func lowLinkSearch[K comparable](adjacencyMap map[K]map[K]Edge[K], finished func(vertex, child K, isRoot bool, discovery, low map[K]int)) {
+	discovery := make(map[K]int, len(adjacencyMap))
+	low := make(map[K]int, len(adjacencyMap))
+	time := 0
+
+	push := func(stack []*lowLinkFrame[K], vertex, parent K, isRoot bool) []*lowLinkFrame[K] {
+		time++
+		discovery[vertex] = time
+		low[vertex] = time
+
+		adjacencies := make([]K, 0, len(adjacencyMap[vertex]))
+		for adjacency := range adjacencyMap[vertex] {
+			adjacencies = append(adjacencies, adjacency)
+		}
+
+		return append(stack, &lowLinkFrame[K]{vertex: vertex, parent: parent, isRoot: isRoot, adjacencies: adjacencies})
+	}
+
+	for root := range adjacencyMap {
+		if _, ok := discovery[root]; ok {
+			continue
+		}
+
+		stack := push(nil, root, root, true)
+
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+
+			if len(top.adjacencies) == 0 {
+				stack = stack[:len(stack)-1]
+				if top.isRoot {
+					continue
+				}
+
+				parent := stack[len(stack)-1]
+				if low[top.vertex] < low[parent.vertex] {
+					low[parent.vertex] = low[top.vertex]
+				}
+				finished(parent.vertex, top.vertex, parent.isRoot, discovery, low)
+				continue
+			}
+
+			adjacency := top.adjacencies[len(top.adjacencies)-1]
+			top.adjacencies = top.adjacencies[:len(top.adjacencies)-1]
+
+			if adjacency == top.vertex || (!top.isRoot && adjacency == top.parent) {
+				continue
+			}
+
+			if _, ok := discovery[adjacency]; ok {
+				if discovery[adjacency] < low[top.vertex] {
+					low[top.vertex] = discovery[adjacency]
+				}
+				continue
+			}
+
+			stack = push(stack, adjacency, top.vertex, false)
+		}
+	}
+}
+
+// ArticulationPoints returns the vertices of an undirected graph whose removal
+// would increase the number of connected components. In a flight network,
+// those are the critical hub airports.
+//
+// Like Bridges, ArticulationPoints compares the DFS discovery times with the
+// lowest discovery time reachable through back edges and runs in O(V+E) time.
+// The vertices are returned in no particular order. ErrDirectedGraph is
+// returned for directed graphs.
+func ArticulationPoints[K comparable, T any](g Graph[K, T]) ([]K, error) {
+	if g.Traits().IsDirected {
+		return nil, ErrDirectedGraph
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	points := make(map[K]bool)
+	rootChildren := make(map[K]int)
+
+	lowLinkSearch(adjacencyMap, func(vertex, child K, isRoot bool, discovery, low map[K]int) {
+		// The root of the DFS tree separates its subtrees, if it has several.
+		if isRoot {
+			rootChildren[vertex]++
+			if rootChildren[vertex] > 1 {
+				points[vertex] = true
+			}
+			return
+		}
+
+		// The subtree of the child can't reach the ancestors of the vertex
+		// without passing through it.
+		if low[child] >= discovery[vertex] {
+			points[vertex] = true
+		}
+	})
+
+	hashes := make([]K, 0, len(points))
+	for vertex := range points {
+		hashes = append(hashes, vertex)
+	}
+
+	return hashes, nil
+}
+
+// Connected reports whether b can be reached from a. In undirected graphs, this
+// means that both vertices are in the same connected component. The search
+// stops as soon as b is found and doesn't keep track of the path, so it's
+// cheaper than ShortestPath when only a yes or no is needed. A vertex is
+// always connected to itself.
+//
+// ErrVertexNotFound is returned if one of the vertices doesn't exist.
+func Connected[K comparable, T any](g Graph[K, T], a, b K) (bool, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return false, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	for _, vertex := range []K{a, b} {
+		if _, ok := adjacencyMap[vertex]; !ok {
+			return false, fmt.Errorf("could not find vertex with hash %v: %w", vertex, ErrVertexNotFound)
+		}
+	}
+
+	visited := map[K]bool{a: true}
+	stack := []K{a}
+
+	for len(stack) > 0 {
+		currentHash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if currentHash == b {
+			return true, nil
+		}
+
+		for adjacency := range adjacencyMap[currentHash] {
+			if !visited[adjacency] {
+				visited[adjacency] = true
+				stack = append(stack, adjacency)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// ReverseReachable returns the vertices from which the target can be reached,
+// found by a search along the ingoing edges. In a flight network, these are
+// the origins with a route to the target airport. The target itself is only
+// part of the set if it lies on a cycle. In undirected graphs, this is the
+// connected component of the target, which includes the target unless it has
+// no edges.
+//
+// ErrVertexNotFound is returned if the target doesn't exist.
+func ReverseReachable[K comparable, T any](g Graph[K, T], target K) (map[K]struct{}, error) {
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	if _, ok := predecessorMap[target]; !ok {
+		return nil, fmt.Errorf("could not find vertex with hash %v: %w", target, ErrVertexNotFound)
+	}
+
+	reachable := make(map[K]struct{})
+	stack := []K{target}
+
+	for len(stack) > 0 {
+		currentHash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for predecessor := range predecessorMap[currentHash] {
+			if _, ok := reachable[predecessor]; !ok {
+				reachable[predecessor] = struct{}{}
+				stack = append(stack, predecessor)
+			}
+		}
+	}
+
+	return reachable, nil
+}
+
+// StronglyConnectedComponents returns the strongly connected components of a
+// directed graph, i.e. the maximal sets of vertices in which each vertex can
+// be reached from every other. In a flight network, each component is a group
+// of airports with round trips between all of them.
+//
+// StronglyConnectedComponents uses Tarjan's algorithm, which runs in O(V+E)
+// time. The components are returned in reverse topological order of the
+// condensation, the order of the vertices within a component is undefined. An
+// error is returned for undirected graphs.
+func StronglyConnectedComponents[K comparable, T any](g Graph[K, T]) ([][]K, error) {
+	if !g.Traits().IsDirected {
+		return nil, errors.New("strongly connected components require a directed graph")
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	components := make([][]K, 0)
+	discovery := make(map[K]int, len(adjacencyMap))
+	low := make(map[K]int, len(adjacencyMap))
+	onStack := make(map[K]bool, len(adjacencyMap))
+	// stack holds the vertices whose component isn't known yet, path the
+	// vertices of the DFS with the adjacencies they haven't looked at yet.
+	stack := make([]K, 0)
+	time := 0
+
+	type frame struct {
+		vertex      K
+		adjacencies []K
+	}
+	var path []*frame
+
+	push := func(vertex K) {
+		time++
+		discovery[vertex] = time
+		low[vertex] = time
+		stack = append(stack, vertex)
+		onStack[vertex] = true
+
+		adjacencies := make([]K, 0, len(adjacencyMap[vertex]))
+		for adjacency := range adjacencyMap[vertex] {
+			adjacencies = append(adjacencies, adjacency)
+		}
+		path = append(path, &frame{vertex: vertex, adjacencies: adjacencies})
+	}
+
+	for root := range adjacencyMap {
+		if _, ok := discovery[root]; ok {
+			continue
+		}
+
+		push(root)
+
+		for len(path) > 0 {
+			top := path[len(path)-1]
+
+			if len(top.adjacencies) > 0 {
+				adjacency := top.adjacencies[len(top.adjacencies)-1]
+				top.adjacencies = top.adjacencies[:len(top.adjacencies)-1]
+
+				if _, ok := discovery[adjacency]; !ok {
+					push(adjacency)
+				} else if onStack[adjacency] && discovery[adjacency] < low[top.vertex] {
+					low[top.vertex] = discovery[adjacency]
+				}
+				continue
+			}
+
+			path = path[:len(path)-1]
+			vertex := top.vertex
+			if len(path) > 0 && low[vertex] < low[path[len(path)-1].vertex] {
+				low[path[len(path)-1].vertex] = low[vertex]
+			}
+
+			// The vertex is the root of a component, which consists of the
+			// vertices pushed on the stack since.
+			if low[vertex] != discovery[vertex] {
+				continue
+			}
+
+			var component []K
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == vertex {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	return components, nil
+}
+
+// Condensation returns the condensation of a directed graph, in which each
+// strongly connected component is contracted into a single vertex. The vertex
+// values are the hashes of the vertices in the component, the vertex hashes
+// are the indices of the components in the order of
+// StronglyConnectedComponents. There is an edge between two components if
+// there is an edge between any of their vertices.
+//
+// The condensation is always acyclic, which allows reasoning about a cyclic
+// flight network with algorithms for DAGs like TopologicalSort. An error is
+// returned for undirected graphs.
+func Condensation[K comparable, T any](g Graph[K, T]) (Graph[int, []K], error) {
+	components, err := StronglyConnectedComponents(g)
+	if err != nil {
+		return nil, err
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	componentOf := make(map[K]int, len(adjacencyMap))
+	for i, component := range components {
+		for _, vertex := range component {
+			componentOf[vertex] = i
+		}
+	}
+
+	// Components are non-empty and disjoint, so any of their vertices
+	// identifies them.
+	condensation := New(func(component []K) int {
+		return componentOf[component[0]]
+	}, Directed(), Acyclic())
+
+	for _, component := range components {
+		if err := condensation.AddVertex(component); err != nil {
+			return nil, fmt.Errorf("failed to add component: %w", err)
+		}
+	}
+
+	for vertex, adjacencies := range adjacencyMap {
+		for adjacency := range adjacencies {
+			source, target := componentOf[vertex], componentOf[adjacency]
+			if source == target {
+				continue
+			}
+
+			err := condensation.AddEdge(source, target)
+			if err != nil && !errors.Is(err, ErrEdgeAlreadyExists) {
+				return nil, fmt.Errorf("failed to add edge from component %d to %d: %w", source, target, err)
+			}
+		}
+	}
+
+	return condensation, nil
+}
+
+// EdgesToConnect returns the minimum number of edges which have to be added to
+// connect the graph. In a flight network, it's the number of new routes needed
+// to unify the network.
+//
+// For undirected graphs, it's the number of connected components minus one. For
+// directed graphs, the graph is connected once every vertex can be reached from
+// every other, which takes the larger of the numbers of components without
+// incoming and without outgoing edges in the condensation, or 0 if the graph
+// is strongly connected already.
+func EdgesToConnect[K comparable, T any](g Graph[K, T]) (int, error) {
+	if !g.Traits().IsDirected {
+		adjacencyMap, err := g.AdjacencyMap()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get adjacency map: %w", err)
+		}
+		if len(adjacencyMap) == 0 {
+			return 0, nil
+		}
+
+		// The adjacency map of an undirected graph is symmetric, so it's its
+		// own predecessor map.
+		return len(weaklyConnectedComponents(adjacencyMap, adjacencyMap)) - 1, nil
+	}
+
+	condensation, err := Condensation(g)
+	if err != nil {
+		return 0, err
+	}
+
+	adjacencyMap, err := condensation.AdjacencyMap()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+	if len(adjacencyMap) <= 1 {
+		return 0, nil
+	}
+
+	predecessorMap, err := condensation.PredecessorMap()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	sources, sinks := 0, 0
+	for component := range adjacencyMap {
+		if len(predecessorMap[component]) == 0 {
+			sources++
+		}
+		if len(adjacencyMap[component]) == 0 {
+			sinks++
+		}
+	}
+
+	if sources > sinks {
+		return sources, nil
+	}
+
+	return sinks, nil
+}
@@ -0,0 +1,393 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestBridges(t *testing.T) {
+	tests := []struct {
+		name        string
+		edges       [][2]string
+		wantBridges [][2]string
+	}{
+		{
+			name:        "two triangles joined by a bridge",
+			edges:       [][2]string{{"SFO", "LAX"}, {"LAX", "SEA"}, {"SEA", "SFO"}, {"SEA", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "ATL"}},
+			wantBridges: [][2]string{{"ATL", "SEA"}},
+		},
+		{
+			name:        "chain",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			wantBridges: [][2]string{{"ATL", "SFO"}, {"ATL", "EWR"}},
+		},
+		{
+			name:        "2-edge-connected",
+			edges:       [][2]string{{"SFO", "LAX"}, {"LAX", "SEA"}, {"SEA", "SFO"}, {"SEA", "ATL"}, {"ATL", "SFO"}},
+			wantBridges: [][2]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges)
+
+			bridges, err := Bridges(g)
+			assert.NoError(t, err)
+
+			// Undirected edges may be reported in either orientation.
+			got := make([][2]string, 0, len(bridges))
+			for _, bridge := range bridges {
+				if bridge.Source > bridge.Target {
+					bridge.Source, bridge.Target = bridge.Target, bridge.Source
+				}
+				got = append(got, [2]string{bridge.Source, bridge.Target})
+			}
+			assert.ElementsMatch(t, test.wantBridges, got)
+		})
+	}
+}
+
+func TestBridgesLongChain(t *testing.T) {
+	const length = 10000
+
+	g := New(IntHash)
+	for i := 0; i <= length; i++ {
+		assert.NoError(t, g.AddVertex(i))
+	}
+	for i := 0; i < length; i++ {
+		assert.NoError(t, g.AddEdge(i, i+1))
+	}
+
+	bridges, err := Bridges(g)
+	assert.NoError(t, err)
+	assert.Len(t, bridges, length)
+}
+
+func TestBridgesDirected(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}}, Directed())
+
+	_, err := Bridges(g)
+	assert.ErrorIs(t, err, ErrDirectedGraph)
+}
+
+func TestArticulationPoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      [][2]string
+		wantPoints []string
+	}{
+		{
+			name:       "two triangles sharing a hub",
+			edges:      [][2]string{{"SFO", "LAX"}, {"LAX", "ATL"}, {"ATL", "SFO"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "ATL"}},
+			wantPoints: []string{"ATL"},
+		},
+		{
+			name:       "chain",
+			edges:      [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}},
+			wantPoints: []string{"ATL", "EWR"},
+		},
+		{
+			name:       "biconnected",
+			edges:      [][2]string{{"SFO", "LAX"}, {"LAX", "SEA"}, {"SEA", "ATL"}, {"ATL", "SFO"}, {"SFO", "SEA"}},
+			wantPoints: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges)
+
+			points, err := ArticulationPoints(g)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantPoints, points)
+		})
+	}
+}
+
+func TestArticulationPointsLongChain(t *testing.T) {
+	const length = 10000
+
+	g := New(IntHash)
+	for i := 0; i <= length; i++ {
+		assert.NoError(t, g.AddVertex(i))
+	}
+	for i := 0; i < length; i++ {
+		assert.NoError(t, g.AddEdge(i, i+1))
+	}
+
+	// All vertices but the ends of the chain separate it.
+	points, err := ArticulationPoints(g)
+	assert.NoError(t, err)
+	assert.Len(t, points, length-1)
+}
+
+func TestArticulationPointsDirected(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}}, Directed())
+
+	_, err := ArticulationPoints(g)
+	assert.ErrorIs(t, err, ErrDirectedGraph)
+}
+
+func TestReverseReachable(t *testing.T) {
+	// Routes converging on EWR, with ORD only flying on from there and a
+	// separate route from LHR.
+	edges := [][2]string{
+		{"SFO", "DEN"}, {"LAX", "DEN"}, {"DEN", "EWR"}, {"ATL", "EWR"},
+		{"MIA", "ATL"}, {"EWR", "ORD"}, {"LHR", "CDG"},
+	}
+
+	tests := []struct {
+		name          string
+		options       []func(*Traits)
+		edges         [][2]string
+		target        string
+		wantReachable []string
+	}{
+		{
+			name:          "converging routes",
+			options:       []func(*Traits){Directed()},
+			edges:         edges,
+			target:        "EWR",
+			wantReachable: []string{"SFO", "LAX", "DEN", "ATL", "MIA"},
+		},
+		{
+			name:    "origin",
+			options: []func(*Traits){Directed()},
+			edges:   edges,
+			target:  "SFO",
+		},
+		{
+			name:          "cycle",
+			options:       []func(*Traits){Directed()},
+			edges:         [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "ATL"}},
+			target:        "EWR",
+			wantReachable: []string{"SFO", "ATL", "EWR"},
+		},
+		{
+			name:          "undirected",
+			edges:         edges,
+			target:        "CDG",
+			wantReachable: []string{"LHR", "CDG"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			reachable, err := ReverseReachable(g, test.target)
+			assert.NoError(t, err)
+
+			hashes := make([]string, 0, len(reachable))
+			for hash := range reachable {
+				hashes = append(hashes, hash)
+			}
+			assert.ElementsMatch(t, test.wantReachable, hashes)
+		})
+	}
+
+	_, err := ReverseReachable(newStringGraph(t, edges, Directed()), "JFK")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+}
+
+func TestConnected(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"LHR", "CDG"}}
+
+	tests := []struct {
+		name          string
+		options       []func(*Traits)
+		a, b          string
+		wantConnected bool
+	}{
+		{
+			name:          "directed reachable",
+			options:       []func(*Traits){Directed()},
+			a:             "SFO",
+			b:             "EWR",
+			wantConnected: true,
+		},
+		{
+			name:    "directed against the edges",
+			options: []func(*Traits){Directed()},
+			a:       "EWR",
+			b:       "SFO",
+		},
+		{
+			name:    "directed disconnected",
+			options: []func(*Traits){Directed()},
+			a:       "SFO",
+			b:       "CDG",
+		},
+		{
+			name:          "undirected same component",
+			a:             "EWR",
+			b:             "SFO",
+			wantConnected: true,
+		},
+		{
+			name: "undirected disconnected",
+			a:    "CDG",
+			b:    "ATL",
+		},
+		{
+			name:          "same vertex",
+			a:             "LHR",
+			b:             "LHR",
+			wantConnected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, edges, test.options...)
+
+			connected, err := Connected(g, test.a, test.b)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantConnected, connected)
+		})
+	}
+
+	g := newStringGraph(t, edges, Directed())
+	_, err := Connected(g, "SFO", "LAX")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	tests := []struct {
+		name           string
+		edges          [][2]string
+		wantComponents [][]string
+	}{
+		{
+			name:           "two round trips joined one way",
+			edges:          [][2]string{{"SFO", "LAX"}, {"LAX", "SFO"}, {"LAX", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "ATL"}},
+			wantComponents: [][]string{{"LAX", "SFO"}, {"ATL", "EWR", "IND"}},
+		},
+		{
+			name:           "chain",
+			edges:          [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			wantComponents: [][]string{{"SFO"}, {"ATL"}, {"EWR"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+
+			components, err := StronglyConnectedComponents(g)
+			assert.NoError(t, err)
+
+			sorted := make([][]string, 0, len(components))
+			for _, component := range components {
+				sort.Strings(component)
+				sorted = append(sorted, component)
+			}
+			assert.ElementsMatch(t, test.wantComponents, sorted)
+		})
+	}
+
+	_, err := StronglyConnectedComponents(newStringGraph(t, [][2]string{{"SFO", "ATL"}}))
+	assert.Error(t, err)
+}
+
+func TestStronglyConnectedComponentsLongCycle(t *testing.T) {
+	const length = 10000
+
+	g := New(IntHash, Directed())
+	for i := 0; i < length; i++ {
+		assert.NoError(t, g.AddVertex(i))
+	}
+	for i := 0; i < length; i++ {
+		assert.NoError(t, g.AddEdge(i, (i+1)%length))
+	}
+
+	components, err := StronglyConnectedComponents(g)
+	assert.NoError(t, err)
+	if assert.Len(t, components, 1) {
+		assert.Len(t, components[0], length)
+	}
+}
+
+func TestCondensation(t *testing.T) {
+	// Three components: {SFO, LAX}, {ATL, EWR, IND} and {BOS}.
+	g := newStringGraph(t, [][2]string{
+		{"SFO", "LAX"}, {"LAX", "SFO"},
+		{"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "ATL"},
+		{"LAX", "ATL"}, {"SFO", "EWR"}, {"IND", "BOS"},
+	}, Directed())
+
+	condensation, err := Condensation(g)
+	assert.NoError(t, err)
+
+	order, err := condensation.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order)
+
+	// The two edges from {SFO, LAX} to {ATL, EWR, IND} are merged.
+	size, err := condensation.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	sorted, err := TopologicalSort(condensation)
+	assert.NoError(t, err)
+
+	var components [][]string
+	for _, hash := range sorted {
+		component, err := condensation.Vertex(hash)
+		assert.NoError(t, err)
+		sort.Strings(component)
+		components = append(components, component)
+	}
+	assert.Equal(t, [][]string{{"LAX", "SFO"}, {"ATL", "EWR", "IND"}, {"BOS"}}, components)
+}
+
+func TestEdgesToConnect(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     [][2]string
+		vertices  []string
+		options   []func(*Traits)
+		wantEdges int
+	}{
+		{
+			name:      "undirected three components",
+			edges:     [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"LHR", "CDG"}},
+			vertices:  []string{"DXB"},
+			wantEdges: 2,
+		},
+		{
+			name:  "undirected connected",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:      "directed chain",
+			edges:     [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			options:   []func(*Traits){Directed()},
+			wantEdges: 1,
+		},
+		{
+			name:      "directed three components",
+			edges:     [][2]string{{"SFO", "ATL"}, {"ATL", "SFO"}, {"LHR", "CDG"}},
+			vertices:  []string{"DXB"},
+			options:   []func(*Traits){Directed()},
+			wantEdges: 3,
+		},
+		{
+			name:    "strongly connected",
+			edges:   [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			options: []func(*Traits){Directed()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+			for _, vertex := range test.vertices {
+				assert.NoError(t, g.AddVertex(vertex))
+			}
+
+			edges, err := EdgesToConnect(g)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantEdges, edges)
+		})
+	}
+}
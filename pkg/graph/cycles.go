@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMaxCyclesExceeded = errors.New("graph contains more than the maximum number of cycles")
+
+// CycleOptions configure the cycle search of AllCycles.
+type CycleOptions struct {
+	// MaxCycles is the maximum number of cycles to find, 0 means unlimited.
+	MaxCycles int
+}
+
+// MaxCycles limits the number of cycles returned by AllCycles. If the graph
+// has more cycles, the first ones found are returned with ErrMaxCyclesExceeded.
+func MaxCycles(cycles int) func(*CycleOptions) {
+	return func(o *CycleOptions) {
+		o.MaxCycles = cycles
+	}
+}
+
+// AllCycles returns all elementary cycles of a directed graph, i.e. cycles in
+// which no vertex appears twice. Each cycle is given by its vertices in the
+// order of its edges, the edge from the last vertex back to the first one is
+// implied. For example, the cycle of the edges (A, B), (B, C) and (C, A) is
+// returned as [A B C], or one of its rotations. A self-loop is a cycle of a
+// single vertex.
+//
+// AllCycles implements Johnson's algorithm, which takes O((V+E)(C+1)) time for
+// C cycles. Since the number of cycles may grow exponentially with the size of
+// the graph, it should be limited with the MaxCycles option for large graphs.
+// An error is returned for undirected graphs.
+func AllCycles[K comparable, T any](g Graph[K, T], options ...func(*CycleOptions)) ([][]K, error) {
+	if !g.Traits().IsDirected {
+		return nil, errors.New("finding all cycles requires a directed graph")
+	}
+
+	var opts CycleOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	// Johnson's algorithm needs an order of the vertices, any order will do.
+	vertices := make([]K, 0, len(adjacencyMap))
+	index := make(map[K]int, len(adjacencyMap))
+	for vertex := range adjacencyMap {
+		index[vertex] = len(vertices)
+		vertices = append(vertices, vertex)
+	}
+
+	search := &cycleSearch[K]{
+		adjacencyMap: adjacencyMap,
+		maxCycles:    opts.MaxCycles,
+	}
+
+	for i, start := range vertices {
+		// Only cycles through start within the vertices from start onwards
+		// are left, those are the cycles in the strongly connected component
+		// of start in the subgraph of these vertices.
+		inSubgraph := func(vertex K) bool {
+			return index[vertex] >= i
+		}
+		reachable := reachableFrom(start, adjacencyMap, inSubgraph)
+		reaching := reachableFrom(start, predecessorMap, inSubgraph)
+
+		component := make(map[K]bool, len(reachable))
+		for vertex := range reachable {
+			if reaching[vertex] {
+				component[vertex] = true
+			}
+		}
+
+		search.reset(start, component)
+		search.circuit(start)
+
+		if search.exceeded() {
+			return search.cycles[:opts.MaxCycles], ErrMaxCyclesExceeded
+		}
+	}
+
+	return search.cycles, nil
+}
+
+// reachableFrom returns the vertices reachable from start via the edges of the
+// adjacency map, only passing vertices for which include returns true.
+func reachableFrom[K comparable](start K, adjacencyMap map[K]map[K]Edge[K], include func(K) bool) map[K]bool {
+	reachable := map[K]bool{start: true}
+	queue := []K{start}
+
+	for len(queue) > 0 {
+		currentHash := queue[0]
+		queue = queue[1:]
+
+		for adjacency := range adjacencyMap[currentHash] {
+			if reachable[adjacency] || !include(adjacency) {
+				continue
+			}
+			reachable[adjacency] = true
+			queue = append(queue, adjacency)
+		}
+	}
+
+	return reachable
+}
+
+// cycleSearch holds the state of Johnson's algorithm while looking for the
+// cycles through the start vertex within its component.
+type cycleSearch[K comparable] struct {
+	adjacencyMap map[K]map[K]Edge[K]
+	maxCycles    int
+	cycles       [][]K
+
+	start     K
+	component map[K]bool
+	stack     []K
+	blocked   map[K]bool
+	blockMap  map[K]map[K]bool
+}
+
+func (s *cycleSearch[K]) reset(start K, component map[K]bool) {
+	s.start = start
+	s.component = component
+	s.stack = s.stack[:0]
+	s.blocked = make(map[K]bool, len(component))
+	s.blockMap = make(map[K]map[K]bool, len(component))
+}
+
+// exceeded reports whether more cycles than the maximum have been found. The
+// search goes on until one more cycle is found, so it's known whether the
+// limit was actually hit.
+func (s *cycleSearch[K]) exceeded() bool {
+	return s.maxCycles > 0 && len(s.cycles) > s.maxCycles
+}
+
+// circuit looks for the cycles from vertex back to the start vertex, and
+// reports whether it found any.
+func (s *cycleSearch[K]) circuit(vertex K) bool {
+	found := false
+	s.stack = append(s.stack, vertex)
+	s.blocked[vertex] = true
+
+	for adjacency := range s.adjacencyMap[vertex] {
+		if !s.component[adjacency] {
+			continue
+		}
+		if s.exceeded() {
+			break
+		}
+
+		if adjacency == s.start {
+			cycle := make([]K, len(s.stack))
+			copy(cycle, s.stack)
+			s.cycles = append(s.cycles, cycle)
+			found = true
+		} else if !s.blocked[adjacency] && s.circuit(adjacency) {
+			found = true
+		}
+	}
+
+	if found {
+		s.unblock(vertex)
+	} else {
+		// Stay blocked until one of the adjacencies leads to the start.
+		for adjacency := range s.adjacencyMap[vertex] {
+			if !s.component[adjacency] {
+				continue
+			}
+			if s.blockMap[adjacency] == nil {
+				s.blockMap[adjacency] = make(map[K]bool)
+			}
+			s.blockMap[adjacency][vertex] = true
+		}
+	}
+
+	s.stack = s.stack[:len(s.stack)-1]
+
+	return found
+}
+
+func (s *cycleSearch[K]) unblock(vertex K) {
+	s.blocked[vertex] = false
+	for blocked := range s.blockMap[vertex] {
+		delete(s.blockMap[vertex], blocked)
+		if s.blocked[blocked] {
+			s.unblock(blocked)
+		}
+	}
+}
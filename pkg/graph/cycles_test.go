@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// rotateCycle rotates the cycle to start with its least vertex, so cycles can
+// be compared regardless of where the search entered them.
+func rotateCycle(cycle []string) []string {
+	least := 0
+	for i, vertex := range cycle {
+		if vertex < cycle[least] {
+			least = i
+		}
+	}
+
+	return append(append([]string{}, cycle[least:]...), cycle[:least]...)
+}
+
+func TestAllCycles(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      [][2]string
+		wantCycles [][]string
+	}{
+		{
+			name:  "No cycles",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}},
+		},
+		{
+			name:       "Two distinct cycles",
+			edges:      [][2]string{{"SFO", "ATL"}, {"ATL", "SFO"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}, {"GSO", "EWR"}},
+			wantCycles: [][]string{{"ATL", "SFO"}, {"EWR", "IND", "GSO"}},
+		},
+		{
+			name:       "Overlapping cycles",
+			edges:      [][2]string{{"ATL", "EWR"}, {"EWR", "SFO"}, {"SFO", "ATL"}, {"EWR", "ATL"}, {"SFO", "EWR"}},
+			wantCycles: [][]string{{"ATL", "EWR"}, {"ATL", "EWR", "SFO"}, {"EWR", "SFO"}},
+		},
+		{
+			name:       "Self-loop",
+			edges:      [][2]string{{"SFO", "SFO"}, {"SFO", "ATL"}},
+			wantCycles: [][]string{{"SFO"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+
+			cycles, err := AllCycles(g)
+			assert.NoError(t, err)
+
+			rotated := make([][]string, 0, len(cycles))
+			for _, cycle := range cycles {
+				rotated = append(rotated, rotateCycle(cycle))
+			}
+			assert.ElementsMatch(t, test.wantCycles, rotated)
+		})
+	}
+}
+
+func TestAllCyclesMaxCycles(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"ATL", "EWR"}, {"EWR", "SFO"}, {"SFO", "ATL"}, {"EWR", "ATL"}, {"SFO", "EWR"}}, Directed())
+
+	cycles, err := AllCycles(g, MaxCycles(2))
+	assert.ErrorIs(t, err, ErrMaxCyclesExceeded)
+	assert.Len(t, cycles, 2)
+
+	cycles, err = AllCycles(g, MaxCycles(3))
+	assert.NoError(t, err)
+	assert.Len(t, cycles, 3)
+
+	_, err = AllCycles(newStringGraph(t, [][2]string{{"SFO", "ATL"}}))
+	assert.Error(t, err)
+}
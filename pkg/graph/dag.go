@@ -0,0 +1,334 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrGraphHasCycle = errors.New("graph contains a cycle")
+
+// TopologicalSort returns the vertex hashes of a directed acyclic graph in
+// topological order, i.e. each vertex comes before all vertices it has an
+// outgoing edge to. If the graph is undirected or contains a cycle, an error
+// is returned.
+//
+// TopologicalSort implements Kahn's algorithm. If there are multiple valid
+// orders, any of them may be returned.
+func TopologicalSort[K comparable, T any](g Graph[K, T]) ([]K, error) {
+	if !g.Traits().IsDirected {
+		return nil, errors.New("topological sort requires a directed graph")
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	inDegrees := make(map[K]int, len(predecessorMap))
+	queue := make([]K, 0)
+
+	for vertex, predecessors := range predecessorMap {
+		inDegrees[vertex] = len(predecessors)
+		if len(predecessors) == 0 {
+			queue = append(queue, vertex)
+		}
+	}
+
+	order := make([]K, 0, len(adjacencyMap))
+
+	for len(queue) > 0 {
+		currentHash := queue[0]
+		queue = queue[1:]
+
+		order = append(order, currentHash)
+
+		for adjacency := range adjacencyMap[currentHash] {
+			inDegrees[adjacency]--
+			if inDegrees[adjacency] == 0 {
+				queue = append(queue, adjacency)
+			}
+		}
+	}
+
+	if len(order) != len(adjacencyMap) {
+		return nil, ErrGraphHasCycle
+	}
+
+	return order, nil
+}
+
+// LongestPath returns the path with the most edges in a directed acyclic graph.
+// If there are several such paths, any of them may be returned. A graph without
+// edges yields a path consisting of a single vertex, an empty graph yields an
+// empty path.
+//
+// LongestPath processes the vertices in topological order and keeps the length
+// of the longest path ending in each vertex, which takes O(V+E) time. It
+// returns an error for undirected or cyclic graphs.
+func LongestPath[K comparable, T any](g Graph[K, T]) ([]K, error) {
+	return longestPath(g, func(Edge[K]) float64 {
+		return 1
+	})
+}
+
+// LongestPathLength returns the number of edges of the path returned by
+// LongestPath, without building the path. It's 0 for graphs without edges.
+// Just like LongestPath, it returns an error for undirected or cyclic graphs.
+func LongestPathLength[K comparable, T any](g Graph[K, T]) (int, error) {
+	order, err := TopologicalSort(g)
+	if err != nil {
+		return 0, err
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	// lengths holds the number of edges of the longest path ending in each
+	// vertex, which is final once the vertex is reached in topological order.
+	lengths := make(map[K]int, len(order))
+	longest := 0
+
+	for _, currentHash := range order {
+		length := lengths[currentHash]
+		if length > longest {
+			longest = length
+		}
+
+		for adjacency := range adjacencyMap[currentHash] {
+			if length+1 > lengths[adjacency] {
+				lengths[adjacency] = length + 1
+			}
+		}
+	}
+
+	return longest, nil
+}
+
+// LongestWeightedPath returns the path with the highest total edge weight in a
+// directed acyclic graph. For unweighted graphs, each edge has a weight of 1 and
+// the result is the same as for LongestPath. Just like LongestPath, it returns
+// an error for undirected or cyclic graphs.
+func LongestWeightedPath[K comparable, T any](g Graph[K, T]) ([]K, error) {
+	return longestPath(g, func(edge Edge[K]) float64 {
+		return edgeWeight(g, edge)
+	})
+}
+
+// longestPath finds the path with the highest total cost in a directed acyclic
+// graph, where the cost of each edge is determined by the cost function.
+func longestPath[K comparable, T any](g Graph[K, T], cost func(Edge[K]) float64) ([]K, error) {
+	order, err := TopologicalSort(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(order) == 0 {
+		return []K{}, nil
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	costs := make(map[K]float64, len(order))
+	predecessors := make(map[K]K, len(order))
+	end := order[0]
+
+	for _, currentHash := range order {
+		if costs[currentHash] > costs[end] {
+			end = currentHash
+		}
+
+		for adjacency, edge := range adjacencyMap[currentHash] {
+			if _, ok := predecessors[adjacency]; ok && costs[currentHash]+cost(edge) <= costs[adjacency] {
+				continue
+			}
+			costs[adjacency] = costs[currentHash] + cost(edge)
+			predecessors[adjacency] = currentHash
+		}
+	}
+
+	path := []K{end}
+	for current, ok := predecessors[end]; ok; current, ok = predecessors[current] {
+		path = append(path, current)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, nil
+}
+
+// WouldStayAcyclic reports whether the directed graph would still be acyclic
+// after adding all of the given edges. Unlike calling CreatesCycle for each
+// edge, it also detects cycles which are only formed by several new edges
+// together. The graph isn't changed.
+//
+// If a cycle would be formed, it's returned like the cycles of AllCycles: its
+// vertices in the order of its edges, with the edge from the last vertex back
+// to the first one implied. If there are several cycles, any of them may be
+// returned. An error is returned if the graph is undirected or an edge refers
+// to a vertex which doesn't exist.
+func WouldStayAcyclic[K comparable, T any](g Graph[K, T], edges []Edge[K]) (bool, []K, error) {
+	if !g.Traits().IsDirected {
+		return false, nil, errors.New("checking for cycles requires a directed graph")
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	adjacencies := make(map[K][]K, len(adjacencyMap))
+	for vertex, targets := range adjacencyMap {
+		for target := range targets {
+			adjacencies[vertex] = append(adjacencies[vertex], target)
+		}
+	}
+
+	for _, edge := range edges {
+		for _, vertex := range []K{edge.Source, edge.Target} {
+			if _, ok := adjacencyMap[vertex]; !ok {
+				return false, nil, fmt.Errorf("could not find vertex with hash %v: %w", vertex, ErrVertexNotFound)
+			}
+		}
+		adjacencies[edge.Source] = append(adjacencies[edge.Source], edge.Target)
+	}
+
+	// A DFS finds a cycle when it reaches a vertex on its current path again,
+	// the cycle is the part of the path from that vertex onwards. next holds
+	// the index of the next adjacency to look at for each vertex on the path.
+	finished := make(map[K]bool, len(adjacencyMap))
+	onPath := make(map[K]int)
+	var path []K
+	var next []int
+
+	for start := range adjacencyMap {
+		if finished[start] {
+			continue
+		}
+
+		onPath[start] = 0
+		path = append(path, start)
+		next = append(next, 0)
+
+		for len(path) > 0 {
+			top := len(path) - 1
+			vertex := path[top]
+
+			if next[top] == len(adjacencies[vertex]) {
+				path = path[:top]
+				next = next[:top]
+				delete(onPath, vertex)
+				finished[vertex] = true
+				continue
+			}
+
+			adjacency := adjacencies[vertex][next[top]]
+			next[top]++
+
+			if index, ok := onPath[adjacency]; ok {
+				cycle := make([]K, len(path)-index)
+				copy(cycle, path[index:])
+				return false, cycle, nil
+			}
+			if finished[adjacency] {
+				continue
+			}
+
+			onPath[adjacency] = len(path)
+			path = append(path, adjacency)
+			next = append(next, 0)
+		}
+	}
+
+	return true, nil, nil
+}
+
+// FeedbackEdgeSet returns edges of a directed graph whose removal makes the
+// graph acyclic, e.g. to suggest which edges to drop to resolve a cycle. An
+// acyclic graph yields an empty set.
+//
+// FeedbackEdgeSet is a heuristic: it returns the back edges of a DFS, i.e. the
+// edges leading to a vertex on the current path, which takes O(V+E) time. It
+// breaks all cycles, but the set isn't necessarily minimal and depends on the
+// order in which the vertices are visited. With the VisitOrder option, the
+// DFS starts from the vertices and follows the adjacencies in that order, so
+// the same graph always yields the same edges in the same order. An error is
+// returned for undirected graphs.
+func FeedbackEdgeSet[K comparable, T any](g Graph[K, T], options ...func(*TraversalOptions[K])) ([]Edge[K], error) {
+	if !g.Traits().IsDirected {
+		return nil, errors.New("finding a feedback edge set requires a directed graph")
+	}
+
+	var opts TraversalOptions[K]
+	for _, option := range options {
+		option(&opts)
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	// Without the back edges, the remaining edges only lead to vertices
+	// which are finished earlier, so they form a DAG. next holds the index
+	// of the next adjacency to look at for each vertex on the path.
+	finished := make(map[K]bool, len(adjacencyMap))
+	onPath := make(map[K]bool)
+	edges := make([]Edge[K], 0)
+	var path []K
+	var adjacencies [][]K
+	var next []int
+
+	push := func(vertex K) {
+		onPath[vertex] = true
+		path = append(path, vertex)
+		adjacencies = append(adjacencies, sortedKeys(adjacencyMap[vertex], opts.Less))
+		next = append(next, 0)
+	}
+
+	for _, start := range sortedKeys(adjacencyMap, opts.Less) {
+		if finished[start] {
+			continue
+		}
+
+		push(start)
+
+		for len(path) > 0 {
+			top := len(path) - 1
+			vertex := path[top]
+
+			if next[top] == len(adjacencies[top]) {
+				path = path[:top]
+				adjacencies = adjacencies[:top]
+				next = next[:top]
+				delete(onPath, vertex)
+				finished[vertex] = true
+				continue
+			}
+
+			adjacency := adjacencies[top][next[top]]
+			next[top]++
+
+			if onPath[adjacency] {
+				edges = append(edges, adjacencyMap[vertex][adjacency])
+				continue
+			}
+			if !finished[adjacency] {
+				push(adjacency)
+			}
+		}
+	}
+
+	return edges, nil
+}
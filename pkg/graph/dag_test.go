@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTopologicalSort(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"IND", "EWR"}, {"SFO", "ATL"}, {"GSO", "IND"}, {"ATL", "GSO"}, {"SFO", "GSO"}}, Directed())
+
+	order, err := TopologicalSort(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "ATL", "GSO", "IND", "EWR"}, order)
+
+	cyclic := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "A"}}, Directed())
+	_, err = TopologicalSort(cyclic)
+	assert.ErrorIs(t, err, ErrGraphHasCycle)
+
+	_, err = TopologicalSort(newStringGraph(t, [][2]string{{"A", "B"}}))
+	assert.Error(t, err)
+}
+
+// bruteForceLongestPath runs a DFS from every vertex and keeps the longest
+// visiting order, the way the search controller used to find routes.
+func bruteForceLongestPath(t *testing.T, g Graph[string, string]) []string {
+	adjacencyMap, err := g.AdjacencyMap()
+	assert.NoError(t, err)
+
+	var longest []string
+	for start := range adjacencyMap {
+		var path []string
+		assert.NoError(t, DFS(g, start, func(value string) bool {
+			path = append(path, value)
+			return false
+		}))
+		if len(path) > len(longest) {
+			longest = path
+		}
+	}
+
+	return longest
+}
+
+func TestLongestPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+	}{
+		{
+			name:  "Single route",
+			edges: [][2]string{{"SFO", "EWR"}},
+		},
+		{
+			name:  "Few routes",
+			edges: [][2]string{{"ATL", "EWR"}, {"SFO", "ATL"}},
+		},
+		{
+			name:  "Multiple routes",
+			edges: [][2]string{{"IND", "EWR"}, {"SFO", "ATL"}, {"GSO", "IND"}, {"ATL", "GSO"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+
+			path, err := LongestPath(g)
+			assert.NoError(t, err)
+			assert.Equal(t, bruteForceLongestPath(t, g), path)
+		})
+	}
+}
+
+func TestLongestPathBranching(t *testing.T) {
+	// A DFS visiting order isn't a path once routes branch, the longest path
+	// is still well-defined.
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "LAX"}, {"LAX", "DEN"}, {"DEN", "ORD"}}, Directed())
+
+	path, err := LongestPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "LAX", "DEN", "ORD"}, path)
+}
+
+func TestLongestPathLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    [][2]string
+		vertices []string
+	}{
+		{
+			name:     "Single vertex",
+			vertices: []string{"SFO"},
+		},
+		{
+			name:  "Multiple routes",
+			edges: [][2]string{{"IND", "EWR"}, {"SFO", "ATL"}, {"GSO", "IND"}, {"ATL", "GSO"}},
+		},
+		{
+			name:  "Branching",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "LAX"}, {"LAX", "DEN"}, {"DEN", "ORD"}},
+		},
+		{
+			name:     "Disconnected",
+			edges:    [][2]string{{"SFO", "ATL"}, {"IND", "GSO"}, {"GSO", "EWR"}},
+			vertices: []string{"LAX"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+			for _, vertex := range test.vertices {
+				assert.NoError(t, g.AddVertex(vertex))
+			}
+
+			path, err := LongestPath(g)
+			assert.NoError(t, err)
+
+			length, err := LongestPathLength(g)
+			assert.NoError(t, err)
+			assert.Equal(t, len(path)-1, length)
+		})
+	}
+}
+
+func TestLongestPathLengthEdgeCases(t *testing.T) {
+	length, err := LongestPathLength(New(StringHash, Directed()))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, length)
+
+	cyclic := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "C"}, {"C", "A"}}, Directed())
+	_, err = LongestPathLength(cyclic)
+	assert.ErrorIs(t, err, ErrGraphHasCycle)
+
+	_, err = LongestPathLength(newStringGraph(t, [][2]string{{"A", "B"}}))
+	assert.Error(t, err)
+}
+
+func TestLongestWeightedPath(t *testing.T) {
+	g := New(StringHash, Directed(), Weighted())
+	for _, airport := range []string{"SFO", "ATL", "GSO", "EWR", "LAX"} {
+		assert.NoError(t, g.AddVertex(airport))
+	}
+	assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWeight(100)))
+	assert.NoError(t, g.AddEdge("ATL", "GSO", EdgeWeight(100)))
+	assert.NoError(t, g.AddEdge("GSO", "EWR", EdgeWeight(100)))
+	assert.NoError(t, g.AddEdge("SFO", "LAX", EdgeWeight(2000)))
+	assert.NoError(t, g.AddEdge("LAX", "EWR", EdgeWeight(1000)))
+
+	path, err := LongestWeightedPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "LAX", "EWR"}, path)
+
+	// By the number of hops, the route through ATL is the longest.
+	path, err = LongestPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "ATL", "GSO", "EWR"}, path)
+
+	cyclic := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "A"}}, Directed())
+	_, err = LongestWeightedPath(cyclic)
+	assert.ErrorIs(t, err, ErrGraphHasCycle)
+}
+
+func TestLongestPathEdgeCases(t *testing.T) {
+	g := New(StringHash, Directed())
+
+	path, err := LongestPath(g)
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+
+	assert.NoError(t, g.AddVertex("SFO"))
+	path, err = LongestPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO"}, path)
+
+	cyclic := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "C"}, {"C", "A"}}, Directed())
+	_, err = LongestPath(cyclic)
+	assert.ErrorIs(t, err, ErrGraphHasCycle)
+}
+
+func TestWouldStayAcyclic(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"GSO", "IND"}}, Directed())
+	batch := []Edge[string]{{Source: "EWR", Target: "IND"}, {Source: "IND", Target: "SFO"}}
+
+	// Each edge on its own is safe.
+	for _, edge := range batch {
+		createsCycle, err := CreatesCycle(g, edge.Source, edge.Target)
+		assert.NoError(t, err)
+		assert.False(t, createsCycle)
+
+		acyclic, cycle, err := WouldStayAcyclic(g, []Edge[string]{edge})
+		assert.NoError(t, err)
+		assert.True(t, acyclic)
+		assert.Nil(t, cycle)
+	}
+
+	acyclic, cycle, err := WouldStayAcyclic(g, batch)
+	assert.NoError(t, err)
+	assert.False(t, acyclic)
+	assert.Equal(t, []string{"ATL", "EWR", "IND", "SFO"}, rotateCycle(cycle))
+
+	// The graph isn't changed.
+	edges, err := g.Edges()
+	assert.NoError(t, err)
+	assert.Len(t, edges, 3)
+
+	_, _, err = WouldStayAcyclic(g, []Edge[string]{{Source: "EWR", Target: "LAX"}})
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+
+	_, _, err = WouldStayAcyclic(newStringGraph(t, [][2]string{{"SFO", "ATL"}}), batch)
+	assert.Error(t, err)
+}
+
+func TestWouldStayAcyclicLongChain(t *testing.T) {
+	const length = 10000
+
+	g := New(IntHash, Directed())
+	for i := 0; i < length; i++ {
+		assert.NoError(t, g.AddVertex(i))
+	}
+	for i := 0; i < length-1; i++ {
+		assert.NoError(t, g.AddEdge(i, i+1))
+	}
+
+	acyclic, cycle, err := WouldStayAcyclic(g, []Edge[int]{{Source: length - 1, Target: 0}})
+	assert.NoError(t, err)
+	assert.False(t, acyclic)
+	assert.Len(t, cycle, length)
+}
+
+func TestFeedbackEdgeSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     [][2]string
+		cycle     [][2]string
+		wantEdges int
+	}{
+		{
+			name:  "Acyclic",
+			edges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}},
+		},
+		{
+			name:      "One cycle",
+			edges:     [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}, {"EWR", "GSO"}},
+			cycle:     [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			wantEdges: 1,
+		},
+		{
+			name:      "Self-loop",
+			edges:     [][2]string{{"SFO", "SFO"}, {"SFO", "ATL"}},
+			cycle:     [][2]string{{"SFO", "SFO"}},
+			wantEdges: 1,
+		},
+		{
+			name:      "Two distinct cycles",
+			edges:     [][2]string{{"SFO", "ATL"}, {"ATL", "SFO"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "EWR"}},
+			wantEdges: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+
+			edges, err := FeedbackEdgeSet(g)
+			assert.NoError(t, err)
+			assert.Len(t, edges, test.wantEdges)
+
+			for _, edge := range edges {
+				if test.cycle != nil {
+					assert.Contains(t, test.cycle, [2]string{edge.Source, edge.Target})
+				}
+				assert.NoError(t, g.RemoveEdge(edge.Source, edge.Target))
+			}
+
+			_, err = TopologicalSort(g)
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err := FeedbackEdgeSet(newStringGraph(t, [][2]string{{"SFO", "ATL"}}))
+	assert.Error(t, err)
+}
+
+func TestFeedbackEdgeSetVisitOrder(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "SFO"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "EWR"}, {"IND", "ATL"}}
+	less := func(a, b string) bool { return a < b }
+
+	// Map iteration order differs between runs, so search a few times to make
+	// sure the result is stable.
+	for i := 0; i < 20; i++ {
+		g := newStringGraph(t, edges, Directed())
+
+		feedbackEdges, err := FeedbackEdgeSet(g, VisitOrder(less))
+		assert.NoError(t, err)
+
+		got := make([][2]string, 0, len(feedbackEdges))
+		for _, edge := range feedbackEdges {
+			got = append(got, [2]string{edge.Source, edge.Target})
+		}
+		assert.Equal(t, [][2]string{{"IND", "ATL"}, {"IND", "EWR"}, {"SFO", "ATL"}}, got)
+	}
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"iter"
 )
 
 type directed[K comparable, T any] struct {
@@ -23,9 +24,13 @@ func (d *directed[K, T]) Traits() *Traits {
 	return d.traits
 }
 
+func (d *directed[K, T]) Store() Store[K, T] {
+	return d.store
+}
+
 func (d *directed[K, T]) AddVertex(value T) error {
 	hash := d.hash(value)
-	return d.store.AddVertex(hash, value)
+	return addVertex(d.store, d.traits, hash, value)
 }
 
 func (d *directed[K, T]) Vertex(hash K) (T, error) {
@@ -37,15 +42,49 @@ func (d *directed[K, T]) RemoveVertex(hash K) error {
 	return d.store.RemoveVertex(hash)
 }
 
-func (d *directed[K, T]) AddEdge(sourceHash, targetHash K) error {
-	_, err := d.store.Vertex(sourceHash)
+func (d *directed[K, T]) SetVertexAttribute(hash K, key, value string) error {
+	return setVertexAttribute(d.store, hash, key, value)
+}
+
+func (d *directed[K, T]) VertexAttributes(hash K) (map[string]string, error) {
+	properties, err := d.store.VertexProperties(hash)
 	if err != nil {
-		return fmt.Errorf("source vertex %v: %w", sourceHash, err)
+		return nil, err
 	}
 
-	_, err = d.store.Vertex(targetHash)
+	return properties.Attributes, nil
+}
+
+func (d *directed[K, T]) AddEdge(sourceHash, targetHash K, options ...func(*EdgeProperties)) (err error) {
+	// The limit is checked first, so no vertices are auto-created for an edge
+	// which can't be added.
+	err = checkEdgeLimit(d.store, d.traits, func() bool {
+		_, err := d.store.Edge(sourceHash, targetHash)
+		return err == nil
+	})
 	if err != nil {
-		return fmt.Errorf("target vertex %v: %w", targetHash, err)
+		return err
+	}
+
+	// Vertices created for the edge are removed again if it's rejected.
+	var created []K
+	defer func() {
+		if err != nil {
+			removeVertices(d.store, created)
+		}
+	}()
+
+	for _, end := range []struct {
+		name string
+		hash K
+	}{{"source", sourceHash}, {"target", targetHash}} {
+		ok, err := vertexForEdge(d.store, d.traits, d.hash, end.hash)
+		if err != nil {
+			return fmt.Errorf("%s vertex %v: %w", end.name, end.hash, err)
+		}
+		if ok {
+			created = append(created, end.hash)
+		}
 	}
 
 	if _, err := d.Edge(sourceHash, targetHash); !errors.Is(err, ErrEdgeNotFound) {
@@ -68,11 +107,19 @@ func (d *directed[K, T]) AddEdge(sourceHash, targetHash K) error {
 		Target: targetHash,
 	}
 
+	for _, option := range options {
+		option(&edge.Properties)
+	}
+
+	if err := checkEdgeID(d.store, edge); err != nil {
+		return err
+	}
+
 	return d.addEdge(sourceHash, targetHash, edge)
 }
 
 func (d *directed[K, T]) Edge(sourceHash, targetHash K) (Edge[T], error) {
-	_, err := d.store.Edge(sourceHash, targetHash)
+	edge, err := d.store.Edge(sourceHash, targetHash)
 	if err != nil {
 		return Edge[T]{}, err
 	}
@@ -88,8 +135,9 @@ func (d *directed[K, T]) Edge(sourceHash, targetHash K) (Edge[T], error) {
 	}
 
 	return Edge[T]{
-		Source: sourceVertex,
-		Target: targetVertex,
+		Source:     sourceVertex,
+		Target:     targetVertex,
+		Properties: edge.Properties,
 	}, nil
 }
 
@@ -97,6 +145,14 @@ func (d *directed[K, T]) Edges() ([]Edge[K], error) {
 	return d.store.ListEdges()
 }
 
+func (d *directed[K, T]) EdgeList() ([]Edge[T], error) {
+	return edgeList(d.store)
+}
+
+func (d *directed[K, T]) CollapseParallelEdges(keep func(a, b EdgeProperties) EdgeProperties) error {
+	return collapseParallelEdges(d.store, true, keep)
+}
+
 func (d *directed[K, T]) RemoveEdge(source, target K) error {
 	if _, err := d.Edge(source, target); err != nil {
 		return err
@@ -120,17 +176,9 @@ func (d *directed[K, T]) AdjacencyMap() (map[K]map[K]Edge[K], error) {
 		return nil, fmt.Errorf("failed to list edges: %w", err)
 	}
 
-	m := make(map[K]map[K]Edge[K], len(vertices))
-
-	for _, vertex := range vertices {
-		m[vertex] = make(map[K]Edge[K])
-	}
-
-	for _, edge := range edges {
-		m[edge.Source][edge.Target] = edge
-	}
-
-	return m, nil
+	return edgeMap(vertices, edges, func(edge Edge[K]) (K, K) {
+		return edge.Source, edge.Target
+	}), nil
 }
 
 func (d *directed[K, T]) PredecessorMap() (map[K]map[K]Edge[K], error) {
@@ -144,24 +192,35 @@ func (d *directed[K, T]) PredecessorMap() (map[K]map[K]Edge[K], error) {
 		return nil, fmt.Errorf("failed to list edges: %w", err)
 	}
 
-	m := make(map[K]map[K]Edge[K], len(vertices))
+	return edgeMap(vertices, edges, func(edge Edge[K]) (K, K) {
+		return edge.Target, edge.Source
+	}), nil
+}
 
-	for _, vertex := range vertices {
-		m[vertex] = make(map[K]Edge[K])
+func (d *directed[K, T]) addEdge(sourceHash, targetHash K, edge Edge[K]) error {
+	return d.store.AddEdge(sourceHash, targetHash, edge)
+}
+
+func (d *directed[K, T]) Sources() ([]K, error) {
+	predecessorMap, err := d.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
 	}
 
-	for _, edge := range edges {
-		if _, ok := m[edge.Target]; !ok {
-			m[edge.Target] = make(map[K]Edge[K])
-		}
-		m[edge.Target][edge.Source] = edge
+	return verticesWithoutEdges(predecessorMap), nil
+}
+
+func (d *directed[K, T]) Sinks() ([]K, error) {
+	adjacencyMap, err := d.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
 	}
 
-	return m, nil
+	return verticesWithoutEdges(adjacencyMap), nil
 }
 
-func (d *directed[K, T]) addEdge(sourceHash, targetHash K, edge Edge[K]) error {
-	return d.store.AddEdge(sourceHash, targetHash, edge)
+func (d *directed[K, T]) DFSSeq(start K) iter.Seq[K] {
+	return dfsSeq[K, T](d, start)
 }
 
 func (d *directed[K, T]) Order() (int, error) {
@@ -193,10 +252,15 @@ func (d *directed[K, T]) edgesAreEqual(a, b Edge[T]) bool {
 
 func (d *directed[K, T]) createsCycle(source, target K) (bool, error) {
 	// If the underlying store implements CreatesCycle, use that fast path.
+	// Stores wrapping another one return errors.ErrUnsupported if the
+	// wrapped store doesn't.
 	if cc, ok := d.store.(interface {
 		CreatesCycle(source, target K) (bool, error)
 	}); ok {
-		return cc.CreatesCycle(source, target)
+		createsCycle, err := cc.CreatesCycle(source, target)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return createsCycle, err
+		}
 	}
 
 	// Slow path.
@@ -0,0 +1,446 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDirectedVertexAttributes(t *testing.T) {
+	g := New(StringHash, Directed())
+	assert.NoError(t, g.AddVertex("SFO"))
+
+	attributes, err := g.VertexAttributes("SFO")
+	assert.NoError(t, err)
+	assert.Empty(t, attributes)
+
+	assert.NoError(t, g.SetVertexAttribute("SFO", "lat", "37.6188"))
+	assert.NoError(t, g.SetVertexAttribute("SFO", "lon", "-122.3750"))
+	assert.NoError(t, g.SetVertexAttribute("SFO", "lat", "37.6190"))
+
+	attributes, err = g.VertexAttributes("SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"lat": "37.6190", "lon": "-122.3750"}, attributes)
+
+	// The returned attributes are a copy and don't affect the stored ones.
+	attributes["lat"] = "0"
+	attributes, err = g.VertexAttributes("SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, "37.6190", attributes["lat"])
+
+	vertex, err := g.Vertex("SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, "SFO", vertex)
+}
+
+func TestDirectedVertexAttributesNotFound(t *testing.T) {
+	g := New(StringHash, Directed())
+
+	assert.ErrorIs(t, g.SetVertexAttribute("SFO", "lat", "37.6188"), ErrVertexNotFound)
+
+	_, err := g.VertexAttributes("SFO")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+}
+
+func TestDirectedAutoCreateVertices(t *testing.T) {
+	g := New(StringHash, Directed(), AutoCreateVertices())
+	assert.NoError(t, g.AddVertex("SFO"))
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+	assert.NoError(t, g.AddEdge("ATL", "EWR"))
+
+	order, err := g.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order)
+
+	vertex, err := g.Vertex("EWR")
+	assert.NoError(t, err)
+	assert.Equal(t, "EWR", vertex)
+
+	_, err = g.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+}
+
+func TestDirectedStrictVertices(t *testing.T) {
+	g := New(StringHash, Directed())
+	assert.NoError(t, g.AddVertex("SFO"))
+
+	assert.ErrorIs(t, g.AddEdge("SFO", "ATL"), ErrVertexNotFound)
+	assert.ErrorIs(t, g.AddEdge("ATL", "SFO"), ErrVertexNotFound)
+
+	order, err := g.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, order)
+}
+
+func TestAutoCreateVerticesDistinctHash(t *testing.T) {
+	type city struct {
+		Name string
+	}
+
+	g := New(func(c city) string { return c.Name }, Directed(), AutoCreateVertices())
+	assert.NoError(t, g.AddVertex(city{Name: "London"}))
+
+	// The value of a city can't be derived from its hash.
+	assert.ErrorIs(t, g.AddEdge("London", "Paris"), ErrVertexNotFound)
+}
+
+func TestAutoCreateVerticesRejectedEdge(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*Traits)
+		edge    [2]string
+		wantErr error
+	}{
+		{
+			name:    "self-loop cycle",
+			options: []func(*Traits){Directed(), PreventCycles()},
+			edge:    [2]string{"EWR", "EWR"},
+			wantErr: ErrEdgeCreatesCycle,
+		},
+		{
+			name:    "undirected self-loop cycle",
+			options: []func(*Traits){PreventCycles()},
+			edge:    [2]string{"EWR", "EWR"},
+			wantErr: ErrEdgeCreatesCycle,
+		},
+		{
+			// The source fits the limit, the target doesn't.
+			name:    "vertex limit",
+			options: []func(*Traits){Directed(), WithLimits(3, 0)},
+			edge:    [2]string{"EWR", "IND"},
+			wantErr: ErrLimitExceeded,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New(StringHash, append(test.options, AutoCreateVertices())...)
+			assert.NoError(t, g.AddEdge("SFO", "ATL"))
+
+			assert.ErrorIs(t, g.AddEdge(test.edge[0], test.edge[1]), test.wantErr)
+
+			// The vertices created for the rejected edge are removed again.
+			_, err := g.Vertex("EWR")
+			assert.ErrorIs(t, err, ErrVertexNotFound)
+			order, err := g.Order()
+			assert.NoError(t, err)
+			assert.Equal(t, 2, order)
+		})
+	}
+}
+
+func TestAutoCreateVerticesHashCollision(t *testing.T) {
+	lower := func(s string) string { return strings.ToLower(s) }
+	g := New(lower, Directed(), AutoCreateVertices(), DetectHashCollisions())
+	assert.NoError(t, g.AddVertex("SFO"))
+
+	// "ATL" hashes to "atl", so it can't be stored under the hash "ATL".
+	assert.ErrorIs(t, g.AddEdge("sfo", "ATL"), ErrHashCollision)
+	assert.NoError(t, g.AddEdge("sfo", "atl"))
+
+	order, err := g.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, order)
+}
+
+func TestDetectHashCollisions(t *testing.T) {
+	type city struct {
+		Name    string
+		Country string
+	}
+
+	// Hashing only the first letter of the name is bound to collide.
+	hash := func(c city) string { return c.Name[:1] }
+
+	tests := []struct {
+		name    string
+		options []func(*Traits)
+		wantErr error
+	}{
+		{
+			name:    "directed",
+			options: []func(*Traits){Directed(), DetectHashCollisions()},
+			wantErr: ErrHashCollision,
+		},
+		{
+			name:    "undirected",
+			options: []func(*Traits){DetectHashCollisions()},
+			wantErr: ErrHashCollision,
+		},
+		{
+			name:    "detection disabled",
+			options: []func(*Traits){Directed()},
+			wantErr: ErrVertexAlreadyExists,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New(hash, test.options...)
+			assert.NoError(t, g.AddVertex(city{Name: "Paris", Country: "France"}))
+
+			assert.ErrorIs(t, g.AddVertex(city{Name: "Prague", Country: "Czechia"}), test.wantErr)
+			assert.ErrorIs(t, g.AddVertex(city{Name: "Paris", Country: "France"}), ErrVertexAlreadyExists)
+
+			// The first vertex is kept.
+			vertex, err := g.Vertex("P")
+			assert.NoError(t, err)
+			assert.Equal(t, city{Name: "Paris", Country: "France"}, vertex)
+		})
+	}
+}
+
+func TestWithLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   func() Store[string, string]
+		options []func(*Traits)
+	}{
+		{
+			name:    "directed",
+			options: []func(*Traits){Directed(), WithLimits(3, 2)},
+		},
+		{
+			name:    "undirected",
+			options: []func(*Traits){WithLimits(3, 2)},
+		},
+		{
+			// The edges are counted by listing them.
+			name: "store without edge count",
+			store: func() Store[string, string] {
+				return &listCountingStore{Store: NewMemoryStore[string, string]()}
+			},
+			options: []func(*Traits){Directed(), WithLimits(3, 2)},
+		},
+		{
+			name: "retrying store without edge count",
+			store: func() Store[string, string] {
+				return NewRetryingStore[string, string](&listCountingStore{Store: NewMemoryStore[string, string]()})
+			},
+			options: []func(*Traits){Directed(), WithLimits(3, 2)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New(StringHash, test.options...)
+			if test.store != nil {
+				g = NewWithStore(StringHash, test.store(), test.options...)
+			}
+
+			// Up to the limits.
+			for _, v := range []string{"SFO", "ATL", "EWR"} {
+				assert.NoError(t, g.AddVertex(v))
+			}
+			assert.NoError(t, g.AddEdge("SFO", "ATL"))
+			assert.NoError(t, g.AddEdge("ATL", "EWR"))
+
+			// Beyond the limits.
+			assert.ErrorIs(t, g.AddVertex("IND"), ErrLimitExceeded)
+			assert.ErrorIs(t, g.AddEdge("SFO", "EWR"), ErrLimitExceeded)
+
+			// Existing vertices and edges are still reported as such.
+			assert.ErrorIs(t, g.AddVertex("SFO"), ErrVertexAlreadyExists)
+			assert.ErrorIs(t, g.AddEdge("SFO", "ATL"), ErrEdgeAlreadyExists)
+
+			order, err := g.Order()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, order)
+			size, err := g.Size()
+			assert.NoError(t, err)
+			assert.Equal(t, 2, size)
+
+			// Removals make room again.
+			assert.NoError(t, g.RemoveEdge("ATL", "EWR"))
+			assert.NoError(t, g.AddEdge("SFO", "EWR"))
+		})
+	}
+}
+
+func TestWithLimitsAutoCreateVertices(t *testing.T) {
+	g := New(StringHash, Directed(), AutoCreateVertices(), WithLimits(2, 0))
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+	assert.ErrorIs(t, g.AddEdge("ATL", "EWR"), ErrLimitExceeded)
+
+	// Without an edge limit, edges between known vertices are fine.
+	assert.NoError(t, g.AddEdge("ATL", "SFO"))
+
+	g = New(StringHash, Directed(), AutoCreateVertices(), WithLimits(0, 1))
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+	assert.ErrorIs(t, g.AddEdge("ATL", "EWR"), ErrLimitExceeded)
+
+	// The edge which can't be added doesn't leave its vertices behind.
+	_, err := g.Vertex("EWR")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+}
+
+func TestStoreProvider(t *testing.T) {
+	store := NewMemoryStore[string, string]()
+
+	for _, options := range [][]func(*Traits){{Directed()}, nil} {
+		g := NewWithStore(StringHash, store, options...)
+		provider, ok := g.(StoreProvider[string, string])
+		if !assert.True(t, ok) {
+			continue
+		}
+		assert.Same(t, store, provider.Store())
+	}
+
+	g := NewWithStore(StringHash, store, Directed(), AutoCreateVertices())
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+
+	// Changes of the store are seen by the graph and vice versa.
+	provider := g.(StoreProvider[string, string])
+	assert.NoError(t, provider.Store().AddVertex("EWR", "EWR"))
+	_, err := g.Vertex("EWR")
+	assert.NoError(t, err)
+
+	stats, err := provider.Store().Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "memory", Vertices: 3, Edges: 1}, stats)
+}
+
+func TestDirectedSourcesAndSinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		edges       [][2]string
+		wantSources []string
+		wantSinks   []string
+	}{
+		{
+			name:        "single route",
+			edges:       [][2]string{{"SFO", "EWR"}},
+			wantSources: []string{"SFO"},
+			wantSinks:   []string{"EWR"},
+		},
+		{
+			name:        "multiple routes",
+			edges:       [][2]string{{"IND", "EWR"}, {"SFO", "ATL"}, {"GSO", "IND"}, {"ATL", "GSO"}},
+			wantSources: []string{"SFO"},
+			wantSinks:   []string{"EWR"},
+		},
+		{
+			name:        "branching routes",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"ATL", "GSO"}, {"IND", "GSO"}},
+			wantSources: []string{"SFO", "IND"},
+			wantSinks:   []string{"EWR", "GSO"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, Directed())
+
+			sources, err := g.Sources()
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantSources, sources)
+
+			sinks, err := g.Sinks()
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantSinks, sinks)
+		})
+	}
+}
+
+func TestDirectedEdgeList(t *testing.T) {
+	type airport struct {
+		Code string
+		City string
+	}
+
+	g := New(func(a airport) string { return a.Code }, Directed(), Weighted())
+	for _, a := range []airport{{"SFO", "San Francisco"}, {"ATL", "Atlanta"}, {"EWR", "Newark"}} {
+		assert.NoError(t, g.AddVertex(a))
+	}
+	assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWeight(4)))
+	assert.NoError(t, g.AddEdge("ATL", "EWR", EdgeWeight(2)))
+
+	edges, err := g.EdgeList()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Edge[airport]{
+		{
+			Source:     airport{"SFO", "San Francisco"},
+			Target:     airport{"ATL", "Atlanta"},
+			Properties: EdgeProperties{Weight: 4},
+		},
+		{
+			Source:     airport{"ATL", "Atlanta"},
+			Target:     airport{"EWR", "Newark"},
+			Properties: EdgeProperties{Weight: 2},
+		},
+	}, edges)
+}
+
+func TestDirectedCollapseParallelEdges(t *testing.T) {
+	store := newMultiStore()
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, store.AddVertex(v, v))
+	}
+	// Graph.AddEdge rejects parallel edges, so add them to the store directly.
+	for _, edge := range []Edge[string]{
+		{Source: "SFO", Target: "ATL", Properties: EdgeProperties{Weight: 300, Attributes: map[string]string{"carrier": "UA"}}},
+		{Source: "SFO", Target: "ATL", Properties: EdgeProperties{Weight: 250, Attributes: map[string]string{"carrier": "DL"}}},
+		{Source: "ATL", Target: "SFO", Properties: EdgeProperties{Weight: 100}},
+		{Source: "ATL", Target: "EWR", Properties: EdgeProperties{Weight: 150}},
+	} {
+		assert.NoError(t, store.AddEdge(edge.Source, edge.Target, edge))
+	}
+	g := NewWithStore(StringHash, store, Directed(), Weighted())
+
+	err := g.CollapseParallelEdges(func(a, b EdgeProperties) EdgeProperties {
+		if b.Weight < a.Weight {
+			return b
+		}
+		return a
+	})
+	assert.NoError(t, err)
+
+	edges, err := g.Edges()
+	assert.NoError(t, err)
+	assert.Len(t, edges, 3)
+
+	edge, err := g.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+	assert.Equal(t, 250.0, edge.Properties.Weight)
+	assert.Equal(t, "DL", edge.Properties.Attributes["carrier"])
+
+	// The opposite direction isn't parallel in a directed graph.
+	edge, err = g.Edge("ATL", "SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, edge.Properties.Weight)
+}
+
+func TestDirectedEdgeByID(t *testing.T) {
+	g := New(StringHash, Directed(), AutoCreateVertices())
+	assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWithID("DL1234"), EdgeWeight(2139)))
+	assert.NoError(t, g.AddEdge("ATL", "EWR", EdgeWithID("UA88")))
+	assert.NoError(t, g.AddEdge("EWR", "IND"))
+
+	edge, err := g.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+	assert.Equal(t, "DL1234", edge.Properties.ID)
+
+	byID, err := EdgeByID(g, "UA88")
+	assert.NoError(t, err)
+	assert.Equal(t, "ATL", byID.Source)
+	assert.Equal(t, "EWR", byID.Target)
+
+	byID, err = EdgeByID(g, "DL1234")
+	assert.NoError(t, err)
+	assert.Equal(t, 2139.0, byID.Properties.Weight)
+
+	_, err = EdgeByID(g, "AA1")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+
+	// Edges without an ID can't be looked up by an empty one.
+	_, err = EdgeByID(g, "")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+
+	// IDs are unique, and the rejected edge doesn't leave its vertices behind.
+	assert.ErrorIs(t, g.AddEdge("IND", "ORD", EdgeWithID("UA88")), ErrEdgeIDAlreadyExists)
+	_, err = g.Vertex("ORD")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+
+	// The edge can be removed by the endpoints of its lookup, which frees
+	// its ID.
+	assert.NoError(t, g.RemoveEdge(byID.Source, byID.Target))
+	_, err = EdgeByID(g, "DL1234")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+	assert.NoError(t, g.AddEdge("IND", "ORD", EdgeWithID("DL1234")))
+}
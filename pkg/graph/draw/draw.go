@@ -0,0 +1,350 @@
+// Package draw renders graphs in formats understood by visualization tools.
+//
+// Vertices and edges are written in a stable order, so rendering the same
+// graph twice yields the same document.
+package draw
+
+import (
+	"artemb/flights-path/pkg/graph"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DOT renders the graph as a Graphviz DOT document. Vertex attributes are
+// written as DOT attributes of the respective node:
+//
+//	strict digraph {
+//		"SFO" [lat="37.6188"];
+//		"ATL";
+//		"SFO" -> "ATL";
+//	}
+func DOT[K comparable, T any](g graph.Graph[K, T], w io.Writer) error {
+	return writeDOT(g, w, nil)
+}
+
+// ExportPathDOT renders the graph as a Graphviz DOT document like DOT, but
+// colors the vertices and edges of the given path red, for example to show a
+// route found in the graph:
+//
+//	strict digraph {
+//		"SFO" [color="red"];
+//		"SFO" -> "ATL" [color="red"];
+//	}
+//
+// An error is returned if the path uses an edge which isn't in the graph.
+func ExportPathDOT[K comparable, T any](g graph.Graph[K, T], path []K, w io.Writer) error {
+	highlight := &pathHighlight[K]{
+		vertices: make(map[K]bool, len(path)),
+		edges:    make(map[[2]K]bool, len(path)),
+	}
+
+	for i, vertex := range path {
+		if _, err := g.Vertex(vertex); err != nil {
+			return fmt.Errorf("could not get path vertex %v: %w", vertex, err)
+		}
+		highlight.vertices[vertex] = true
+
+		if i == 0 {
+			continue
+		}
+		if _, err := g.Edge(path[i-1], vertex); err != nil {
+			return fmt.Errorf("could not get path edge (%v, %v): %w", path[i-1], vertex, err)
+		}
+		highlight.edges[[2]K{path[i-1], vertex}] = true
+		if !g.Traits().IsDirected {
+			highlight.edges[[2]K{vertex, path[i-1]}] = true
+		}
+	}
+
+	return writeDOT(g, w, highlight)
+}
+
+const highlightColor = "red"
+
+// pathHighlight holds the vertices and edges to color in a DOT document.
+type pathHighlight[K comparable] struct {
+	vertices map[K]bool
+	edges    map[[2]K]bool
+}
+
+func writeDOT[K comparable, T any](g graph.Graph[K, T], w io.Writer, highlight *pathHighlight[K]) error {
+	vertices, edges, err := sortedGraph(g)
+	if err != nil {
+		return err
+	}
+
+	kind, connector := "graph", "--"
+	if g.Traits().IsDirected {
+		kind, connector = "digraph", "->"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "strict %s {\n", kind)
+
+	for _, vertex := range vertices {
+		attributes, err := g.VertexAttributes(vertex)
+		if err != nil {
+			return fmt.Errorf("failed to get attributes of vertex %v: %w", vertex, err)
+		}
+		if highlight != nil && highlight.vertices[vertex] {
+			colored := make(map[string]string, len(attributes)+1)
+			for key, value := range attributes {
+				colored[key] = value
+			}
+			colored["color"] = highlightColor
+			attributes = colored
+		}
+		fmt.Fprintf(&sb, "\t%s%s;\n", dotQuote(fmt.Sprint(vertex)), dotAttributes(attributes))
+	}
+
+	for _, edge := range edges {
+		var attributes map[string]string
+		if highlight != nil && highlight.edges[[2]K{edge.Source, edge.Target}] {
+			attributes = map[string]string{"color": highlightColor}
+		}
+		fmt.Fprintf(&sb, "\t%s %s %s%s;\n", dotQuote(fmt.Sprint(edge.Source)), connector, dotQuote(fmt.Sprint(edge.Target)), dotAttributes(attributes))
+	}
+
+	sb.WriteString("}\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func dotAttributes(attributes map[string]string) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, dotID(key)+"="+dotQuote(attributes[key]))
+	}
+
+	return " [" + strings.Join(pairs, ", ") + "]"
+}
+
+// dotID returns s as a DOT ID: unquoted if it's a plain identifier of
+// letters, digits and underscores, quoted otherwise. Keywords like "node" are
+// quoted as well.
+func dotID(s string) string {
+	if s == "" || dotKeywords[strings.ToLower(s)] {
+		return dotQuote(s)
+	}
+
+	for i, r := range s {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !(i > 0 && '0' <= r && r <= '9') {
+			return dotQuote(s)
+		}
+	}
+
+	return s
+}
+
+var dotKeywords = map[string]bool{"node": true, "edge": true, "graph": true, "digraph": true, "subgraph": true, "strict": true}
+
+// dotQuote returns s as a quoted DOT string. The DOT grammar only escapes
+// double quotes in quoted strings, but Graphviz reads backslashes in labels
+// as escapes like \n, so they're doubled to stay literal. Line breaks are
+// written as \n.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+// graphMLKey declares an attribute of the nodes or edges, which is then
+// given by the data elements referring to its ID.
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GraphML renders the graph as a GraphML document. Vertex and edge attributes
+// are declared as string keys and written as data of the nodes and edges:
+//
+//	<key id="d0" for="node" attr.name="lat" attr.type="string"></key>
+//	<graph id="G" edgedefault="directed">
+//	  <node id="SFO">
+//	    <data key="d0">37.6188</data>
+//	  </node>
+//	</graph>
+func GraphML[K comparable, T any](g graph.Graph[K, T], w io.Writer) error {
+	vertices, edges, err := sortedGraph(g)
+	if err != nil {
+		return err
+	}
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{
+			ID:          "G",
+			EdgeDefault: "undirected",
+			Nodes:       make([]graphMLNode, 0, len(vertices)),
+			Edges:       make([]graphMLEdge, 0, len(edges)),
+		},
+	}
+	if g.Traits().IsDirected {
+		doc.Graph.EdgeDefault = "directed"
+	}
+
+	vertexAttributes := make([]map[string]string, 0, len(vertices))
+	for _, vertex := range vertices {
+		attributes, err := g.VertexAttributes(vertex)
+		if err != nil {
+			return fmt.Errorf("failed to get attributes of vertex %v: %w", vertex, err)
+		}
+		vertexAttributes = append(vertexAttributes, attributes)
+	}
+
+	edgeAttributes := make([]map[string]string, 0, len(edges))
+	for _, edge := range edges {
+		edgeAttributes = append(edgeAttributes, edge.Properties.Attributes)
+	}
+
+	nodeKeys := doc.declareKeys("node", vertexAttributes)
+	edgeKeys := doc.declareKeys("edge", edgeAttributes)
+
+	for i, vertex := range vertices {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   fmt.Sprint(vertex),
+			Data: graphMLDataOf(nodeKeys, vertexAttributes[i]),
+		})
+	}
+
+	for i, edge := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: fmt.Sprint(edge.Source),
+			Target: fmt.Sprint(edge.Target),
+			Data:   graphMLDataOf(edgeKeys, edgeAttributes[i]),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// declareKeys adds a key for each attribute name used by the nodes or edges,
+// in alphabetical order, and returns the key IDs by attribute name.
+func (doc *graphML) declareKeys(kind string, attributes []map[string]string) map[string]string {
+	var names []string
+	ids := make(map[string]string)
+	for _, element := range attributes {
+		for name := range element {
+			if _, ok := ids[name]; !ok {
+				ids[name] = ""
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ids[name] = fmt.Sprintf("d%d", len(doc.Keys))
+		doc.Keys = append(doc.Keys, graphMLKey{ID: ids[name], For: kind, Name: name, Type: "string"})
+	}
+
+	return ids
+}
+
+// graphMLDataOf returns the data elements of the attributes, ordered by key.
+func graphMLDataOf(keys map[string]string, attributes map[string]string) []graphMLData {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := make([]graphMLData, 0, len(names))
+	for _, name := range names {
+		data = append(data, graphMLData{Key: keys[name], Value: attributes[name]})
+	}
+
+	return data
+}
+
+// sortedGraph returns the vertices and edges of the graph ordered by the
+// string representation of their hashes.
+func sortedGraph[K comparable, T any](g graph.Graph[K, T]) ([]K, []graph.Edge[K], error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get adjacency map: %w", err)
+	}
+
+	vertices := make([]K, 0, len(adjacencyMap))
+	for vertex := range adjacencyMap {
+		vertices = append(vertices, vertex)
+	}
+	sort.Slice(vertices, func(i, j int) bool {
+		return fmt.Sprint(vertices[i]) < fmt.Sprint(vertices[j])
+	})
+
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get edges: %w", err)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		si, sj := fmt.Sprint(edges[i].Source), fmt.Sprint(edges[j].Source)
+		if si != sj {
+			return si < sj
+		}
+		return fmt.Sprint(edges[i].Target) < fmt.Sprint(edges[j].Target)
+	})
+
+	return vertices, edges, nil
+}
@@ -0,0 +1,112 @@
+package draw
+
+import (
+	"artemb/flights-path/pkg/graph"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestGraph(t *testing.T, options ...func(*graph.Traits)) graph.Graph[string, string] {
+	g := graph.New(graph.StringHash, options...)
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+	assert.NoError(t, g.AddEdge("ATL", "EWR"))
+	assert.NoError(t, g.SetVertexAttribute("SFO", "lat", "37.6188"))
+
+	return g
+}
+
+func TestDOT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, DOT(newTestGraph(t, graph.Directed()), &buf))
+	assert.Equal(t, `strict digraph {
+	"ATL";
+	"EWR";
+	"SFO" [lat="37.6188"];
+	"ATL" -> "EWR";
+	"SFO" -> "ATL";
+}
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, DOT(newTestGraph(t), &buf))
+	assert.Contains(t, buf.String(), "strict graph {")
+	assert.Contains(t, buf.String(), `"SFO" -- "ATL";`)
+}
+
+func TestDOTEscaping(t *testing.T) {
+	g := graph.New(graph.StringHash, graph.Directed())
+	assert.NoError(t, g.AddVertex(`Say "hi"`))
+	assert.NoError(t, g.AddVertex(`C:\dir`))
+	assert.NoError(t, g.AddEdge(`Say "hi"`, `C:\dir`))
+	assert.NoError(t, g.SetVertexAttribute(`Say "hi"`, "label", "two\nlines"))
+	assert.NoError(t, g.SetVertexAttribute(`Say "hi"`, "node", "keyword"))
+	assert.NoError(t, g.SetVertexAttribute(`Say "hi"`, "x-y", `a\b`))
+
+	var buf bytes.Buffer
+	assert.NoError(t, DOT(g, &buf))
+	assert.Equal(t, `strict digraph {
+	"C:\\dir";
+	"Say \"hi\"" [label="two\nlines", "node"="keyword", "x-y"="a\\b"];
+	"Say \"hi\"" -> "C:\\dir";
+}
+`, buf.String())
+}
+
+func TestPathDOT(t *testing.T) {
+	g := newTestGraph(t, graph.Directed())
+	assert.NoError(t, g.AddEdge("SFO", "EWR"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, ExportPathDOT(g, []string{"SFO", "ATL", "EWR"}, &buf))
+	assert.Equal(t, `strict digraph {
+	"ATL" [color="red"];
+	"EWR" [color="red"];
+	"SFO" [color="red", lat="37.6188"];
+	"ATL" -> "EWR" [color="red"];
+	"SFO" -> "ATL" [color="red"];
+	"SFO" -> "EWR";
+}
+`, buf.String())
+
+	// The path may follow undirected edges in either direction.
+	buf.Reset()
+	assert.NoError(t, ExportPathDOT(newTestGraph(t), []string{"EWR", "ATL"}, &buf))
+	assert.Contains(t, buf.String(), `"ATL" -- "EWR" [color="red"];`)
+	assert.Contains(t, buf.String(), "\t\"SFO\" -- \"ATL\";\n")
+
+	assert.Error(t, ExportPathDOT(g, []string{"EWR", "SFO"}, &buf))
+}
+
+func TestGraphML(t *testing.T) {
+	g := newTestGraph(t, graph.Directed())
+	assert.NoError(t, g.SetVertexAttribute("EWR", "city", "Newark"))
+	assert.NoError(t, g.AddEdge("SFO", "EWR", graph.EdgeAttribute("carrier", "AT&T Air")))
+
+	var buf bytes.Buffer
+	assert.NoError(t, GraphML(g, &buf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="city" attr.type="string"></key>
+  <key id="d1" for="node" attr.name="lat" attr.type="string"></key>
+  <key id="d2" for="edge" attr.name="carrier" attr.type="string"></key>
+  <graph id="G" edgedefault="directed">
+    <node id="ATL"></node>
+    <node id="EWR">
+      <data key="d0">Newark</data>
+    </node>
+    <node id="SFO">
+      <data key="d1">37.6188</data>
+    </node>
+    <edge source="ATL" target="EWR"></edge>
+    <edge source="SFO" target="ATL"></edge>
+    <edge source="SFO" target="EWR">
+      <data key="d2">AT&amp;T Air</data>
+    </edge>
+  </graph>
+</graphml>
+`, buf.String())
+}
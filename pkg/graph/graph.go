@@ -1,14 +1,22 @@
 package graph
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+)
 
 var (
 	ErrVertexNotFound      = errors.New("vertex not found")
 	ErrVertexAlreadyExists = errors.New("vertex already exists")
 	ErrEdgeNotFound        = errors.New("edge not found")
 	ErrEdgeAlreadyExists   = errors.New("edge already exists")
+	ErrEdgeIDAlreadyExists = errors.New("edge ID already exists")
 	ErrEdgeCreatesCycle    = errors.New("edge would create a cycle")
 	ErrVertexHasEdges      = errors.New("vertex has edges")
+	ErrHashCollision       = errors.New("different vertices have the same hash")
+	ErrLimitExceeded       = errors.New("graph limit exceeded")
 )
 
 // Graph represents a generic graph data structure consisting of vertices of
@@ -19,7 +27,9 @@ type Graph[K comparable, T any] interface {
 	Traits() *Traits
 
 	// AddVertex creates a new vertex in the graph. If the vertex already exists
-	// in the graph, ErrVertexAlreadyExists will be returned.
+	// in the graph, ErrVertexAlreadyExists will be returned. If the graph
+	// detects hash collisions and a different vertex with the same hash exists,
+	// ErrHashCollision will be returned.
 	//
 	// AddVertex accepts a variety of functional options to set further edge
 	// details such as the weight or an attribute:
@@ -39,6 +49,20 @@ type Graph[K comparable, T any] interface {
 	// be returned. If the vertex doesn't exist, ErrVertexNotFound is returned.
 	RemoveVertex(hash K) error
 
+	// SetVertexAttribute sets the attribute with the given key of the vertex
+	// with the given hash, overwriting any previous value. The vertex value
+	// itself stays untouched, so attributes can be used to attach mutable
+	// metadata such as coordinates to a vertex:
+	//
+	//	_ = g.SetVertexAttribute("SFO", "lat", "37.6188")
+	//
+	// If the vertex doesn't exist, ErrVertexNotFound will be returned.
+	SetVertexAttribute(hash K, key, value string) error
+
+	// VertexAttributes returns a copy of the attributes of the vertex with the
+	// given hash or ErrVertexNotFound if it doesn't exist.
+	VertexAttributes(hash K) (map[string]string, error)
+
 	// AddEdge creates an edge between the source and the target vertex.
 	//
 	// If either vertex cannot be found, ErrVertexNotFound will be returned. If
@@ -46,9 +70,15 @@ type Graph[K comparable, T any] interface {
 	// prevention has been activated using PreventCycles and if adding the edge
 	// would create a cycle, ErrEdgeCreatesCycle will be returned.
 	//
-	//	_ = g.AddEdge("A", "B")
+	// If the graph has been created with AutoCreateVertices, missing vertices
+	// are added instead of returning ErrVertexNotFound.
+	//
+	// AddEdge accepts functional options to set further edge properties such
+	// as the weight or an attribute:
 	//
-	AddEdge(sourceHash, targetHash K) error
+	//	_ = g.AddEdge("A", "B", graph.EdgeWeight(4), graph.EdgeAttribute("carrier", "DL"))
+	//
+	AddEdge(sourceHash, targetHash K, options ...func(*EdgeProperties)) error
 
 	// Edge returns the edge joining two given vertices or ErrEdgeNotFound if
 	// the edge doesn't exist. In an undirected graph, an edge with swapped
@@ -59,6 +89,28 @@ type Graph[K comparable, T any] interface {
 	// Edge[K] and hence will contain the vertex hashes, not the vertex values.
 	Edges() ([]Edge[K], error)
 
+	// EdgeList returns a slice of all edges in the graph like Edges, but with
+	// the vertex values instead of the hashes. This is convenient when the
+	// vertex values carry richer data than the hashes.
+	EdgeList() ([]Edge[T], error)
+
+	// CollapseParallelEdges merges edges joining the same vertices, as they
+	// may be returned by stores supporting multigraphs, into a single edge.
+	// The properties of the merged edge are obtained by reducing the
+	// properties of the parallel edges with keep, e.g. to keep the cheapest
+	// flight among several carriers:
+	//
+	//	_ = g.CollapseParallelEdges(func(a, b graph.EdgeProperties) graph.EdgeProperties {
+	//		if b.Weight < a.Weight {
+	//			return b
+	//		}
+	//		return a
+	//	})
+	//
+	// The parallel edges are removed with the store's RemoveEdge, which must
+	// remove all edges between the two vertices.
+	CollapseParallelEdges(keep func(a, b EdgeProperties) EdgeProperties) error
+
 	// RemoveEdge removes the edge between the given source and target vertices.
 	// If the edge cannot be found, ErrEdgeNotFound will be returned.
 	RemoveEdge(source, target K) error
@@ -108,6 +160,33 @@ type Graph[K comparable, T any] interface {
 	// in an undirected graph.
 	PredecessorMap() (map[K]map[K]Edge[K], error)
 
+	// Sources returns the hashes of all vertices without ingoing edges, in no
+	// particular order. In a flight network, these are the origins.
+	//
+	// In an undirected graph, there is no distinction between ingoing and
+	// outgoing edges, so only vertices without any edges are returned.
+	Sources() ([]K, error)
+
+	// Sinks returns the hashes of all vertices without outgoing edges, in no
+	// particular order. In a flight network, these are the destinations.
+	//
+	// In an undirected graph, only vertices without any edges are returned.
+	Sinks() ([]K, error)
+
+	// DFSSeq returns the hashes of the vertices reachable from start in
+	// depth-first order as a sequence, which stops the traversal when the loop
+	// over it is left:
+	//
+	//	for hash := range g.DFSSeq("SFO") {
+	//		if hash == "EWR" {
+	//			break
+	//		}
+	//	}
+	//
+	// Unlike DFS, it doesn't report errors: the sequence is empty if start
+	// doesn't exist.
+	DFSSeq(start K) iter.Seq[K]
+
 	// Order returns the number of vertices in the graph.
 	Order() (int, error)
 
@@ -115,12 +194,70 @@ type Graph[K comparable, T any] interface {
 	Size() (int, error)
 }
 
+// StoreProvider is implemented by graphs which give access to the store they
+// were created with, including the graphs returned by New and NewWithStore.
+// It's an escape hatch for store-specific operations like backups:
+//
+//	if provider, ok := g.(graph.StoreProvider[string, string]); ok {
+//		stats, err := provider.Store().Stats()
+//	}
+//
+// Mutating the store directly bypasses the checks of the graph, like
+// PreventCycles or the existence of the vertices of an edge. It's up to the
+// caller to keep the graph consistent.
+type StoreProvider[K comparable, T any] interface {
+	Store() Store[K, T]
+}
+
+// VertexProperties represents the metadata of a vertex. Properties are stored
+// separately from the vertex value and can be changed at any time.
+type VertexProperties struct {
+	Attributes map[string]string
+}
+
 // Edge represents an edge that joins two vertices. Even though these edges are
 // always referred to as source and target, whether the graph is directed or not
 // is determined by its traits.
 type Edge[T any] struct {
-	Source T
-	Target T
+	Source     T
+	Target     T
+	Properties EdgeProperties
+}
+
+// EdgeProperties represents the metadata of an edge. The weight is only taken
+// into account by the path algorithms if the graph has been created with the
+// Weighted option. The ID is an optional opaque label like a flight number,
+// which tells otherwise identical edges apart.
+type EdgeProperties struct {
+	Attributes map[string]string
+	Weight     float64
+	ID         string
+}
+
+// EdgeWeight returns a functional option that sets the weight of an edge.
+func EdgeWeight(weight float64) func(*EdgeProperties) {
+	return func(e *EdgeProperties) {
+		e.Weight = weight
+	}
+}
+
+// EdgeWithID returns a functional option that sets the ID of an edge, by which
+// it can be looked up with EdgeByID. Adding an edge with an ID which another
+// edge already has fails with ErrEdgeIDAlreadyExists.
+func EdgeWithID(id string) func(*EdgeProperties) {
+	return func(e *EdgeProperties) {
+		e.ID = id
+	}
+}
+
+// EdgeAttribute returns a functional option that sets an attribute of an edge.
+func EdgeAttribute(key, value string) func(*EdgeProperties) {
+	return func(e *EdgeProperties) {
+		if e.Attributes == nil {
+			e.Attributes = make(map[string]string)
+		}
+		e.Attributes[key] = value
+	}
 }
 
 // Hash is a hashing function that takes a vertex of type T and returns a hash
@@ -141,10 +278,11 @@ type Hash[K comparable, T any] func(T) K
 // New creates a new graph with vertices of type T, identified by hash values of
 // type K. These hash values will be obtained using the provided hash function.
 //
-// The graph will use the default in-memory store for persisting vertices and
-// edges. To use a different [Store], use [NewWithStore].
+// The graph is undirected unless the Directed option is passed. It will use the
+// default in-memory store for persisting vertices and edges. To use a different
+// [Store], use [NewWithStore].
 func New[K comparable, T any](hash Hash[K, T], options ...func(*Traits)) Graph[K, T] {
-	return NewWithStore(hash, newMemoryStore[K, T](), options...)
+	return NewWithStore(hash, NewMemoryStore[K, T](), options...)
 }
 
 // NewWithStore creates a new graph same as [New] but uses the provided store
@@ -156,7 +294,11 @@ func NewWithStore[K comparable, T any](hash Hash[K, T], store Store[K, T], optio
 		option(&p)
 	}
 
-	return newDirected(hash, &p, store)
+	if p.IsDirected {
+		return newDirected(hash, &p, store)
+	}
+
+	return newUndirected(hash, &p, store)
 }
 
 // StringHash is a hashing function that accepts a string and uses that exact
@@ -170,3 +312,434 @@ func StringHash(v string) string {
 func IntHash(v int) int {
 	return v
 }
+
+// addVertex adds the vertex to the store under the given hash. If the graph
+// detects hash collisions, a different vertex with the same hash is reported
+// with ErrHashCollision.
+func addVertex[K comparable, T any](store Store[K, T], traits *Traits, hash K, value T) error {
+	if traits.DetectHashCollisions {
+		existing, err := store.Vertex(hash)
+		switch {
+		case errors.Is(err, ErrVertexNotFound):
+		case err != nil:
+			return err
+		case !reflect.DeepEqual(existing, value):
+			return fmt.Errorf("%w: %v and %v have the hash %v", ErrHashCollision, existing, value, hash)
+		}
+	}
+
+	if err := checkVertexLimit(store, traits, hash); err != nil {
+		return err
+	}
+
+	return store.AddVertex(hash, value)
+}
+
+// setVertexAttribute implements Graph.SetVertexAttribute. If the store
+// implements SetVertexAttribute itself, it's used to update the attribute in
+// one step, unless it returns errors.ErrUnsupported. Otherwise, the properties
+// are read and written back, so concurrent updates of the same vertex may
+// overwrite each other.
+func setVertexAttribute[K comparable, T any](store Store[K, T], hash K, key, value string) error {
+	if setter, ok := store.(interface {
+		SetVertexAttribute(hash K, key, value string) error
+	}); ok {
+		if err := setter.SetVertexAttribute(hash, key, value); !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+	}
+
+	properties, err := store.VertexProperties(hash)
+	if err != nil {
+		return err
+	}
+
+	if properties.Attributes == nil {
+		properties.Attributes = make(map[string]string, 1)
+	}
+	properties.Attributes[key] = value
+
+	return store.UpdateVertexProperties(hash, properties)
+}
+
+// checkVertexLimit returns ErrLimitExceeded if the store holds the maximum
+// number of vertices set with WithLimits, unless the vertex is already stored,
+// in which case ErrVertexAlreadyExists is returned.
+func checkVertexLimit[K comparable, T any](store Store[K, T], traits *Traits, hash K) error {
+	if traits.MaxVertices <= 0 {
+		return nil
+	}
+
+	count, err := store.VertexCount()
+	if err != nil {
+		return fmt.Errorf("failed to count vertices: %w", err)
+	}
+	if count < traits.MaxVertices {
+		return nil
+	}
+
+	if _, err := store.Vertex(hash); err == nil {
+		return ErrVertexAlreadyExists
+	}
+
+	return fmt.Errorf("%w: at most %d vertices", ErrLimitExceeded, traits.MaxVertices)
+}
+
+// checkEdgeLimit returns ErrLimitExceeded if the store holds the maximum
+// number of edges set with WithLimits, unless exists reports that the edge is
+// already stored, in which case ErrEdgeAlreadyExists is returned.
+func checkEdgeLimit[K comparable, T any](store Store[K, T], traits *Traits, exists func() bool) error {
+	if traits.MaxEdges <= 0 {
+		return nil
+	}
+
+	count, err := edgeCount(store)
+	if err != nil {
+		return fmt.Errorf("failed to count edges: %w", err)
+	}
+	if count < traits.MaxEdges {
+		return nil
+	}
+
+	if exists() {
+		return ErrEdgeAlreadyExists
+	}
+
+	return fmt.Errorf("%w: at most %d edges", ErrLimitExceeded, traits.MaxEdges)
+}
+
+// edgeCount returns the number of edges with the EdgeCount method of the store
+// if it has one, unless it returns errors.ErrUnsupported, and by listing the
+// edges otherwise.
+func edgeCount[K comparable, T any](store Store[K, T]) (int, error) {
+	if counter, ok := store.(interface {
+		EdgeCount() (int, error)
+	}); ok {
+		count, err := counter.EdgeCount()
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return count, err
+		}
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(edges), nil
+}
+
+// vertexForEdge makes sure the vertex with the given hash exists before adding
+// an edge to it. If the graph auto-creates vertices and the hash can be used as
+// the vertex value, a missing vertex is added and reported as created, so it
+// can be removed with removeVertices if the edge isn't added after all.
+// Otherwise, ErrVertexNotFound is returned for missing vertices.
+//
+// Like AddVertex, a graph detecting hash collisions rejects a value whose own
+// hash isn't the given one with ErrHashCollision.
+func vertexForEdge[K comparable, T any](store Store[K, T], traits *Traits, hashFn Hash[K, T], hash K) (bool, error) {
+	_, err := store.Vertex(hash)
+	if err == nil || !traits.AutoCreateVertices || !errors.Is(err, ErrVertexNotFound) {
+		return false, err
+	}
+
+	value, ok := any(hash).(T)
+	if !ok {
+		return false, err
+	}
+
+	if traits.DetectHashCollisions {
+		if valueHash := hashFn(value); valueHash != hash {
+			return false, fmt.Errorf("%w: %v has the hash %v, not %v", ErrHashCollision, value, valueHash, hash)
+		}
+	}
+
+	if err := checkVertexLimit(store, traits, hash); err != nil {
+		return false, err
+	}
+
+	err = store.AddVertex(hash, value)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrVertexAlreadyExists):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// RemoveVertexCascade removes the vertex along with all of its edges, e.g. an
+// airport which closed along with its flights, whereas Graph.RemoveVertex
+// fails with ErrVertexHasEdges. If the vertex doesn't exist, ErrVertexNotFound
+// is returned. Only graphs created by New or NewWithStore are supported.
+//
+// Stores implementing RemoveVertexEdges, like the memory store, remove the
+// edges in one operation. Other stores have their edges listed, and those of
+// the vertex removed one by one, which takes O(E) time.
+func RemoveVertexCascade[K comparable, T any](g Graph[K, T], hash K) error {
+	_, store, err := internalsOf(g)
+	if err != nil {
+		return err
+	}
+
+	if err := removeVertexEdges(store, hash); err != nil {
+		return err
+	}
+
+	return store.RemoveVertex(hash)
+}
+
+// removeVertexEdges removes all edges from and to the vertex with the
+// RemoveVertexEdges method of the store if it has one, unless it returns
+// errors.ErrUnsupported, and one by one otherwise.
+func removeVertexEdges[K comparable, T any](store Store[K, T], hash K) error {
+	if remover, ok := store.(interface {
+		RemoveVertexEdges(hash K) error
+	}); ok {
+		if err := remover.RemoveVertexEdges(hash); !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+	}
+
+	if _, err := store.Vertex(hash); err != nil {
+		return err
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	for _, edge := range edges {
+		if edge.Source != hash && edge.Target != hash {
+			continue
+		}
+		if err := store.RemoveEdge(edge.Source, edge.Target); err != nil {
+			return fmt.Errorf("failed to remove edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+	}
+
+	return nil
+}
+
+// removeVertices removes the vertices created by vertexForEdge for an edge
+// which couldn't be added. They don't have any edges, so failures are ignored.
+func removeVertices[K comparable, T any](store Store[K, T], hashes []K) {
+	for _, hash := range hashes {
+		_ = store.RemoveVertex(hash)
+	}
+}
+
+// EdgeByID returns the edge with the given ID, which has been set with the
+// EdgeWithID option, e.g. to address a flight by its number. If no edge has
+// the ID, ErrEdgeNotFound is returned.
+//
+// An ID names an edge, but doesn't make it distinct: edges are still keyed by
+// their source and target, so two flights between the same airports can't be
+// stored side by side, whatever their IDs.
+//
+// Stores indexing the IDs, like the memory store, find the edge in O(1) time.
+// Other stores are scanned, which takes O(E) time. Only graphs created by New
+// or NewWithStore are supported.
+func EdgeByID[K comparable, T any](g Graph[K, T], id string) (Edge[K], error) {
+	_, store, err := internalsOf(g)
+	if err != nil {
+		return Edge[K]{}, err
+	}
+
+	return edgeByID(store, id)
+}
+
+// edgeByID looks the edge with the given ID up in the index of the store if it
+// has one, unless it returns errors.ErrUnsupported, and scans the edges of the
+// store otherwise.
+func edgeByID[K comparable, T any](store Store[K, T], id string) (Edge[K], error) {
+	// Edges without an ID can't be looked up.
+	if id == "" {
+		return Edge[K]{}, ErrEdgeNotFound
+	}
+
+	if index, ok := store.(interface {
+		EdgeByID(id string) (Edge[K], error)
+	}); ok {
+		edge, err := index.EdgeByID(id)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return edge, err
+		}
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return Edge[K]{}, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	for _, edge := range edges {
+		if edge.Properties.ID == id {
+			return edge, nil
+		}
+	}
+
+	return Edge[K]{}, ErrEdgeNotFound
+}
+
+// checkEdgeID returns ErrEdgeIDAlreadyExists if the edge has an ID which
+// another edge of the store already has.
+func checkEdgeID[K comparable, T any](store Store[K, T], edge Edge[K]) error {
+	if edge.Properties.ID == "" {
+		return nil
+	}
+
+	_, err := edgeByID(store, edge.Properties.ID)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: %s", ErrEdgeIDAlreadyExists, edge.Properties.ID)
+	case errors.Is(err, ErrEdgeNotFound):
+		return nil
+	default:
+		return fmt.Errorf("failed to look up edge ID %s: %w", edge.Properties.ID, err)
+	}
+}
+
+// edgeList resolves the hashes of all edges in the store to the vertex values.
+// The values are looked up once per vertex rather than once per edge.
+func edgeList[K comparable, T any](store Store[K, T]) ([]Edge[T], error) {
+	hashes, err := store.ListVertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	values := make(map[K]T, len(hashes))
+	for _, hash := range hashes {
+		value, err := store.Vertex(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+		values[hash] = value
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	list := make([]Edge[T], 0, len(edges))
+	for _, edge := range edges {
+		list = append(list, Edge[T]{
+			Source:     values[edge.Source],
+			Target:     values[edge.Target],
+			Properties: edge.Properties,
+		})
+	}
+
+	return list, nil
+}
+
+// collapseParallelEdges merges the parallel edges in the store as described by
+// Graph.CollapseParallelEdges. In undirected graphs, edges joining the same
+// vertices in opposite orientations are parallel too.
+func collapseParallelEdges[K comparable, T any](store Store[K, T], directed bool, keep func(a, b EdgeProperties) EdgeProperties) error {
+	edges, err := store.ListEdges()
+	if err != nil {
+		return fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	type pair struct {
+		source, target K
+	}
+
+	groups := make(map[pair][]Edge[K])
+	order := make([]pair, 0)
+	for _, edge := range edges {
+		key := pair{edge.Source, edge.Target}
+		if _, ok := groups[pair{edge.Target, edge.Source}]; ok && !directed {
+			key = pair{edge.Target, edge.Source}
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], edge)
+	}
+
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+
+		properties := group[0].Properties
+		for _, edge := range group[1:] {
+			properties = keep(properties, edge.Properties)
+		}
+
+		removed := make(map[pair]bool)
+		for _, edge := range group {
+			if p := (pair{edge.Source, edge.Target}); !removed[p] {
+				if err := store.RemoveEdge(edge.Source, edge.Target); err != nil {
+					return fmt.Errorf("failed to remove edges from %v to %v: %w", edge.Source, edge.Target, err)
+				}
+				removed[p] = true
+			}
+		}
+
+		edge := Edge[K]{Source: key.source, Target: key.target, Properties: properties}
+		if err := store.AddEdge(key.source, key.target, edge); err != nil {
+			return fmt.Errorf("failed to add edge from %v to %v: %w", key.source, key.target, err)
+		}
+	}
+
+	return nil
+}
+
+// verticesWithoutEdges returns the vertices whose entry in the given adjacency
+// or predecessor map is empty.
+func verticesWithoutEdges[K comparable](m map[K]map[K]Edge[K]) []K {
+	hashes := make([]K, 0)
+	for hash, edges := range m {
+		if len(edges) == 0 {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// edgeMap builds an adjacency or predecessor map of the vertices. Each edge is
+// added to the inner map of the vertex returned by key, under the hash of the
+// vertex at its other end.
+//
+// Stores usually list the edges of a vertex one after another, like the memory
+// store does for their sources, so the inner maps are created for such a run
+// of edges and presized by its length. This saves growing them while they're
+// filled without counting the edges of each vertex up front.
+func edgeMap[K comparable](vertices []K, edges []Edge[K], key func(edge Edge[K]) (K, K)) map[K]map[K]Edge[K] {
+	m := make(map[K]map[K]Edge[K], len(vertices))
+
+	for i := 0; i < len(edges); {
+		vertex, _ := key(edges[i])
+		end := i + 1
+		for end < len(edges) {
+			if next, _ := key(edges[end]); next != vertex {
+				break
+			}
+			end++
+		}
+
+		inner, ok := m[vertex]
+		if !ok {
+			inner = make(map[K]Edge[K], end-i)
+			m[vertex] = inner
+		}
+		for ; i < end; i++ {
+			_, other := key(edges[i])
+			inner[other] = edges[i]
+		}
+	}
+
+	for _, vertex := range vertices {
+		if _, ok := m[vertex]; !ok {
+			m[vertex] = make(map[K]Edge[K])
+		}
+	}
+
+	return m
+}
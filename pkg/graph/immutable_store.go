@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrReadOnlyStore = errors.New("store is read-only")
+
+// immutableStore is a snapshot of another store. Since it never changes after
+// being created, it's safe for concurrent reads without any locking.
+type immutableStore[K comparable, T any] struct {
+	vertices         map[K]T
+	vertexProperties map[K]VertexProperties
+	outEdges         map[K]map[K]Edge[K]
+	edgeIDs          map[string]Edge[K]
+	hashes           []K
+	edges            []Edge[K]
+}
+
+// NewImmutableStore creates a read-only snapshot of src. Later changes of src
+// aren't reflected by the snapshot, and all mutations of the snapshot fail
+// with ErrReadOnlyStore. Reads don't need any locking, which makes it suitable
+// for serving a fixed reference graph under high concurrency.
+func NewImmutableStore[K comparable, T any](src Store[K, T]) (Store[K, T], error) {
+	hashes, err := src.ListVertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	edges, err := src.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	s := &immutableStore[K, T]{
+		vertices:         make(map[K]T, len(hashes)),
+		vertexProperties: make(map[K]VertexProperties, len(hashes)),
+		outEdges:         make(map[K]map[K]Edge[K], len(hashes)),
+		edgeIDs:          make(map[string]Edge[K]),
+		hashes:           hashes,
+		edges:            edges,
+	}
+
+	for _, hash := range hashes {
+		value, err := src.Vertex(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+
+		properties, err := src.VertexProperties(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get properties of vertex %v: %w", hash, err)
+		}
+
+		s.vertices[hash] = value
+		s.vertexProperties[hash] = properties
+	}
+
+	for _, edge := range edges {
+		if _, ok := s.outEdges[edge.Source]; !ok {
+			s.outEdges[edge.Source] = make(map[K]Edge[K])
+		}
+		s.outEdges[edge.Source][edge.Target] = edge
+		if edge.Properties.ID != "" {
+			s.edgeIDs[edge.Properties.ID] = edge
+		}
+	}
+
+	return s, nil
+}
+
+func (s *immutableStore[K, T]) AddVertex(K, T) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) Vertex(k K) (T, error) {
+	v, ok := s.vertices[k]
+	if !ok {
+		return v, ErrVertexNotFound
+	}
+
+	return v, nil
+}
+
+func (s *immutableStore[K, T]) RemoveVertex(K) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) VertexProperties(k K) (VertexProperties, error) {
+	properties, ok := s.vertexProperties[k]
+	if !ok {
+		return VertexProperties{}, ErrVertexNotFound
+	}
+
+	attributes := make(map[string]string, len(properties.Attributes))
+	for key, value := range properties.Attributes {
+		attributes[key] = value
+	}
+
+	return VertexProperties{Attributes: attributes}, nil
+}
+
+func (s *immutableStore[K, T]) UpdateVertexProperties(K, VertexProperties) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) ListVertices() ([]K, error) {
+	return append([]K{}, s.hashes...), nil
+}
+
+func (s *immutableStore[K, T]) VertexCount() (int, error) {
+	return len(s.hashes), nil
+}
+
+func (s *immutableStore[K, T]) AddEdge(K, K, Edge[K]) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) RemoveEdge(K, K) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) RemoveVertexEdges(K) error {
+	return ErrReadOnlyStore
+}
+
+func (s *immutableStore[K, T]) Edge(sourceHash, targetHash K) (Edge[K], error) {
+	edge, ok := s.outEdges[sourceHash][targetHash]
+	if !ok {
+		return Edge[K]{}, ErrEdgeNotFound
+	}
+
+	return edge, nil
+}
+
+// Successors lists the targets of the outgoing edges of the vertex.
+func (s *immutableStore[K, T]) Successors(k K) ([]K, error) {
+	if _, ok := s.vertices[k]; !ok {
+		return nil, ErrVertexNotFound
+	}
+
+	return sortedKeys(s.outEdges[k], nil), nil
+}
+
+// EdgeByID looks the edge up in the index of the edge IDs built along with
+// the snapshot.
+func (s *immutableStore[K, T]) EdgeByID(id string) (Edge[K], error) {
+	edge, ok := s.edgeIDs[id]
+	if !ok {
+		return Edge[K]{}, ErrEdgeNotFound
+	}
+
+	return edge, nil
+}
+
+func (s *immutableStore[K, T]) ListEdges() ([]Edge[K], error) {
+	return append([]Edge[K]{}, s.edges...), nil
+}
+
+// EdgeCount counts the edges of the snapshot without copying them.
+func (s *immutableStore[K, T]) EdgeCount() (int, error) {
+	return len(s.edges), nil
+}
+
+func (s *immutableStore[K, T]) Stats() (StoreStats, error) {
+	return StoreStats{Type: "immutable", Vertices: len(s.hashes), Edges: len(s.edges)}, nil
+}
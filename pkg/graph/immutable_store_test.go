@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestImmutableStore(t *testing.T) {
+	src := NewMemoryStore[string, string]()
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, src.AddVertex(v, v))
+	}
+	assert.NoError(t, src.UpdateVertexProperties("SFO", VertexProperties{Attributes: map[string]string{"city": "San Francisco"}}))
+	assert.NoError(t, src.AddEdge("SFO", "ATL", Edge[string]{Source: "SFO", Target: "ATL"}))
+	assert.NoError(t, src.AddEdge("ATL", "EWR", Edge[string]{Source: "ATL", Target: "EWR"}))
+
+	store, err := NewImmutableStore(src)
+	assert.NoError(t, err)
+
+	// Later changes of the source aren't part of the snapshot.
+	assert.NoError(t, src.AddVertex("IND", "IND"))
+
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "immutable", Vertices: 3, Edges: 2}, stats)
+
+	properties, err := store.VertexProperties("SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, "San Francisco", properties.Attributes["city"])
+
+	_, err = store.Vertex("IND")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+	_, err = store.Edge("ATL", "SFO")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+
+	assert.ErrorIs(t, store.AddVertex("IND", "IND"), ErrReadOnlyStore)
+	assert.ErrorIs(t, store.RemoveVertex("SFO"), ErrReadOnlyStore)
+	assert.ErrorIs(t, store.UpdateVertexProperties("SFO", VertexProperties{}), ErrReadOnlyStore)
+	assert.ErrorIs(t, store.AddEdge("EWR", "SFO", Edge[string]{Source: "EWR", Target: "SFO"}), ErrReadOnlyStore)
+	assert.ErrorIs(t, store.RemoveEdge("SFO", "ATL"), ErrReadOnlyStore)
+	assert.ErrorIs(t, removeVertexEdges(store, "SFO"), ErrReadOnlyStore)
+
+	g := NewWithStore(StringHash, store, Directed())
+	assert.ErrorIs(t, g.AddEdge("EWR", "SFO"), ErrReadOnlyStore)
+}
+
+// TestImmutableStoreConcurrentReads is meant to be run with -race.
+func TestImmutableStoreConcurrentReads(t *testing.T) {
+	src := NewMemoryStore[string, string]()
+	g := NewWithStore(StringHash, src, Directed())
+	for _, edge := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "DEN"}, {"DEN", "EWR"}} {
+		for _, v := range edge {
+			if _, err := g.Vertex(v); err != nil {
+				assert.NoError(t, g.AddVertex(v))
+			}
+		}
+		assert.NoError(t, g.AddEdge(edge[0], edge[1]))
+	}
+
+	store, err := NewImmutableStore(src)
+	assert.NoError(t, err)
+	snapshot := NewWithStore(StringHash, store, Directed())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				path, err := ShortestPath(snapshot, "SFO", "EWR")
+				assert.NoError(t, err)
+				assert.Len(t, path, 3)
+
+				_, err = snapshot.VertexAttributes("ATL")
+				assert.NoError(t, err)
+
+				edges, err := snapshot.Edges()
+				assert.NoError(t, err)
+				assert.Len(t, edges, 4)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LoadEdgeList reads an edge list into the store. Each line holds the source
+// and target vertex of one edge, separated by whitespace. Empty lines and lines
+// starting with # are skipped:
+//
+//	# known connections
+//	SFO ATL
+//	ATL EWR
+//
+// Vertices are created as needed and duplicated edges are added only once. The
+// edges are added to the store directly, so no cycle checks are performed.
+func LoadEdgeList(r io.Reader, store Store[string, string]) error {
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: expected source and target, got %q", line, text)
+		}
+
+		for _, vertex := range fields {
+			err := store.AddVertex(vertex, vertex)
+			if err != nil && !errors.Is(err, ErrVertexAlreadyExists) {
+				return fmt.Errorf("line %d: failed to add vertex %s: %w", line, vertex, err)
+			}
+		}
+
+		if _, err := store.Edge(fields[0], fields[1]); err == nil {
+			continue
+		}
+
+		err := store.AddEdge(fields[0], fields[1], Edge[string]{Source: fields[0], Target: fields[1]})
+		if err != nil {
+			return fmt.Errorf("line %d: failed to add edge: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestLoadEdgeList(t *testing.T) {
+	store := NewMemoryStore[string, string]()
+	err := LoadEdgeList(strings.NewReader(`
+# known connections
+SFO ATL
+ATL	EWR
+  ATL EWR
+EWR SFO
+`), store)
+	assert.NoError(t, err)
+
+	count, err := store.VertexCount()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	edges, err := store.ListEdges()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Edge[string]{
+		{Source: "SFO", Target: "ATL"},
+		{Source: "ATL", Target: "EWR"},
+		{Source: "EWR", Target: "SFO"},
+	}, edges)
+
+	g := NewWithStore(StringHash, store, Directed())
+	_, err = g.Edge("ATL", "EWR")
+	assert.NoError(t, err)
+}
+
+func TestLoadEdgeListMalformed(t *testing.T) {
+	err := LoadEdgeList(strings.NewReader("SFO ATL\nATL\n"), NewMemoryStore[string, string]())
+	assert.EqualError(t, err, `line 2: expected source and target, got "ATL"`)
+}
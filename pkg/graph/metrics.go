@@ -0,0 +1,338 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrGraphNotConnected = errors.New("graph is not connected")
+
+// Density returns the ratio of the edges in the graph to the maximum number of
+// edges it could have: V*(V-1) for directed and V*(V-1)/2 for undirected
+// graphs. Graphs with fewer than two vertices have a density of 0.
+func Density[K comparable, T any](g Graph[K, T]) (float64, error) {
+	order, err := g.Order()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get graph order: %w", err)
+	}
+
+	if order < 2 {
+		return 0, nil
+	}
+
+	size, err := g.Size()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get graph size: %w", err)
+	}
+
+	maxEdges := float64(order) * float64(order-1)
+	if !g.Traits().IsDirected {
+		maxEdges /= 2
+	}
+
+	return float64(size) / maxEdges, nil
+}
+
+// DegreeOptions configure the degrees counted by DegreeHistogram.
+type DegreeOptions struct {
+	// In counts the ingoing and Out the outgoing edges of each vertex. Both
+	// are counted if neither is set. They make no difference in undirected
+	// graphs.
+	In  bool
+	Out bool
+}
+
+// InDegree makes DegreeHistogram count only the ingoing edges of each vertex.
+func InDegree() func(*DegreeOptions) {
+	return func(o *DegreeOptions) {
+		o.In = true
+	}
+}
+
+// OutDegree makes DegreeHistogram count only the outgoing edges of each vertex.
+func OutDegree() func(*DegreeOptions) {
+	return func(o *DegreeOptions) {
+		o.Out = true
+	}
+}
+
+// DegreeHistogram maps each degree to the number of vertices with that degree.
+// In a flight network, the hubs are the few vertices with a high degree, while
+// most airports only have a few connections.
+//
+// The degree of a vertex in a directed graph is the number of its ingoing and
+// outgoing edges, the InDegree and OutDegree options count only one of them.
+// In an undirected graph, it's the number of its adjacent vertices.
+func DegreeHistogram[K comparable, T any](g Graph[K, T], options ...func(*DegreeOptions)) (map[int]int, error) {
+	var opts DegreeOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	if !opts.In && !opts.Out {
+		opts.In, opts.Out = true, true
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	histogram := make(map[int]int)
+
+	if !g.Traits().IsDirected {
+		for _, adjacencies := range adjacencyMap {
+			histogram[len(adjacencies)]++
+		}
+		return histogram, nil
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	for vertex, adjacencies := range adjacencyMap {
+		degree := 0
+		if opts.In {
+			degree += len(predecessorMap[vertex])
+		}
+		if opts.Out {
+			degree += len(adjacencies)
+		}
+		histogram[degree]++
+	}
+
+	return histogram, nil
+}
+
+// CenterOptions configure the computation of the center by Center.
+type CenterOptions struct {
+	// PerComponent computes the center of each weakly connected component
+	// instead of failing for disconnected graphs.
+	PerComponent bool
+}
+
+// PerComponent makes Center return the centers of all weakly connected
+// components of a disconnected graph. The eccentricity of a vertex then only
+// takes the vertices reachable from it into account.
+func PerComponent() func(*CenterOptions) {
+	return func(o *CenterOptions) {
+		o.PerComponent = true
+	}
+}
+
+// Eccentricity returns the greatest number of edges on the shortest paths from
+// the vertex to all other vertices. Edge weights are ignored. If some vertex
+// can't be reached from the vertex, ErrGraphNotConnected is returned.
+func Eccentricity[K comparable, T any](g Graph[K, T], vertex K) (int, error) {
+	order, err := g.Order()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get graph order: %w", err)
+	}
+
+	eccentricity, reached, err := eccentricity(g, vertex)
+	if err != nil {
+		return 0, err
+	}
+
+	if reached < order {
+		return 0, ErrGraphNotConnected
+	}
+
+	return eccentricity, nil
+}
+
+// eccentricity returns the eccentricity of the vertex among the vertices
+// reachable from it and the number of these vertices, including itself.
+func eccentricity[K comparable, T any](g Graph[K, T], vertex K) (int, int, error) {
+	layers, err := BFSLayers(g, vertex)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reached := 0
+	for _, layer := range layers {
+		reached += len(layer)
+	}
+
+	return len(layers) - 1, reached, nil
+}
+
+// Diameter returns the greatest eccentricity of all vertices, i.e. the number
+// of connections of the longest trip in the network when always flying the
+// shortest route. Edge weights are ignored, and an empty graph has a diameter
+// of 0.
+//
+// ErrGraphNotConnected is returned if not all vertices can be reached from each
+// other. Diameter runs a BFS from each vertex, which takes O(V*(V+E)) time.
+func Diameter[K comparable, T any](g Graph[K, T]) (int, error) {
+	return extremeEccentricity(g, func(a, b int) bool {
+		return a > b
+	})
+}
+
+// Radius returns the least eccentricity of all vertices, i.e. the eccentricity
+// of the vertices returned by Center. In undirected graphs, it's at least half
+// the diameter, rounded up. Like for Diameter, edge weights are ignored, an
+// empty graph has a radius of 0, and ErrGraphNotConnected is returned for
+// disconnected graphs.
+func Radius[K comparable, T any](g Graph[K, T]) (int, error) {
+	return extremeEccentricity(g, func(a, b int) bool {
+		return a < b
+	})
+}
+
+// extremeEccentricity returns the eccentricity which is preferred over all
+// others by better, or ErrGraphNotConnected if some vertex can't be reached
+// from another.
+func extremeEccentricity[K comparable, T any](g Graph[K, T], better func(a, b int) bool) (int, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	extreme, found := 0, false
+	for vertex := range adjacencyMap {
+		e, reached, err := eccentricity(g, vertex)
+		if err != nil {
+			return 0, err
+		}
+		if reached < len(adjacencyMap) {
+			return 0, ErrGraphNotConnected
+		}
+
+		if !found || better(e, extreme) {
+			extreme, found = e, true
+		}
+	}
+
+	return extreme, nil
+}
+
+// AverageShortestPathLength returns the average number of edges on the
+// shortest paths between all ordered pairs of distinct vertices, where the
+// second vertex can be reached from the first. Edge weights are ignored, and
+// unreachable pairs are left out. It tells how many connections apart the
+// airports of a flight network are on average. Graphs without any reachable
+// pair, like a single vertex, have an average of 0.
+//
+// AverageShortestPathLength runs a BFS from each vertex on an adjacency map
+// built once, which takes O(V*(V+E)) time.
+func AverageShortestPathLength[K comparable, T any](g Graph[K, T]) (float64, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	total, pairs := 0, 0
+	for vertex := range adjacencyMap {
+		layers := bfsLayers(adjacencyMap, vertex)
+		for distance, layer := range layers[1:] {
+			total += (distance + 1) * len(layer)
+			pairs += len(layer)
+		}
+	}
+
+	if pairs == 0 {
+		return 0, nil
+	}
+
+	return float64(total) / float64(pairs), nil
+}
+
+// Center returns the vertices with the minimum eccentricity, i.e. the vertices
+// from which all others can be reached with the fewest connections. In a
+// flight network, they're the best places for a hub. The vertices are returned
+// in no particular order, and an empty graph has no center.
+//
+// ErrGraphNotConnected is returned if not all vertices can be reached from each
+// other, unless the PerComponent option is given. Center runs a BFS from each
+// vertex, which takes O(V*(V+E)) time.
+func Center[K comparable, T any](g Graph[K, T], options ...func(*CenterOptions)) ([]K, error) {
+	var opts CenterOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	eccentricities := make(map[K]int, len(adjacencyMap))
+	for vertex := range adjacencyMap {
+		e, reached, err := eccentricity(g, vertex)
+		if err != nil {
+			return nil, err
+		}
+		if reached < len(adjacencyMap) && !opts.PerComponent {
+			return nil, ErrGraphNotConnected
+		}
+		eccentricities[vertex] = e
+	}
+
+	components := [][]K{make([]K, 0, len(adjacencyMap))}
+	if opts.PerComponent {
+		predecessorMap, err := g.PredecessorMap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+		}
+		components = weaklyConnectedComponents(adjacencyMap, predecessorMap)
+	} else {
+		for vertex := range adjacencyMap {
+			components[0] = append(components[0], vertex)
+		}
+	}
+
+	center := make([]K, 0)
+	for _, component := range components {
+		if len(component) == 0 {
+			continue
+		}
+
+		minimum := eccentricities[component[0]]
+		for _, vertex := range component {
+			if eccentricities[vertex] < minimum {
+				minimum = eccentricities[vertex]
+			}
+		}
+
+		for _, vertex := range component {
+			if eccentricities[vertex] == minimum {
+				center = append(center, vertex)
+			}
+		}
+	}
+
+	return center, nil
+}
+
+// weaklyConnectedComponents returns the sets of vertices which are connected
+// when ignoring the direction of the edges.
+func weaklyConnectedComponents[K comparable](adjacencyMap, predecessorMap map[K]map[K]Edge[K]) [][]K {
+	var components [][]K
+	visited := make(map[K]bool, len(adjacencyMap))
+
+	for start := range adjacencyMap {
+		if visited[start] {
+			continue
+		}
+
+		visited[start] = true
+		component := []K{start}
+		for i := 0; i < len(component); i++ {
+			for _, neighbours := range []map[K]Edge[K]{adjacencyMap[component[i]], predecessorMap[component[i]]} {
+				for neighbour := range neighbours {
+					if !visited[neighbour] {
+						visited[neighbour] = true
+						component = append(component, neighbour)
+					}
+				}
+			}
+		}
+
+		components = append(components, component)
+	}
+
+	return components
+}
@@ -0,0 +1,357 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDensity(t *testing.T) {
+	tests := []struct {
+		name        string
+		edges       [][2]string
+		vertices    []string
+		options     []func(*Traits)
+		wantDensity float64
+	}{
+		{
+			name:        "empty graph",
+			wantDensity: 0,
+		},
+		{
+			name:        "single vertex",
+			vertices:    []string{"SFO"},
+			wantDensity: 0,
+		},
+		{
+			name:        "directed chain",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			options:     []func(*Traits){Directed()},
+			wantDensity: 2.0 / 6.0,
+		},
+		{
+			name:        "complete directed graph",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "SFO"}},
+			options:     []func(*Traits){Directed()},
+			wantDensity: 1,
+		},
+		{
+			name:        "undirected chain",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			wantDensity: 2.0 / 3.0,
+		},
+		{
+			name:        "directed graph with isolated vertex",
+			edges:       [][2]string{{"SFO", "ATL"}},
+			vertices:    []string{"EWR"},
+			options:     []func(*Traits){Directed()},
+			wantDensity: 1.0 / 6.0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+			for _, v := range test.vertices {
+				assert.NoError(t, g.AddVertex(v))
+			}
+
+			density, err := Density(g)
+			assert.NoError(t, err)
+			assert.InDelta(t, test.wantDensity, density, 1e-9)
+		})
+	}
+}
+
+func TestDegreeHistogram(t *testing.T) {
+	// ATL is the hub of a star, with flights to and from four airports.
+	star := [][2]string{{"ATL", "SFO"}, {"ATL", "EWR"}, {"IND", "ATL"}, {"GSO", "ATL"}}
+
+	tests := []struct {
+		name          string
+		edges         [][2]string
+		vertices      []string
+		options       []func(*Traits)
+		degreeOptions []func(*DegreeOptions)
+		wantHistogram map[int]int
+	}{
+		{
+			name:          "undirected star",
+			edges:         star,
+			wantHistogram: map[int]int{4: 1, 1: 4},
+		},
+		{
+			name:          "directed star",
+			edges:         star,
+			options:       []func(*Traits){Directed()},
+			wantHistogram: map[int]int{4: 1, 1: 4},
+		},
+		{
+			name:          "in-degrees",
+			edges:         star,
+			options:       []func(*Traits){Directed()},
+			degreeOptions: []func(*DegreeOptions){InDegree()},
+			wantHistogram: map[int]int{2: 1, 1: 2, 0: 2},
+		},
+		{
+			name:          "out-degrees",
+			edges:         star,
+			options:       []func(*Traits){Directed()},
+			degreeOptions: []func(*DegreeOptions){OutDegree()},
+			wantHistogram: map[int]int{2: 1, 1: 2, 0: 2},
+		},
+		{
+			name:          "isolated vertex",
+			edges:         [][2]string{{"SFO", "ATL"}},
+			vertices:      []string{"EWR"},
+			wantHistogram: map[int]int{1: 2, 0: 1},
+		},
+		{
+			name:          "empty graph",
+			wantHistogram: map[int]int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+			for _, vertex := range test.vertices {
+				assert.NoError(t, g.AddVertex(vertex))
+			}
+
+			histogram, err := DegreeHistogram(g, test.degreeOptions...)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantHistogram, histogram)
+		})
+	}
+}
+
+func TestAverageShortestPathLength(t *testing.T) {
+	path := [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}}
+
+	tests := []struct {
+		name        string
+		edges       [][2]string
+		vertices    []string
+		options     []func(*Traits)
+		wantAverage float64
+	}{
+		{
+			name:     "single vertex",
+			vertices: []string{"SFO"},
+		},
+		{
+			// The distances are 1, 2 and 3 from SFO, 1 and 2 from ATL and
+			// 1 from EWR, the other direction is unreachable.
+			name:        "directed path",
+			edges:       path,
+			options:     []func(*Traits){Directed()},
+			wantAverage: 10.0 / 6.0,
+		},
+		{
+			name:        "undirected path",
+			edges:       path,
+			wantAverage: 20.0 / 12.0,
+		},
+		{
+			name:        "disconnected vertex",
+			edges:       path,
+			vertices:    []string{"LAX"},
+			wantAverage: 20.0 / 12.0,
+		},
+		{
+			name:        "complete graph",
+			edges:       [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			wantAverage: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+			for _, vertex := range test.vertices {
+				assert.NoError(t, g.AddVertex(vertex))
+			}
+
+			average, err := AverageShortestPathLength(g)
+			assert.NoError(t, err)
+			assert.InDelta(t, test.wantAverage, average, 1e-9)
+		})
+	}
+}
+
+// listCountingStore counts the calls of ListEdges, i.e. how often adjacency
+// and predecessor maps are built.
+type listCountingStore struct {
+	Store[string, string]
+	lists int
+}
+
+func (s *listCountingStore) ListEdges() ([]Edge[string], error) {
+	s.lists++
+	return s.Store.ListEdges()
+}
+
+func TestAverageShortestPathLengthAdjacencyMap(t *testing.T) {
+	store := &listCountingStore{Store: NewMemoryStore[string, string]()}
+	g := NewWithStore(StringHash, store, Directed())
+	for _, airport := range []string{"SFO", "ATL", "EWR", "IND"} {
+		assert.NoError(t, g.AddVertex(airport))
+	}
+	for _, edge := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}} {
+		assert.NoError(t, g.AddEdge(edge[0], edge[1]))
+	}
+	store.lists = 0
+
+	_, err := AverageShortestPathLength(g)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.lists)
+}
+
+func TestCenter(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      [][2]string
+		options    []func(*Traits)
+		center     []func(*CenterOptions)
+		wantCenter []string
+		wantErr    error
+	}{
+		{
+			name:       "odd path",
+			edges:      [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "BOS"}, {"BOS", "LHR"}},
+			wantCenter: []string{"ORD"},
+		},
+		{
+			name:       "even path",
+			edges:      [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "BOS"}},
+			wantCenter: []string{"DEN", "ORD"},
+		},
+		{
+			name:       "star",
+			edges:      [][2]string{{"ATL", "SFO"}, {"ATL", "EWR"}, {"ATL", "IND"}, {"ATL", "GSO"}},
+			wantCenter: []string{"ATL"},
+		},
+		{
+			name:       "directed cycle",
+			edges:      [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			options:    []func(*Traits){Directed()},
+			wantCenter: []string{"SFO", "ATL", "EWR"},
+		},
+		{
+			name:    "directed path",
+			edges:   [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			options: []func(*Traits){Directed()},
+			wantErr: ErrGraphNotConnected,
+		},
+		{
+			name:    "disconnected",
+			edges:   [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"LHR", "CDG"}},
+			wantErr: ErrGraphNotConnected,
+		},
+		{
+			name:       "disconnected per component",
+			edges:      [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"LHR", "CDG"}},
+			center:     []func(*CenterOptions){PerComponent()},
+			wantCenter: []string{"DEN", "LHR", "CDG"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			center, err := Center(g, test.center...)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantCenter, center)
+		})
+	}
+}
+
+func TestEccentricity(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "BOS"}})
+
+	eccentricity, err := Eccentricity(g, "SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, eccentricity)
+
+	eccentricity, err = Eccentricity(g, "DEN")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, eccentricity)
+
+	assert.NoError(t, g.AddVertex("LHR"))
+	_, err = Eccentricity(g, "SFO")
+	assert.ErrorIs(t, err, ErrGraphNotConnected)
+}
+
+func TestDiameterAndRadius(t *testing.T) {
+	tests := []struct {
+		name         string
+		edges        [][2]string
+		options      []func(*Traits)
+		wantDiameter int
+		wantRadius   int
+		wantErr      error
+	}{
+		// The radius of a path is half its diameter, rounded up.
+		{
+			name:         "odd path",
+			edges:        [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "BOS"}, {"BOS", "LHR"}},
+			wantDiameter: 4,
+			wantRadius:   2,
+		},
+		{
+			name:         "even path",
+			edges:        [][2]string{{"SFO", "DEN"}, {"DEN", "ORD"}, {"ORD", "BOS"}},
+			wantDiameter: 3,
+			wantRadius:   2,
+		},
+		{
+			name:         "star",
+			edges:        [][2]string{{"ATL", "SFO"}, {"ATL", "EWR"}, {"ATL", "IND"}},
+			wantDiameter: 2,
+			wantRadius:   1,
+		},
+		{
+			name:         "directed cycle",
+			edges:        [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			options:      []func(*Traits){Directed()},
+			wantDiameter: 2,
+			wantRadius:   2,
+		},
+		{
+			name:    "directed path",
+			edges:   [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}},
+			options: []func(*Traits){Directed()},
+			wantErr: ErrGraphNotConnected,
+		},
+		{
+			name:    "disconnected",
+			edges:   [][2]string{{"SFO", "DEN"}, {"LHR", "CDG"}},
+			wantErr: ErrGraphNotConnected,
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			diameter, err := Diameter(g)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.wantDiameter, diameter)
+			}
+
+			radius, err := Radius(g)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantRadius, radius)
+		})
+	}
+}
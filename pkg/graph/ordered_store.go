@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"sort"
+	"sync"
+)
+
+// orderedMemoryStore is a memory store which lists its vertices and edges in
+// the order they were added. It keeps a sequence number per vertex and edge
+// next to the memory store, so removals stay cheap and listings are sorted by
+// these numbers.
+type orderedMemoryStore[K comparable, T any] struct {
+	*memoryStore[K, T]
+
+	// lock guards the sequence numbers and is held around the changes of the
+	// memory store, so both always agree.
+	lock       sync.RWMutex
+	next       uint64
+	vertexSeqs map[K]uint64
+	edgeSeqs   map[[2]K]uint64
+}
+
+// NewOrderedMemoryStore creates an in-memory store like NewMemoryStore, whose
+// ListVertices and ListEdges return the vertices and edges in the order they
+// were added rather than in random order. This makes listings, and everything
+// built on them like Graph.Edges, reproducible between runs. It is safe for
+// concurrent use.
+func NewOrderedMemoryStore[K comparable, T any]() Store[K, T] {
+	return &orderedMemoryStore[K, T]{
+		memoryStore: NewMemoryStore[K, T]().(*memoryStore[K, T]),
+		vertexSeqs:  make(map[K]uint64),
+		edgeSeqs:    make(map[[2]K]uint64),
+	}
+}
+
+func (s *orderedMemoryStore[K, T]) AddVertex(k K, t T) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.memoryStore.AddVertex(k, t); err != nil {
+		return err
+	}
+
+	s.vertexSeqs[k] = s.next
+	s.next++
+
+	return nil
+}
+
+func (s *orderedMemoryStore[K, T]) RemoveVertex(k K) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.memoryStore.RemoveVertex(k); err != nil {
+		return err
+	}
+
+	delete(s.vertexSeqs, k)
+
+	return nil
+}
+
+func (s *orderedMemoryStore[K, T]) ListVertices() ([]K, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	hashes := make([]K, 0, len(s.vertexSeqs))
+	for k := range s.vertexSeqs {
+		hashes = append(hashes, k)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return s.vertexSeqs[hashes[i]] < s.vertexSeqs[hashes[j]]
+	})
+
+	return hashes, nil
+}
+
+func (s *orderedMemoryStore[K, T]) AddEdge(sourceHash, targetHash K, edge Edge[K]) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.memoryStore.AddEdge(sourceHash, targetHash, edge); err != nil {
+		return err
+	}
+
+	// Replacing an edge, e.g. to update its properties, keeps its position.
+	key := [2]K{sourceHash, targetHash}
+	if _, ok := s.edgeSeqs[key]; !ok {
+		s.edgeSeqs[key] = s.next
+		s.next++
+	}
+
+	return nil
+}
+
+func (s *orderedMemoryStore[K, T]) RemoveEdge(sourceHash, targetHash K) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.memoryStore.RemoveEdge(sourceHash, targetHash); err != nil {
+		return err
+	}
+
+	delete(s.edgeSeqs, [2]K{sourceHash, targetHash})
+
+	return nil
+}
+
+func (s *orderedMemoryStore[K, T]) RemoveVertexEdges(k K) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// The edges of the memory store only change while lock is held, so they
+	// can be read directly to find the sequence numbers to drop.
+	var keys [][2]K
+	for target := range s.memoryStore.outEdges[k] {
+		keys = append(keys, [2]K{k, target})
+	}
+	for source := range s.memoryStore.inEdges[k] {
+		keys = append(keys, [2]K{source, k})
+	}
+
+	if err := s.memoryStore.RemoveVertexEdges(k); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(s.edgeSeqs, key)
+	}
+
+	return nil
+}
+
+func (s *orderedMemoryStore[K, T]) ListEdges() ([]Edge[K], error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := make([][2]K, 0, len(s.edgeSeqs))
+	for key := range s.edgeSeqs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.edgeSeqs[keys[i]] < s.edgeSeqs[keys[j]]
+	})
+
+	edges := make([]Edge[K], 0, len(keys))
+	for _, key := range keys {
+		edge, err := s.memoryStore.Edge(key[0], key[1])
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, nil
+}
+
+func (s *orderedMemoryStore[K, T]) Stats() (StoreStats, error) {
+	stats, err := s.memoryStore.Stats()
+	stats.Type = "ordered-memory"
+
+	return stats, err
+}
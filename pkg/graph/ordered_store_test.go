@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOrderedMemoryStore(t *testing.T) {
+	store := NewOrderedMemoryStore[string, string]()
+	g := NewWithStore(StringHash, store, Directed())
+	for _, v := range []string{"SFO", "ATL", "EWR", "IND", "DEN"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	for _, edge := range [][2]string{{"SFO", "ATL"}, {"IND", "EWR"}, {"ATL", "IND"}, {"DEN", "SFO"}, {"ATL", "DEN"}} {
+		assert.NoError(t, g.AddEdge(edge[0], edge[1]))
+	}
+	assert.NoError(t, g.RemoveEdge("IND", "EWR"))
+	assert.NoError(t, g.RemoveVertex("EWR"))
+	assert.NoError(t, g.AddVertex("EWR"))
+	assert.NoError(t, g.AddEdge("IND", "EWR"))
+
+	// The listings don't change between calls, unlike map iteration.
+	for i := 0; i < 10; i++ {
+		vertices, err := store.ListVertices()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SFO", "ATL", "IND", "DEN", "EWR"}, vertices)
+
+		edges, err := g.Edges()
+		assert.NoError(t, err)
+
+		pairs := make([][2]string, 0, len(edges))
+		for _, edge := range edges {
+			pairs = append(pairs, [2]string{edge.Source, edge.Target})
+		}
+		assert.Equal(t, [][2]string{{"SFO", "ATL"}, {"ATL", "IND"}, {"DEN", "SFO"}, {"ATL", "DEN"}, {"IND", "EWR"}}, pairs)
+	}
+
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "ordered-memory", Vertices: 5, Edges: 5}, stats)
+}
@@ -3,9 +3,34 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"math"
 )
 
-var ErrTargetNotReachable = errors.New("target vertex not reachable from source")
+var (
+	ErrTargetNotReachable = errors.New("target vertex not reachable from source")
+	ErrNegativeCycle      = errors.New("graph contains a negative cycle")
+	ErrMaxHopsExceeded    = errors.New("target vertex not reachable within the maximum number of hops")
+	ErrTooManyRequired    = fmt.Errorf("more than %d required vertices", maxRequiredVertices)
+)
+
+// maxRequiredVertices is the maximum number of required vertices of
+// ShortestPathCovering. Held-Karp needs O(2ⁿ·n) memory, which is about 1M
+// entries for 16 vertices.
+const maxRequiredVertices = 16
+
+// PathOptions configure the path search of ShortestPath.
+type PathOptions struct {
+	// MaxHops is the maximum number of edges of the path, 0 means unlimited.
+	MaxHops int
+}
+
+// MaxHops limits the paths to the given number of edges. If the target can
+// only be reached with more hops, ErrMaxHopsExceeded is returned.
+func MaxHops(hops int) func(*PathOptions) {
+	return func(o *PathOptions) {
+		o.MaxHops = hops
+	}
+}
 
 // CreatesCycle determines whether adding an edge between the two given vertices
 // would introduce a cycle in the graph. CreatesCycle will not create an edge.
@@ -56,3 +81,502 @@ func CreatesCycle[K comparable, T any](g Graph[K, T], source, target K) (bool, e
 
 	return false, nil
 }
+
+// IsPath determines whether the graph forms a single simple path and returns
+// its start and end vertices if so.
+//
+// In a directed graph, this is the case if there is exactly one vertex without
+// ingoing edges (the start) and one vertex without outgoing edges (the end),
+// all other vertices have exactly one ingoing and one outgoing edge, and all
+// vertices are reachable from the start. In an undirected graph, the start and
+// end vertices have a degree of 1 and all others a degree of 2. A graph with a
+// single vertex is a path starting and ending at that vertex, an empty graph is
+// not a path.
+func IsPath[K comparable, T any](g Graph[K, T]) (bool, K, K, error) {
+	var start, end K
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return false, start, end, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	predecessorMap, err := g.PredecessorMap()
+	if err != nil {
+		return false, start, end, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	if len(adjacencyMap) == 0 {
+		return false, start, end, nil
+	}
+
+	starts, ends := 0, 0
+	for vertex, adjacencies := range adjacencyMap {
+		if g.Traits().IsDirected {
+			if len(adjacencies) > 1 || len(predecessorMap[vertex]) > 1 {
+				return false, start, end, nil
+			}
+			if len(predecessorMap[vertex]) == 0 {
+				start = vertex
+				starts++
+			}
+			if len(adjacencies) == 0 {
+				end = vertex
+				ends++
+			}
+			continue
+		}
+
+		switch len(adjacencies) {
+		case 0:
+			start, end = vertex, vertex
+			starts, ends = starts+1, ends+1
+		case 1:
+			if starts == ends {
+				start = vertex
+				starts++
+			} else {
+				end = vertex
+				ends++
+			}
+		case 2:
+		default:
+			return false, start, end, nil
+		}
+	}
+
+	if starts != 1 || ends != 1 {
+		return false, start, end, nil
+	}
+
+	// The degrees match a path, but the graph might still consist of a path
+	// and a separate cycle. Walk the path to make sure it covers all vertices.
+	visited := map[K]bool{start: true}
+	for current := start; ; {
+		next, found := current, false
+		for adjacency := range adjacencyMap[current] {
+			if !visited[adjacency] {
+				next, found = adjacency, true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+		visited[next] = true
+		current = next
+	}
+
+	if len(visited) != len(adjacencyMap) {
+		return false, start, end, nil
+	}
+
+	return true, start, end, nil
+}
+
+// IsValidPath reports whether the path can be travelled in the graph: all of
+// its vertices exist and each of them is joined to the next one by an edge,
+// e.g. to check an itinerary proposed by a client against the known flights.
+// Edges are looked up with Graph.Edge, which takes the directedness into
+// account. The path may visit a vertex more than once. An empty path isn't
+// valid.
+func IsValidPath[K comparable, T any](g Graph[K, T], path []K) (bool, error) {
+	if len(path) == 0 {
+		return false, nil
+	}
+
+	for i, hash := range path {
+		_, err := g.Vertex(hash)
+		if errors.Is(err, ErrVertexNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		_, err = g.Edge(path[i-1], hash)
+		if errors.Is(err, ErrEdgeNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get edge from %v to %v: %w", path[i-1], hash, err)
+		}
+	}
+
+	return true, nil
+}
+
+// AllPairsShortestPath computes the costs of the shortest paths between all
+// pairs of vertices using the Floyd-Warshall algorithm. The cost of an edge is
+// its weight in weighted graphs and 1 otherwise, in which case the costs are
+// hop counts.
+//
+// The result maps each source vertex to the costs of reaching all vertices,
+// where unreachable vertices have a cost of +Inf:
+//
+//	costs, _ := graph.AllPairsShortestPath(g)
+//	fmt.Println(costs["SFO"]["EWR"])
+//
+// If the graph contains a cycle with a negative total weight, there are no
+// shortest paths and ErrNegativeCycle will be returned. The algorithm runs in
+// O(V³) time, which makes it suitable for small and dense graphs.
+func AllPairsShortestPath[K comparable, T any](g Graph[K, T]) (map[K]map[K]float64, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	vertices := make([]K, 0, len(adjacencyMap))
+	costs := make(map[K]map[K]float64, len(adjacencyMap))
+
+	for source := range adjacencyMap {
+		vertices = append(vertices, source)
+		costs[source] = make(map[K]float64, len(adjacencyMap))
+		for target := range adjacencyMap {
+			costs[source][target] = math.Inf(1)
+		}
+		costs[source][source] = 0
+	}
+
+	for source, adjacencies := range adjacencyMap {
+		for target, edge := range adjacencies {
+			if weight := edgeWeight(g, edge); weight < costs[source][target] {
+				costs[source][target] = weight
+			}
+		}
+	}
+
+	for _, via := range vertices {
+		for _, source := range vertices {
+			if math.IsInf(costs[source][via], 1) {
+				continue
+			}
+			for _, target := range vertices {
+				if cost := costs[source][via] + costs[via][target]; cost < costs[source][target] {
+					costs[source][target] = cost
+				}
+			}
+		}
+	}
+
+	for _, vertex := range vertices {
+		if costs[vertex][vertex] < 0 {
+			return nil, ErrNegativeCycle
+		}
+	}
+
+	return costs, nil
+}
+
+// ShortestPath computes the shortest path between the source and target vertex
+// using Dijkstra's algorithm and returns the hashes of the vertices along the
+// path, including the source and target. The cost of an edge is its weight in
+// weighted graphs and 1 otherwise, so the path with the fewest hops is found
+// in unweighted graphs.
+//
+// The number of edges of the path can be limited with the MaxHops option. In
+// weighted graphs, the cheapest path within the limit is returned then, even
+// if there are cheaper paths with more hops.
+//
+// ErrVertexNotFound is returned if either vertex doesn't exist, and
+// ErrTargetNotReachable if there is no path between them. Negative weights
+// aren't supported and may lead to wrong results.
+func ShortestPath[K comparable, T any](g Graph[K, T], source, target K, options ...func(*PathOptions)) ([]K, error) {
+	var opts PathOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	path, err := shortestPath(g, source, target)
+	if err != nil || opts.MaxHops <= 0 || len(path)-1 <= opts.MaxHops {
+		return path, err
+	}
+
+	return boundedShortestPath(g, source, target, opts.MaxHops)
+}
+
+func shortestPath[K comparable, T any](g Graph[K, T], source, target K) ([]K, error) {
+	if _, err := g.Vertex(target); err != nil {
+		return nil, fmt.Errorf("could not get vertex with hash %v: %w", target, err)
+	}
+
+	previous, costs, err := dijkstra(g, source, func(vertex K) bool {
+		return vertex == target
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := costs[target]; !ok {
+		return nil, ErrTargetNotReachable
+	}
+
+	return pathFromTree(previous, source, target), nil
+}
+
+// ShortestPathCovering computes the shortest path visiting all required
+// vertices, e.g. for an itinerary through a set of airports. The path starts at
+// one of the required vertices and ends at another one, whichever order is the
+// cheapest, and may pass through any other vertices in between. Costs are
+// computed like in ShortestPath.
+//
+// The order of the required vertices is found with the Held-Karp algorithm,
+// which runs in O(2ⁿ·n²) time for n required vertices, so at most 16 of them
+// are supported and ErrTooManyRequired is returned otherwise. ErrVertexNotFound
+// is returned if a required vertex doesn't exist, and ErrTargetNotReachable if
+// no order connects all of them.
+func ShortestPathCovering[K comparable, T any](g Graph[K, T], required []K) ([]K, error) {
+	var stops []K
+	seen := make(map[K]bool, len(required))
+	for _, vertex := range required {
+		if !seen[vertex] {
+			seen[vertex] = true
+			stops = append(stops, vertex)
+		}
+	}
+
+	if len(stops) > maxRequiredVertices {
+		return nil, ErrTooManyRequired
+	}
+	if len(stops) == 0 {
+		return nil, nil
+	}
+
+	// The shortest path trees between the stops, so the legs of the best
+	// order can be reconstructed without searching again.
+	trees := make([]map[K]K, len(stops))
+	costs := make([][]float64, len(stops))
+	for i, source := range stops {
+		previous, sourceCosts, err := dijkstra(g, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		trees[i] = previous
+		costs[i] = make([]float64, len(stops))
+		for j, target := range stops {
+			cost, ok := sourceCosts[target]
+			if !ok {
+				cost = math.Inf(1)
+			}
+			costs[i][j] = cost
+		}
+	}
+
+	// best[set][i] is the cost of the cheapest path visiting the stops in the
+	// set, given as a bit mask, and ending at stop i. last[set][i] is the stop
+	// visited before i on that path.
+	sets := 1 << len(stops)
+	best := make([][]float64, sets)
+	last := make([][]int, sets)
+	for set := range best {
+		best[set] = make([]float64, len(stops))
+		last[set] = make([]int, len(stops))
+		for i := range best[set] {
+			best[set][i] = math.Inf(1)
+			last[set][i] = -1
+		}
+	}
+	for i := range stops {
+		best[1<<i][i] = 0
+	}
+
+	for set := 1; set < sets; set++ {
+		for i := range stops {
+			if set&(1<<i) == 0 || math.IsInf(best[set][i], 1) {
+				continue
+			}
+			for j := range stops {
+				if set&(1<<j) != 0 {
+					continue
+				}
+				next := set | 1<<j
+				if cost := best[set][i] + costs[i][j]; cost < best[next][j] {
+					best[next][j] = cost
+					last[next][j] = i
+				}
+			}
+		}
+	}
+
+	all := sets - 1
+	end := -1
+	for i := range stops {
+		if !math.IsInf(best[all][i], 1) && (end < 0 || best[all][i] < best[all][end]) {
+			end = i
+		}
+	}
+	if end < 0 {
+		return nil, ErrTargetNotReachable
+	}
+
+	order := []int{end}
+	for set, current := all, end; last[set][current] >= 0; {
+		set, current = set&^(1<<current), last[set][current]
+		order = append(order, current)
+	}
+
+	path := []K{stops[order[len(order)-1]]}
+	for k := len(order) - 1; k > 0; k-- {
+		from, to := order[k], order[k-1]
+		leg := pathFromTree(trees[from], stops[from], stops[to])
+		path = append(path, leg[1:]...)
+	}
+
+	return path, nil
+}
+
+// ShortestPathTree runs Dijkstra's algorithm from the source vertex to all
+// vertices and returns its raw output: the predecessor of each reachable
+// vertex on its shortest path and the cost of reaching each vertex. Vertices
+// which aren't reachable are missing in both maps, the source vertex has a cost
+// of 0 and no predecessor.
+//
+// A single tree yields the shortest paths to many targets, by following the
+// predecessors from a target back to the source. Costs are computed like in
+// ShortestPath.
+func ShortestPathTree[K comparable, T any](g Graph[K, T], source K) (map[K]K, map[K]float64, error) {
+	return dijkstra(g, source, nil)
+}
+
+// dijkstra computes the shortest path tree from the source vertex. If stop is
+// given, the search ends as soon as it returns true for a finalized vertex.
+func dijkstra[K comparable, T any](g Graph[K, T], source K, stop func(K) bool) (map[K]K, map[K]float64, error) {
+	if _, err := g.Vertex(source); err != nil {
+		return nil, nil, fmt.Errorf("could not get vertex with hash %v: %w", source, err)
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	costs := map[K]float64{source: 0}
+	previous := make(map[K]K)
+	visited := make(map[K]bool)
+
+	queue := newPriorityQueue[K]()
+	queue.Push(source, 0)
+
+	for queue.Len() > 0 {
+		vertex := queue.Pop()
+		if stop != nil && stop(vertex) {
+			break
+		}
+		visited[vertex] = true
+
+		for adjacency, edge := range adjacencyMap[vertex] {
+			if visited[adjacency] {
+				continue
+			}
+
+			cost := costs[vertex] + edgeWeight(g, edge)
+			if current, ok := costs[adjacency]; ok && current <= cost {
+				continue
+			}
+
+			costs[adjacency] = cost
+			previous[adjacency] = vertex
+			queue.Push(adjacency, cost)
+		}
+	}
+
+	return previous, costs, nil
+}
+
+// pathFromTree reconstructs the path from the source to the target vertex by
+// following the predecessors of a shortest path tree. The target must be
+// reachable.
+func pathFromTree[K comparable](previous map[K]K, source, target K) []K {
+	path := []K{target}
+	for current := target; current != source; {
+		current = previous[current]
+		path = append(path, current)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// boundedShortestPath computes the cheapest path between the source and target
+// vertex with at most maxHops edges. It runs maxHops rounds of the Bellman-Ford
+// algorithm, where round i finds the cheapest paths with up to i edges.
+func boundedShortestPath[K comparable, T any](g Graph[K, T], source, target K, maxHops int) ([]K, error) {
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	// Undirected edges can be traversed in both directions.
+	if !g.Traits().IsDirected {
+		for _, edge := range edges {
+			edges = append(edges, Edge[K]{Source: edge.Target, Target: edge.Source, Properties: edge.Properties})
+		}
+	}
+
+	// previous[i] maps each vertex to its predecessor on the cheapest path
+	// with up to i edges.
+	costs := map[K]float64{source: 0}
+	previous := make([]map[K]K, maxHops+1)
+	previous[0] = make(map[K]K)
+
+	for i := 1; i <= maxHops; i++ {
+		roundCosts := make(map[K]float64, len(costs))
+		previous[i] = make(map[K]K, len(previous[i-1]))
+		for vertex, cost := range costs {
+			roundCosts[vertex] = cost
+		}
+		for vertex, predecessor := range previous[i-1] {
+			previous[i][vertex] = predecessor
+		}
+
+		for _, edge := range edges {
+			cost, ok := costs[edge.Source]
+			if !ok || edge.Target == source {
+				continue
+			}
+
+			cost += edgeWeight(g, edge)
+			if current, ok := roundCosts[edge.Target]; ok && current <= cost {
+				continue
+			}
+
+			roundCosts[edge.Target] = cost
+			previous[i][edge.Target] = edge.Source
+		}
+
+		costs = roundCosts
+	}
+
+	if _, ok := costs[target]; !ok {
+		return nil, ErrMaxHopsExceeded
+	}
+
+	// The predecessor of a vertex in round i has been reached within i-1
+	// edges, so walk the rounds backwards.
+	path := []K{target}
+	for current, round := target, maxHops; current != source; round-- {
+		current = previous[round][current]
+		path = append(path, current)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, nil
+}
+
+// edgeWeight returns the cost of traversing the edge, which is its weight in
+// weighted graphs and 1 otherwise.
+func edgeWeight[K comparable, T any](g Graph[K, T], edge Edge[K]) float64 {
+	if g.Traits().IsWeighted {
+		return edge.Properties.Weight
+	}
+	return 1
+}
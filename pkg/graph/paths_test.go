@@ -0,0 +1,468 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func newStringGraph(t *testing.T, edges [][2]string, options ...func(*Traits)) Graph[string, string] {
+	g := New(StringHash, options...)
+	for _, edge := range edges {
+		for _, v := range edge {
+			if _, err := g.Vertex(v); err != nil {
+				assert.NoError(t, g.AddVertex(v))
+			}
+		}
+		assert.NoError(t, g.AddEdge(edge[0], edge[1]))
+	}
+
+	return g
+}
+
+func TestIsPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     [][2]string
+		vertices  []string
+		options   []func(*Traits)
+		wantPath  bool
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "valid directed path",
+			edges:     [][2]string{{"IND", "EWR"}, {"SFO", "ATL"}, {"GSO", "IND"}, {"ATL", "GSO"}},
+			options:   []func(*Traits){Directed()},
+			wantPath:  true,
+			wantStart: "SFO",
+			wantEnd:   "EWR",
+		},
+		{
+			name:      "single vertex",
+			vertices:  []string{"SFO"},
+			options:   []func(*Traits){Directed()},
+			wantPath:  true,
+			wantStart: "SFO",
+			wantEnd:   "SFO",
+		},
+		{
+			name:    "empty graph",
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:    "branching",
+			edges:   [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}},
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:    "disconnected",
+			edges:   [][2]string{{"IND", "FDF"}, {"DAD", "EED"}},
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:    "cycle",
+			edges:   [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:    "path and separate cycle",
+			edges:   [][2]string{{"SFO", "ATL"}, {"GSO", "IND"}, {"IND", "GSO"}},
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:     "valid undirected path",
+			edges:    [][2]string{{"A", "B"}, {"C", "B"}, {"C", "D"}},
+			wantPath: true,
+		},
+		{
+			name:  "undirected cycle",
+			edges: [][2]string{{"A", "B"}, {"B", "C"}, {"C", "A"}},
+		},
+		{
+			name:  "undirected star",
+			edges: [][2]string{{"A", "B"}, {"A", "C"}, {"A", "D"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+			for _, v := range test.vertices {
+				assert.NoError(t, g.AddVertex(v))
+			}
+
+			isPath, start, end, err := IsPath(g)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, isPath)
+			if test.wantPath && g.Traits().IsDirected {
+				assert.Equal(t, test.wantStart, start)
+				assert.Equal(t, test.wantEnd, end)
+			}
+			if test.wantPath && !g.Traits().IsDirected {
+				assert.ElementsMatch(t, []string{"A", "D"}, []string{start, end})
+			}
+		})
+	}
+}
+
+func TestIsValidPath(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}}
+
+	tests := []struct {
+		name     string
+		options  []func(*Traits)
+		path     []string
+		wantPath bool
+	}{
+		{
+			name:     "valid path",
+			options:  []func(*Traits){Directed()},
+			path:     []string{"SFO", "ATL", "EWR", "IND"},
+			wantPath: true,
+		},
+		{
+			name:     "single vertex",
+			options:  []func(*Traits){Directed()},
+			path:     []string{"ATL"},
+			wantPath: true,
+		},
+		{
+			name:    "missing edge",
+			options: []func(*Traits){Directed()},
+			path:    []string{"SFO", "EWR", "IND"},
+		},
+		{
+			name:    "against the direction",
+			options: []func(*Traits){Directed()},
+			path:    []string{"EWR", "ATL"},
+		},
+		{
+			name:    "unknown vertex",
+			options: []func(*Traits){Directed()},
+			path:    []string{"SFO", "ATL", "LAX"},
+		},
+		{
+			name:    "empty path",
+			options: []func(*Traits){Directed()},
+		},
+		{
+			name:     "undirected path in both directions",
+			path:     []string{"IND", "EWR", "ATL", "EWR"},
+			wantPath: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, edges, test.options...)
+
+			valid, err := IsValidPath(g, test.path)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, valid)
+		})
+	}
+}
+
+func TestAllPairsShortestPath(t *testing.T) {
+	inf := math.Inf(1)
+
+	g := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"A", "B", "C", "D"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("A", "B", EdgeWeight(4)))
+	assert.NoError(t, g.AddEdge("A", "C", EdgeWeight(1)))
+	assert.NoError(t, g.AddEdge("C", "B", EdgeWeight(2)))
+	assert.NoError(t, g.AddEdge("B", "D", EdgeWeight(5)))
+
+	costs, err := AllPairsShortestPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]float64{
+		"A": {"A": 0, "B": 3, "C": 1, "D": 8},
+		"B": {"A": inf, "B": 0, "C": inf, "D": 5},
+		"C": {"A": inf, "B": 2, "C": 0, "D": 7},
+		"D": {"A": inf, "B": inf, "C": inf, "D": 0},
+	}, costs)
+}
+
+func TestAllPairsShortestPathUnweighted(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "C"}, {"A", "C"}, {"C", "D"}})
+
+	costs, err := AllPairsShortestPath(g)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, costs["A"]["D"])
+	assert.Equal(t, 2.0, costs["D"]["A"])
+	assert.Equal(t, 1.0, costs["B"]["C"])
+}
+
+func TestAllPairsShortestPathNegativeCycle(t *testing.T) {
+	g := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"A", "B", "C"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("A", "B", EdgeWeight(1)))
+	assert.NoError(t, g.AddEdge("B", "C", EdgeWeight(-3)))
+	assert.NoError(t, g.AddEdge("C", "A", EdgeWeight(1)))
+
+	_, err := AllPairsShortestPath(g)
+	assert.ErrorIs(t, err, ErrNegativeCycle)
+}
+
+func TestShortestPath(t *testing.T) {
+	weighted := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"A", "B", "C", "D", "E"} {
+		assert.NoError(t, weighted.AddVertex(v))
+	}
+	assert.NoError(t, weighted.AddEdge("A", "B", EdgeWeight(4)))
+	assert.NoError(t, weighted.AddEdge("A", "C", EdgeWeight(1)))
+	assert.NoError(t, weighted.AddEdge("C", "B", EdgeWeight(2)))
+	assert.NoError(t, weighted.AddEdge("B", "D", EdgeWeight(5)))
+
+	unweighted := newStringGraph(t, [][2]string{{"A", "B"}, {"A", "C"}, {"C", "B"}, {"B", "D"}}, Directed())
+	undirected := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "C"}, {"C", "D"}})
+
+	tests := []struct {
+		name     string
+		g        Graph[string, string]
+		source   string
+		target   string
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			name:     "weighted",
+			g:        weighted,
+			source:   "A",
+			target:   "D",
+			wantPath: []string{"A", "C", "B", "D"},
+		},
+		{
+			name:     "unweighted takes fewest hops",
+			g:        unweighted,
+			source:   "A",
+			target:   "D",
+			wantPath: []string{"A", "B", "D"},
+		},
+		{
+			name:     "undirected against edge orientation",
+			g:        undirected,
+			source:   "D",
+			target:   "A",
+			wantPath: []string{"D", "C", "B", "A"},
+		},
+		{
+			name:     "source is target",
+			g:        weighted,
+			source:   "A",
+			target:   "A",
+			wantPath: []string{"A"},
+		},
+		{
+			name:    "not reachable",
+			g:       weighted,
+			source:  "D",
+			target:  "A",
+			wantErr: ErrTargetNotReachable,
+		},
+		{
+			name:    "isolated target",
+			g:       weighted,
+			source:  "A",
+			target:  "E",
+			wantErr: ErrTargetNotReachable,
+		},
+		{
+			name:    "unknown vertex",
+			g:       weighted,
+			source:  "A",
+			target:  "X",
+			wantErr: ErrVertexNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := ShortestPath(test.g, test.source, test.target)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, path)
+		})
+	}
+}
+
+func TestShortestPathMaxHops(t *testing.T) {
+	weighted := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"A", "B", "C", "D", "E"} {
+		assert.NoError(t, weighted.AddVertex(v))
+	}
+	assert.NoError(t, weighted.AddEdge("A", "B", EdgeWeight(1)))
+	assert.NoError(t, weighted.AddEdge("B", "C", EdgeWeight(1)))
+	assert.NoError(t, weighted.AddEdge("C", "D", EdgeWeight(1)))
+	assert.NoError(t, weighted.AddEdge("A", "E", EdgeWeight(4)))
+	assert.NoError(t, weighted.AddEdge("E", "D", EdgeWeight(4)))
+	assert.NoError(t, weighted.AddEdge("A", "D", EdgeWeight(10)))
+
+	chain := newStringGraph(t, [][2]string{{"A", "B"}, {"B", "C"}, {"C", "D"}})
+
+	tests := []struct {
+		name     string
+		g        Graph[string, string]
+		maxHops  int
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			name:     "cheapest path within the limit",
+			g:        weighted,
+			maxHops:  3,
+			wantPath: []string{"A", "B", "C", "D"},
+		},
+		{
+			name:     "shorter valid path",
+			g:        weighted,
+			maxHops:  2,
+			wantPath: []string{"A", "E", "D"},
+		},
+		{
+			name:     "direct path",
+			g:        weighted,
+			maxHops:  1,
+			wantPath: []string{"A", "D"},
+		},
+		{
+			name:     "unlimited",
+			g:        weighted,
+			wantPath: []string{"A", "B", "C", "D"},
+		},
+		{
+			name:     "only path within the limit",
+			g:        chain,
+			maxHops:  3,
+			wantPath: []string{"A", "B", "C", "D"},
+		},
+		{
+			name:    "only path exceeds the limit",
+			g:       chain,
+			maxHops: 2,
+			wantErr: ErrMaxHopsExceeded,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := ShortestPath(test.g, "A", "D", MaxHops(test.maxHops))
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, path)
+		})
+	}
+}
+
+func TestShortestPathTree(t *testing.T) {
+	g := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"A", "B", "C", "D", "E"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("A", "B", EdgeWeight(4)))
+	assert.NoError(t, g.AddEdge("A", "C", EdgeWeight(1)))
+	assert.NoError(t, g.AddEdge("C", "B", EdgeWeight(2)))
+	assert.NoError(t, g.AddEdge("B", "D", EdgeWeight(5)))
+
+	previous, costs, err := ShortestPathTree(g, "A")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"B": "C", "C": "A", "D": "B"}, previous)
+	assert.Equal(t, map[string]float64{"A": 0, "B": 3, "C": 1, "D": 8}, costs)
+
+	// The paths to all reachable targets can be reconstructed from one tree.
+	for target, wantPath := range map[string][]string{
+		"A": {"A"},
+		"B": {"A", "C", "B"},
+		"C": {"A", "C"},
+		"D": {"A", "C", "B", "D"},
+	} {
+		assert.Equal(t, wantPath, pathFromTree(previous, "A", target))
+
+		path, err := ShortestPath(g, "A", target)
+		assert.NoError(t, err)
+		assert.Equal(t, wantPath, path)
+	}
+
+	_, _, err = ShortestPathTree(g, "X")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+}
+
+func TestShortestPathCovering(t *testing.T) {
+	g := New(StringHash, Directed(), Weighted())
+	for _, v := range []string{"SFO", "DEN", "ORD", "EWR", "ATL"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("SFO", "DEN", EdgeWeight(2)))
+	assert.NoError(t, g.AddEdge("DEN", "ORD", EdgeWeight(2)))
+	assert.NoError(t, g.AddEdge("ORD", "EWR", EdgeWeight(2)))
+	assert.NoError(t, g.AddEdge("SFO", "EWR", EdgeWeight(5)))
+	assert.NoError(t, g.AddEdge("EWR", "DEN", EdgeWeight(1)))
+	assert.NoError(t, g.AddEdge("DEN", "SFO", EdgeWeight(2)))
+
+	tests := []struct {
+		name     string
+		required []string
+		wantPath []string
+		wantErr  error
+	}{
+		{
+			// Of the six orders, ORD, EWR, SFO is the only one costing 5.
+			name:     "Three stops",
+			required: []string{"EWR", "SFO", "ORD"},
+			wantPath: []string{"ORD", "EWR", "DEN", "SFO"},
+		},
+		{
+			name:     "Duplicated stops",
+			required: []string{"SFO", "ORD", "SFO"},
+			wantPath: []string{"SFO", "DEN", "ORD"},
+		},
+		{
+			name:     "Single stop",
+			required: []string{"DEN"},
+			wantPath: []string{"DEN"},
+		},
+		{
+			name: "No stops",
+		},
+		{
+			name:     "Unreachable stop",
+			required: []string{"SFO", "ATL"},
+			wantErr:  ErrTargetNotReachable,
+		},
+		{
+			name:     "Unknown stop",
+			required: []string{"SFO", "LAX"},
+			wantErr:  ErrVertexNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := ShortestPathCovering(g, test.required)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, path)
+		})
+	}
+}
+
+func TestShortestPathCoveringTooMany(t *testing.T) {
+	g := New(IntHash)
+	required := make([]int, maxRequiredVertices+1)
+	for i := range required {
+		assert.NoError(t, g.AddVertex(i))
+		required[i] = i
+	}
+
+	_, err := ShortestPathCovering(g, required)
+	assert.ErrorIs(t, err, ErrTooManyRequired)
+}
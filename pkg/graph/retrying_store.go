@@ -0,0 +1,303 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// permanentErrors are the errors which are part of the Store contract. They
+// report the state of the store rather than a failure, so retrying the
+// operation won't change the result.
+var permanentErrors = []error{
+	ErrVertexNotFound,
+	ErrVertexAlreadyExists,
+	ErrEdgeNotFound,
+	ErrEdgeAlreadyExists,
+	ErrEdgeCreatesCycle,
+	ErrVertexHasEdges,
+	ErrHashCollision,
+	ErrReadOnlyStore,
+}
+
+// RetryOptions configure the retries of a store created by NewRetryingStore.
+type RetryOptions struct {
+	// Attempts is the maximum number of attempts of an operation, including
+	// the first one. It's 3 by default.
+	Attempts int
+	// Backoff is the time to wait before the first retry. It's doubled for
+	// each further retry, and 10 milliseconds by default.
+	Backoff time.Duration
+	// Retryable reports whether a failed operation should be retried. By
+	// default, all errors are retried except for the errors of the Store
+	// contract like ErrVertexNotFound.
+	Retryable func(error) bool
+}
+
+// RetryAttempts sets the maximum number of attempts of an operation.
+func RetryAttempts(attempts int) func(*RetryOptions) {
+	return func(o *RetryOptions) {
+		o.Attempts = attempts
+	}
+}
+
+// RetryBackoff sets the time to wait before the first retry.
+func RetryBackoff(backoff time.Duration) func(*RetryOptions) {
+	return func(o *RetryOptions) {
+		o.Backoff = backoff
+	}
+}
+
+// RetryIf sets the function deciding which errors are retried, for example to
+// only retry the timeouts of a networked store.
+func RetryIf(retryable func(error) bool) func(*RetryOptions) {
+	return func(o *RetryOptions) {
+		o.Retryable = retryable
+	}
+}
+
+// IsRetryable is the default of RetryOptions.Retryable. It reports whether the
+// error is a transient failure, i.e. not one of the errors of the Store
+// contract.
+func IsRetryable(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// retryingStore retries the failed operations of another store.
+type retryingStore[K comparable, T any] struct {
+	store Store[K, T]
+	opts  RetryOptions
+	sleep func(time.Duration)
+}
+
+// NewRetryingStore wraps a store, usually one backed by a database or another
+// service, so its operations are retried with an exponential backoff when they
+// fail with a transient error. Errors of the Store contract like
+// ErrVertexNotFound are returned right away.
+//
+// Note that a mutation which failed on the client side may have succeeded on
+// the server side, in which case the retry may fail with ErrVertexAlreadyExists
+// or ErrEdgeAlreadyExists.
+func NewRetryingStore[K comparable, T any](store Store[K, T], options ...func(*RetryOptions)) Store[K, T] {
+	opts := RetryOptions{
+		Attempts:  3,
+		Backoff:   10 * time.Millisecond,
+		Retryable: IsRetryable,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &retryingStore[K, T]{store: store, opts: opts, sleep: time.Sleep}
+}
+
+// retry calls the operation until it succeeds, fails with an error which isn't
+// retryable, or the attempts are used up, and returns its last result.
+func retry[K comparable, T, V any](s *retryingStore[K, T], operation func() (V, error)) (V, error) {
+	backoff := s.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		v, err := operation()
+		if err == nil || attempt >= s.opts.Attempts || !s.opts.Retryable(err) {
+			return v, err
+		}
+
+		s.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// retryErr is retry for operations which only return an error.
+func retryErr[K comparable, T any](s *retryingStore[K, T], operation func() error) error {
+	_, err := retry(s, func() (struct{}, error) {
+		return struct{}{}, operation()
+	})
+	return err
+}
+
+func (s *retryingStore[K, T]) AddVertex(hash K, value T) error {
+	return retryErr(s, func() error {
+		return s.store.AddVertex(hash, value)
+	})
+}
+
+func (s *retryingStore[K, T]) Vertex(hash K) (T, error) {
+	return retry(s, func() (T, error) {
+		return s.store.Vertex(hash)
+	})
+}
+
+func (s *retryingStore[K, T]) RemoveVertex(hash K) error {
+	return retryErr(s, func() error {
+		return s.store.RemoveVertex(hash)
+	})
+}
+
+func (s *retryingStore[K, T]) VertexProperties(hash K) (VertexProperties, error) {
+	return retry(s, func() (VertexProperties, error) {
+		return s.store.VertexProperties(hash)
+	})
+}
+
+func (s *retryingStore[K, T]) UpdateVertexProperties(hash K, properties VertexProperties) error {
+	return retryErr(s, func() error {
+		return s.store.UpdateVertexProperties(hash, properties)
+	})
+}
+
+func (s *retryingStore[K, T]) ListVertices() ([]K, error) {
+	return retry(s, s.store.ListVertices)
+}
+
+func (s *retryingStore[K, T]) VertexCount() (int, error) {
+	return retry(s, s.store.VertexCount)
+}
+
+func (s *retryingStore[K, T]) AddEdge(sourceHash, targetHash K, edge Edge[K]) error {
+	return retryErr(s, func() error {
+		return s.store.AddEdge(sourceHash, targetHash, edge)
+	})
+}
+
+func (s *retryingStore[K, T]) RemoveEdge(sourceHash, targetHash K) error {
+	return retryErr(s, func() error {
+		return s.store.RemoveEdge(sourceHash, targetHash)
+	})
+}
+
+func (s *retryingStore[K, T]) Edge(sourceHash, targetHash K) (Edge[K], error) {
+	return retry(s, func() (Edge[K], error) {
+		return s.store.Edge(sourceHash, targetHash)
+	})
+}
+
+func (s *retryingStore[K, T]) ListEdges() ([]Edge[K], error) {
+	return retry(s, s.store.ListEdges)
+}
+
+func (s *retryingStore[K, T]) Stats() (StoreStats, error) {
+	return retry(s, s.store.Stats)
+}
+
+// CreatesCycle uses the fast path of the wrapped store, like the memory store's,
+// with retries. If the wrapped store doesn't have one, errors.ErrUnsupported is
+// returned, so the graph falls back to its own check.
+func (s *retryingStore[K, T]) CreatesCycle(source, target K) (bool, error) {
+	cc, ok := s.store.(interface {
+		CreatesCycle(source, target K) (bool, error)
+	})
+	if !ok {
+		return false, errors.ErrUnsupported
+	}
+
+	return retry(s, func() (bool, error) {
+		return cc.CreatesCycle(source, target)
+	})
+}
+
+// SetVertexAttribute uses the atomic update of the wrapped store, like the
+// memory store's, with retries. If the wrapped store doesn't have one,
+// errors.ErrUnsupported is returned, so the graph falls back to reading and
+// writing back the properties.
+func (s *retryingStore[K, T]) SetVertexAttribute(hash K, key, value string) error {
+	setter, ok := s.store.(interface {
+		SetVertexAttribute(hash K, key, value string) error
+	})
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	return retryErr(s, func() error {
+		return setter.SetVertexAttribute(hash, key, value)
+	})
+}
+
+// EdgeCount counts the edges with the wrapped store, like the memory store,
+// with retries. If the wrapped store can't count them, errors.ErrUnsupported
+// is returned, so the graph lists the edges instead.
+func (s *retryingStore[K, T]) EdgeCount() (int, error) {
+	counter, ok := s.store.(interface {
+		EdgeCount() (int, error)
+	})
+	if !ok {
+		return 0, errors.ErrUnsupported
+	}
+
+	return retry(s, counter.EdgeCount)
+}
+
+// RemoveVertexEdges removes the edges of a vertex in one operation of the
+// wrapped store, like the memory store, with retries. If the wrapped store
+// can't, errors.ErrUnsupported is returned, so the graph removes the edges one
+// by one.
+func (s *retryingStore[K, T]) RemoveVertexEdges(hash K) error {
+	remover, ok := s.store.(interface {
+		RemoveVertexEdges(hash K) error
+	})
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	return retryErr(s, func() error {
+		return remover.RemoveVertexEdges(hash)
+	})
+}
+
+// Successors lists the successors of a vertex with the wrapped store, like the
+// memory store, with retries. If the wrapped store can't list them,
+// errors.ErrUnsupported is returned, so the graph uses its adjacency map.
+func (s *retryingStore[K, T]) Successors(hash K) ([]K, error) {
+	successors, ok := s.store.(interface {
+		Successors(hash K) ([]K, error)
+	})
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	return retry(s, func() ([]K, error) {
+		return successors.Successors(hash)
+	})
+}
+
+// Predecessors lists the predecessors of a vertex like Successors.
+func (s *retryingStore[K, T]) Predecessors(hash K) ([]K, error) {
+	predecessors, ok := s.store.(interface {
+		Predecessors(hash K) ([]K, error)
+	})
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	return retry(s, func() ([]K, error) {
+		return predecessors.Predecessors(hash)
+	})
+}
+
+// EdgeByID uses the index of the edge IDs of the wrapped store, like the memory
+// store's, with retries. If the wrapped store doesn't have one,
+// errors.ErrUnsupported is returned, so the graph scans the edges instead.
+func (s *retryingStore[K, T]) EdgeByID(id string) (Edge[K], error) {
+	index, ok := s.store.(interface {
+		EdgeByID(id string) (Edge[K], error)
+	})
+	if !ok {
+		return Edge[K]{}, errors.ErrUnsupported
+	}
+
+	return retry(s, func() (Edge[K], error) {
+		return index.EdgeByID(id)
+	})
+}
+
+// Ping pings the wrapped store if it implements Pinger. It isn't retried, so
+// readiness probes report failures right away.
+func (s *retryingStore[K, T]) Ping(ctx context.Context) error {
+	if pinger, ok := s.store.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("store unavailable")
+
+// flakyStore fails the first failures calls of AddEdge and Vertex.
+type flakyStore struct {
+	Store[string, string]
+	failures int
+	calls    int
+}
+
+func (s *flakyStore) fail() bool {
+	s.calls++
+	return s.calls <= s.failures
+}
+
+func (s *flakyStore) AddEdge(sourceHash, targetHash string, edge Edge[string]) error {
+	if s.fail() {
+		return errUnavailable
+	}
+	return s.Store.AddEdge(sourceHash, targetHash, edge)
+}
+
+func (s *flakyStore) Vertex(hash string) (string, error) {
+	if s.fail() {
+		return "", errUnavailable
+	}
+	return s.Store.Vertex(hash)
+}
+
+func TestRetryingStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantErr   error
+		wantCalls int
+		wantWaits []time.Duration
+	}{
+		{
+			name:      "Succeeds on the first attempt",
+			wantCalls: 1,
+		},
+		{
+			name:      "Succeeds on the second attempt",
+			failures:  1,
+			wantCalls: 2,
+			wantWaits: []time.Duration{time.Millisecond},
+		},
+		{
+			name:      "Attempts used up",
+			failures:  5,
+			wantErr:   errUnavailable,
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Millisecond, 2 * time.Millisecond},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flaky := &flakyStore{Store: NewMemoryStore[string, string](), failures: test.failures}
+			assert.NoError(t, flaky.Store.AddVertex("SFO", "SFO"))
+			assert.NoError(t, flaky.Store.AddVertex("ATL", "ATL"))
+
+			store := NewRetryingStore[string, string](flaky, RetryAttempts(3), RetryBackoff(time.Millisecond))
+			var waits []time.Duration
+			store.(*retryingStore[string, string]).sleep = func(d time.Duration) {
+				waits = append(waits, d)
+			}
+
+			err := store.AddEdge("SFO", "ATL", Edge[string]{Source: "SFO", Target: "ATL"})
+			assert.ErrorIs(t, err, test.wantErr)
+			assert.Equal(t, test.wantCalls, flaky.calls)
+			assert.Equal(t, test.wantWaits, waits)
+		})
+	}
+}
+
+func TestRetryingStorePermanentErrors(t *testing.T) {
+	flaky := &flakyStore{Store: NewMemoryStore[string, string]()}
+	store := NewRetryingStore[string, string](flaky, RetryBackoff(0))
+
+	// Missing vertices aren't retried.
+	_, err := store.Vertex("SFO")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+	assert.Equal(t, 1, flaky.calls)
+
+	// The retried errors can be restricted.
+	flaky = &flakyStore{Store: NewMemoryStore[string, string](), failures: 1}
+	store = NewRetryingStore[string, string](flaky, RetryBackoff(0), RetryIf(func(err error) bool {
+		return false
+	}))
+	_, err = store.Vertex("SFO")
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.Equal(t, 1, flaky.calls)
+
+	// The graph works on top of the store.
+	g := NewWithStore(StringHash, NewRetryingStore[string, string](NewMemoryStore[string, string]()), Directed(), AutoCreateVertices())
+	assert.NoError(t, g.AddEdge("SFO", "ATL"))
+	assert.ErrorIs(t, g.AddEdge("SFO", "ATL"), ErrEdgeAlreadyExists)
+}
+
+func TestRetryingStoreFastPaths(t *testing.T) {
+	tests := []struct {
+		name            string
+		store           Store[string, string]
+		wantUnsupported bool
+	}{
+		{
+			name:  "Memory store",
+			store: NewMemoryStore[string, string](),
+		},
+		{
+			name:            "Store without fast paths",
+			store:           &flakyStore{Store: NewMemoryStore[string, string]()},
+			wantUnsupported: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewRetryingStore(test.store)
+			fastPaths := store.(interface {
+				CreatesCycle(source, target string) (bool, error)
+				SetVertexAttribute(hash string, key, value string) error
+				EdgeByID(id string) (Edge[string], error)
+				Successors(hash string) ([]string, error)
+				Predecessors(hash string) ([]string, error)
+				EdgeCount() (int, error)
+			})
+
+			g := NewWithStore(StringHash, store, Directed(), PreventCycles())
+			assert.NoError(t, g.AddVertex("SFO"))
+			assert.NoError(t, g.AddVertex("ATL"))
+			assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWithID("DL1234")))
+
+			_, err := fastPaths.CreatesCycle("ATL", "SFO")
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+			err = fastPaths.SetVertexAttribute("SFO", "city", "San Francisco")
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+			_, err = fastPaths.EdgeByID("DL1234")
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+			_, err = fastPaths.Successors("SFO")
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+			_, err = fastPaths.Predecessors("ATL")
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+			_, err = fastPaths.EdgeCount()
+			assert.Equal(t, test.wantUnsupported, errors.Is(err, errors.ErrUnsupported))
+
+			// The graph falls back to its own implementations.
+			assert.ErrorIs(t, g.AddEdge("ATL", "SFO"), ErrEdgeCreatesCycle)
+			assert.NoError(t, g.SetVertexAttribute("SFO", "city", "San Francisco"))
+			properties, err := store.VertexProperties("SFO")
+			assert.NoError(t, err)
+			assert.Equal(t, "San Francisco", properties.Attributes["city"])
+			edge, err := EdgeByID(g, "DL1234")
+			assert.NoError(t, err)
+			assert.Equal(t, "ATL", edge.Target)
+			assert.NoError(t, g.AddVertex("EWR"))
+			assert.ErrorIs(t, g.AddEdge("ATL", "EWR", EdgeWithID("DL1234")), ErrEdgeIDAlreadyExists)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package graph
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"sync"
 )
 
@@ -25,6 +27,15 @@ type Store[K comparable, T any] interface {
 	// ErrVertexHasEdges should be returned.
 	RemoveVertex(hash K) error
 
+	// VertexProperties should return the properties of the vertex with the given hash value. The
+	// returned attributes must not share memory with the stored ones. If the vertex doesn't
+	// exist, ErrVertexNotFound should be returned.
+	VertexProperties(hash K) (VertexProperties, error)
+
+	// UpdateVertexProperties should replace the properties of the vertex with the given hash
+	// value. If the vertex doesn't exist, ErrVertexNotFound should be returned.
+	UpdateVertexProperties(hash K, properties VertexProperties) error
+
 	// ListVertices should return all vertices in the graph in a slice.
 	ListVertices() ([]K, error)
 
@@ -58,23 +69,102 @@ type Store[K comparable, T any] interface {
 
 	// ListEdges should return all edges in the graph in a slice.
 	ListEdges() ([]Edge[K], error)
+
+	// Stats should return the number of vertices and edges in the store along with a short name
+	// of the store implementation, for introspection and debugging.
+	Stats() (StoreStats, error)
+}
+
+// Pinger may be implemented by stores backed by a database or another service to report whether it
+// is reachable, e.g. for readiness probes.
+type Pinger interface {
+	// Ping should return an error if the store can't be used at the moment.
+	Ping(ctx context.Context) error
+}
+
+// StoreStats describes the contents of a Store.
+type StoreStats struct {
+	Type     string `json:"type"`
+	Vertices int    `json:"vertices"`
+	Edges    int    `json:"edges"`
+}
+
+// Copy copies all vertices, including their properties, and then all edges, including their
+// properties, from src into dst. It can be used to move a graph between different kinds of stores,
+// for example to warm up a persistent store from an in-memory snapshot.
+//
+// Vertices and edges already present in dst cause an error, so dst should usually be empty. The
+// attributes are copied as well, so changing them in one store doesn't affect the other.
+func Copy[K comparable, T any](dst, src Store[K, T]) error {
+	hashes, err := src.ListVertices()
+	if err != nil {
+		return fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	for _, hash := range hashes {
+		value, err := src.Vertex(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+
+		properties, err := src.VertexProperties(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get properties of vertex %v: %w", hash, err)
+		}
+
+		if err := dst.AddVertex(hash, value); err != nil {
+			return fmt.Errorf("failed to add vertex %v: %w", hash, err)
+		}
+
+		properties.Attributes = maps.Clone(properties.Attributes)
+		if err := dst.UpdateVertexProperties(hash, properties); err != nil {
+			return fmt.Errorf("failed to set properties of vertex %v: %w", hash, err)
+		}
+	}
+
+	edges, err := src.ListEdges()
+	if err != nil {
+		return fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	for _, edge := range edges {
+		edge.Properties.Attributes = maps.Clone(edge.Properties.Attributes)
+		if err := dst.AddEdge(edge.Source, edge.Target, edge); err != nil {
+			return fmt.Errorf("failed to add edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+	}
+
+	return nil
 }
 
 type memoryStore[K comparable, T any] struct {
-	lock     sync.RWMutex
-	vertices map[K]T
+	lock             sync.RWMutex
+	vertices         map[K]T
+	vertexProperties map[K]VertexProperties
 
 	// outEdges and inEdges store all outgoing and ingoing edges for all vertices. For O(1) access,
 	// these edges themselves are stored in maps whose keys are the hashes of the target vertices.
 	outEdges map[K]map[K]Edge[K] // source -> target
 	inEdges  map[K]map[K]Edge[K] // target -> source
+
+	// edgeCount is the number of edges in outEdges, kept up to date so it
+	// can be read without walking all vertices.
+	edgeCount int
+
+	// edgeIDs maps the IDs of the edges which have one to their source and
+	// target.
+	edgeIDs map[string][2]K
 }
 
-func newMemoryStore[K comparable, T any]() Store[K, T] {
+// NewMemoryStore creates the in-memory store used by [New]. It is safe for
+// concurrent use.
+func NewMemoryStore[K comparable, T any]() Store[K, T] {
 	return &memoryStore[K, T]{
-		vertices: make(map[K]T),
-		outEdges: make(map[K]map[K]Edge[K]),
-		inEdges:  make(map[K]map[K]Edge[K]),
+		vertices:         make(map[K]T),
+		vertexProperties: make(map[K]VertexProperties),
+		outEdges:         make(map[K]map[K]Edge[K]),
+		inEdges:          make(map[K]map[K]Edge[K]),
+		edgeIDs:          make(map[string][2]K),
 	}
 }
 
@@ -123,8 +213,8 @@ func (s *memoryStore[K, T]) Vertex(k K) (T, error) {
 }
 
 func (s *memoryStore[K, T]) RemoveVertex(k K) error {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
 	if _, ok := s.vertices[k]; !ok {
 		return ErrVertexNotFound
@@ -145,6 +235,59 @@ func (s *memoryStore[K, T]) RemoveVertex(k K) error {
 	}
 
 	delete(s.vertices, k)
+	delete(s.vertexProperties, k)
+
+	return nil
+}
+
+func (s *memoryStore[K, T]) VertexProperties(k K) (VertexProperties, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return VertexProperties{}, ErrVertexNotFound
+	}
+
+	attributes := make(map[string]string, len(s.vertexProperties[k].Attributes))
+	for key, value := range s.vertexProperties[k].Attributes {
+		attributes[key] = value
+	}
+
+	return VertexProperties{Attributes: attributes}, nil
+}
+
+func (s *memoryStore[K, T]) UpdateVertexProperties(k K, properties VertexProperties) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return ErrVertexNotFound
+	}
+
+	s.vertexProperties[k] = properties
+
+	return nil
+}
+
+// SetVertexAttribute sets one attribute of the vertex under the lock, so
+// concurrent updates of different attributes don't overwrite each other like
+// reading and writing back the VertexProperties would.
+func (s *memoryStore[K, T]) SetVertexAttribute(k K, key, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return ErrVertexNotFound
+	}
+
+	// The stored map may be shared with the caller of UpdateVertexProperties,
+	// so it's replaced rather than modified.
+	attributes := make(map[string]string, len(s.vertexProperties[k].Attributes)+1)
+	for name, v := range s.vertexProperties[k].Attributes {
+		attributes[name] = v
+	}
+	attributes[key] = value
+	s.vertexProperties[k] = VertexProperties{Attributes: attributes}
 
 	return nil
 }
@@ -153,11 +296,27 @@ func (s *memoryStore[K, T]) AddEdge(sourceHash, targetHash K, edge Edge[K]) erro
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	key := [2]K{sourceHash, targetHash}
+	if id := edge.Properties.ID; id != "" {
+		if other, ok := s.edgeIDs[id]; ok && other != key {
+			return fmt.Errorf("%w: %s", ErrEdgeIDAlreadyExists, id)
+		}
+	}
+
 	if _, ok := s.outEdges[sourceHash]; !ok {
 		s.outEdges[sourceHash] = make(map[K]Edge[K])
 	}
 
+	// Replacing an edge, e.g. to update its properties, doesn't add one.
+	if old, ok := s.outEdges[sourceHash][targetHash]; ok {
+		delete(s.edgeIDs, old.Properties.ID)
+	} else {
+		s.edgeCount++
+	}
 	s.outEdges[sourceHash][targetHash] = edge
+	if edge.Properties.ID != "" {
+		s.edgeIDs[edge.Properties.ID] = key
+	}
 
 	if _, ok := s.inEdges[targetHash]; !ok {
 		s.inEdges[targetHash] = make(map[K]Edge[K])
@@ -172,11 +331,45 @@ func (s *memoryStore[K, T]) RemoveEdge(sourceHash, targetHash K) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if edge, ok := s.outEdges[sourceHash][targetHash]; ok {
+		s.edgeCount--
+		delete(s.edgeIDs, edge.Properties.ID)
+	}
 	delete(s.inEdges[targetHash], sourceHash)
 	delete(s.outEdges[sourceHash], targetHash)
 	return nil
 }
 
+// RemoveVertexEdges is a fastpath version of the loop over all edges in
+// [RemoveVertexCascade], which removes the edges of the vertex from the edge
+// maps directly, but keeps the vertex itself.
+func (s *memoryStore[K, T]) RemoveVertexEdges(k K) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return ErrVertexNotFound
+	}
+
+	s.edgeCount -= len(s.outEdges[k])
+	for target, edge := range s.outEdges[k] {
+		delete(s.inEdges[target], k)
+		delete(s.edgeIDs, edge.Properties.ID)
+	}
+	for source, edge := range s.inEdges[k] {
+		// A self-loop is already counted with the outgoing edges.
+		if source != k {
+			s.edgeCount--
+		}
+		delete(s.outEdges[source], k)
+		delete(s.edgeIDs, edge.Properties.ID)
+	}
+	delete(s.outEdges, k)
+	delete(s.inEdges, k)
+
+	return nil
+}
+
 func (s *memoryStore[K, T]) Edge(sourceHash, targetHash K) (Edge[K], error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -194,11 +387,61 @@ func (s *memoryStore[K, T]) Edge(sourceHash, targetHash K) (Edge[K], error) {
 	return edge, nil
 }
 
+// Successors lists the targets of the outgoing edges of the vertex, so
+// traversals like [IterativeDeepeningDFS] don't need the adjacency map of the
+// whole graph.
+func (s *memoryStore[K, T]) Successors(k K) ([]K, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return nil, ErrVertexNotFound
+	}
+
+	return sortedKeys(s.outEdges[k], nil), nil
+}
+
+// Predecessors lists the sources of the ingoing edges of the vertex, which
+// are adjacencies as well in undirected graphs.
+func (s *memoryStore[K, T]) Predecessors(k K) ([]K, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.vertices[k]; !ok {
+		return nil, ErrVertexNotFound
+	}
+
+	return sortedKeys(s.inEdges[k], nil), nil
+}
+
+// EdgeByID is a fastpath version of [EdgeByID] that looks the edge up in the
+// index of the edge IDs rather than scanning all edges.
+func (s *memoryStore[K, T]) EdgeByID(id string) (Edge[K], error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	key, ok := s.edgeIDs[id]
+	if !ok {
+		return Edge[K]{}, ErrEdgeNotFound
+	}
+
+	return s.outEdges[key[0]][key[1]], nil
+}
+
 func (s *memoryStore[K, T]) ListEdges() ([]Edge[K], error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	res := make([]Edge[K], 0)
+	// Counting the edges first saves growing the slice, which made up about
+	// 40% of the memory allocated by AdjacencyMap and PredecessorMap. The
+	// edges are listed by source, which lets AdjacencyMap presize its inner
+	// maps.
+	count := 0
+	for _, edges := range s.outEdges {
+		count += len(edges)
+	}
+
+	res := make([]Edge[K], 0, count)
 	for _, edges := range s.outEdges {
 		for _, edge := range edges {
 			res = append(res, edge)
@@ -207,6 +450,27 @@ func (s *memoryStore[K, T]) ListEdges() ([]Edge[K], error) {
 	return res, nil
 }
 
+// EdgeCount is a fastpath version of counting the edges listed by ListEdges,
+// which reads the count kept up to date by the edge changes.
+func (s *memoryStore[K, T]) EdgeCount() (int, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.edgeCount, nil
+}
+
+func (s *memoryStore[K, T]) Stats() (StoreStats, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return StoreStats{Type: "memory", Vertices: len(s.vertices), Edges: s.edgeCount}, nil
+}
+
+// Ping always succeeds, since the memory store has no backend to reach.
+func (s *memoryStore[K, T]) Ping(context.Context) error {
+	return nil
+}
+
 // CreatesCycle is a fastpath version of [CreatesCycle] that avoids calling
 // [PredecessorMap], which generates large amounts of garbage to collect.
 //
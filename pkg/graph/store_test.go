@@ -0,0 +1,272 @@
+package graph
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	src := NewMemoryStore[string, string]()
+	g := NewWithStore(StringHash, src, Directed(), Weighted())
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.SetVertexAttribute("SFO", "lat", "37.6188"))
+	assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWeight(2139), EdgeAttribute("carrier", "DL")))
+	assert.NoError(t, g.AddEdge("ATL", "EWR", EdgeWeight(746)))
+
+	dst := NewMemoryStore[string, string]()
+	assert.NoError(t, Copy(dst, src))
+
+	srcVertices, err := src.ListVertices()
+	assert.NoError(t, err)
+	dstVertices, err := dst.ListVertices()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, srcVertices, dstVertices)
+
+	for _, hash := range srcVertices {
+		srcValue, _ := src.Vertex(hash)
+		dstValue, err := dst.Vertex(hash)
+		assert.NoError(t, err)
+		assert.Equal(t, srcValue, dstValue)
+
+		srcProperties, _ := src.VertexProperties(hash)
+		dstProperties, err := dst.VertexProperties(hash)
+		assert.NoError(t, err)
+		assert.Equal(t, srcProperties, dstProperties)
+	}
+
+	srcEdges, err := src.ListEdges()
+	assert.NoError(t, err)
+	dstEdges, err := dst.ListEdges()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, srcEdges, dstEdges)
+
+	edge, err := dst.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+	assert.Equal(t, EdgeProperties{Attributes: map[string]string{"carrier": "DL"}, Weight: 2139}, edge.Properties)
+
+	// The stores don't share attributes.
+	edge.Properties.Attributes["carrier"] = "UA"
+	edge, err = src.Edge("SFO", "ATL")
+	assert.NoError(t, err)
+	assert.Equal(t, "DL", edge.Properties.Attributes["carrier"])
+}
+
+func TestCopyIntoNonEmptyStore(t *testing.T) {
+	src := NewMemoryStore[string, string]()
+	assert.NoError(t, src.AddVertex("SFO", "SFO"))
+
+	dst := NewMemoryStore[string, string]()
+	assert.NoError(t, dst.AddVertex("SFO", "SFO"))
+
+	assert.ErrorIs(t, Copy(dst, src), ErrVertexAlreadyExists)
+}
+
+func TestMemoryStoreStats(t *testing.T) {
+	store := NewMemoryStore[string, string]()
+
+	stats, err := store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "memory"}, stats)
+
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, store.AddVertex(v, v))
+	}
+	assert.NoError(t, store.AddEdge("SFO", "ATL", Edge[string]{Source: "SFO", Target: "ATL"}))
+	assert.NoError(t, store.AddEdge("ATL", "EWR", Edge[string]{Source: "ATL", Target: "EWR"}))
+	// Replacing an edge doesn't count it twice.
+	assert.NoError(t, store.AddEdge("ATL", "EWR", Edge[string]{Source: "ATL", Target: "EWR", Properties: EdgeProperties{Weight: 1}}))
+
+	stats, err = store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "memory", Vertices: 3, Edges: 2}, stats)
+
+	count, err := edgeCount(store)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, store.RemoveEdge("ATL", "EWR"))
+	assert.NoError(t, store.RemoveEdge("ATL", "EWR"))
+	assert.NoError(t, store.RemoveVertex("EWR"))
+
+	stats, err = store.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, StoreStats{Type: "memory", Vertices: 2, Edges: 1}, stats)
+}
+
+func TestMemoryStoreSetVertexAttributeConcurrently(t *testing.T) {
+	g := New(StringHash, Directed())
+	assert.NoError(t, g.AddVertex("SFO"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, g.SetVertexAttribute("SFO", fmt.Sprintf("gate%d", i), "open"))
+		}(i)
+	}
+	wg.Wait()
+
+	// No update is lost to another one.
+	attributes, err := g.VertexAttributes("SFO")
+	assert.NoError(t, err)
+	assert.Len(t, attributes, 50)
+}
+
+func TestRemoveVertexEdges(t *testing.T) {
+	stores := map[string]func() Store[string, string]{
+		"memory":         NewMemoryStore[string, string],
+		"ordered-memory": NewOrderedMemoryStore[string, string],
+		"retrying": func() Store[string, string] {
+			return NewRetryingStore(NewMemoryStore[string, string]())
+		},
+		// The edges are removed one by one without RemoveVertexEdges.
+		"without fast path": func() Store[string, string] {
+			return &listCountingStore{Store: NewMemoryStore[string, string]()}
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+			for _, v := range []string{"SFO", "ATL", "EWR", "IND"} {
+				assert.NoError(t, store.AddVertex(v, v))
+			}
+			for _, edge := range [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "ATL"}, {"ATL", "ATL"}, {"EWR", "IND"}} {
+				assert.NoError(t, store.AddEdge(edge[0], edge[1], Edge[string]{Source: edge[0], Target: edge[1]}))
+			}
+
+			assert.NoError(t, removeVertexEdges(store, "ATL"))
+
+			_, err := store.Vertex("ATL")
+			assert.NoError(t, err)
+
+			edges, err := store.ListEdges()
+			assert.NoError(t, err)
+			assert.Equal(t, []Edge[string]{{Source: "EWR", Target: "IND"}}, edges)
+
+			stats, err := store.Stats()
+			assert.NoError(t, err)
+			assert.Equal(t, 4, stats.Vertices)
+			assert.Equal(t, 1, stats.Edges)
+
+			count, err := edgeCount(store)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			// Without edges, the vertex can be removed.
+			assert.NoError(t, store.RemoveVertex("ATL"))
+
+			assert.ErrorIs(t, removeVertexEdges(store, "BOS"), ErrVertexNotFound)
+		})
+	}
+}
+
+func TestRemoveVertexCascade(t *testing.T) {
+	for _, directed := range []bool{true, false} {
+		var options []func(*Traits)
+		if directed {
+			options = append(options, Directed())
+		}
+		g := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"IND", "ATL"}, {"EWR", "IND"}}, options...)
+
+		assert.ErrorIs(t, g.RemoveVertex("ATL"), ErrVertexHasEdges)
+		assert.NoError(t, RemoveVertexCascade(g, "ATL"))
+
+		_, err := g.Vertex("ATL")
+		assert.ErrorIs(t, err, ErrVertexNotFound)
+		assert.ElementsMatch(t, [][2]string{{"EWR", "IND"}}, edgePairs(t, g))
+
+		assert.ErrorIs(t, RemoveVertexCascade(g, "ATL"), ErrVertexNotFound)
+	}
+}
+
+func TestMemoryStoreEdgeIDs(t *testing.T) {
+	store := NewMemoryStore[string, string]().(*memoryStore[string, string])
+	for _, v := range []string{"SFO", "ATL", "EWR"} {
+		assert.NoError(t, store.AddVertex(v, v))
+	}
+	edge := func(source, target, id string) Edge[string] {
+		return Edge[string]{Source: source, Target: target, Properties: EdgeProperties{ID: id}}
+	}
+
+	assert.NoError(t, store.AddEdge("SFO", "ATL", edge("SFO", "ATL", "DL1234")))
+	assert.ErrorIs(t, store.AddEdge("ATL", "EWR", edge("ATL", "EWR", "DL1234")), ErrEdgeIDAlreadyExists)
+
+	// Replacing the edge may change its ID, which frees the old one.
+	assert.NoError(t, store.AddEdge("SFO", "ATL", edge("SFO", "ATL", "DL88")))
+	_, err := store.EdgeByID("DL1234")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+	found, err := store.EdgeByID("DL88")
+	assert.NoError(t, err)
+	assert.Equal(t, "ATL", found.Target)
+
+	assert.NoError(t, store.AddEdge("ATL", "EWR", edge("ATL", "EWR", "DL1234")))
+	assert.NoError(t, store.RemoveVertexEdges("ATL"))
+	for _, id := range []string{"DL88", "DL1234"} {
+		_, err := store.EdgeByID(id)
+		assert.ErrorIs(t, err, ErrEdgeNotFound)
+	}
+}
+
+// multiStore is a store keeping parallel edges, as a multigraph store would.
+// Vertices are kept by the embedded memory store.
+type multiStore struct {
+	Store[string, string]
+	edges []Edge[string]
+}
+
+func newMultiStore() Store[string, string] {
+	return &multiStore{Store: NewMemoryStore[string, string]()}
+}
+
+func (s *multiStore) AddEdge(sourceHash, targetHash string, edge Edge[string]) error {
+	s.edges = append(s.edges, edge)
+	return nil
+}
+
+func (s *multiStore) RemoveEdge(sourceHash, targetHash string) error {
+	edges := s.edges[:0]
+	for _, edge := range s.edges {
+		if edge.Source != sourceHash || edge.Target != targetHash {
+			edges = append(edges, edge)
+		}
+	}
+	s.edges = edges
+	return nil
+}
+
+func (s *multiStore) RemoveVertexEdges(hash string) error {
+	if _, err := s.Vertex(hash); err != nil {
+		return err
+	}
+
+	edges := s.edges[:0]
+	for _, edge := range s.edges {
+		if edge.Source != hash && edge.Target != hash {
+			edges = append(edges, edge)
+		}
+	}
+	s.edges = edges
+	return nil
+}
+
+func (s *multiStore) Edge(sourceHash, targetHash string) (Edge[string], error) {
+	for _, edge := range s.edges {
+		if edge.Source == sourceHash && edge.Target == targetHash {
+			return edge, nil
+		}
+	}
+	return Edge[string]{}, ErrEdgeNotFound
+}
+
+func (s *multiStore) ListEdges() ([]Edge[string], error) {
+	return append([]Edge[string]{}, s.edges...), nil
+}
+
+func (s *multiStore) EdgeCount() (int, error) {
+	return len(s.edges), nil
+}
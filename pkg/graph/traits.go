@@ -8,9 +8,18 @@ package graph
 //
 // This will set the IsDirected field to true.
 type Traits struct {
-	IsDirected    bool
-	IsAcyclic     bool
-	PreventCycles bool
+	IsDirected         bool
+	IsAcyclic          bool
+	IsWeighted         bool
+	PreventCycles      bool
+	AutoCreateVertices bool
+	// DetectHashCollisions is a debug mode making AddVertex compare the
+	// vertex with the stored vertex of the same hash.
+	DetectHashCollisions bool
+	// MaxVertices and MaxEdges limit the order and size of the graph. 0 means
+	// no limit.
+	MaxVertices int
+	MaxEdges    int
 }
 
 // Directed creates a directed graph. This has implications on graph traversal and the order of
@@ -37,3 +46,48 @@ func PreventCycles() func(*Traits) {
 		t.PreventCycles = true
 	}
 }
+
+// Weighted creates a weighted graph. Path algorithms then use the weights set with EdgeWeight as
+// the cost of an edge. In unweighted graphs, each edge costs 1, i.e. costs are hop counts.
+func Weighted() func(*Traits) {
+	return func(t *Traits) {
+		t.IsWeighted = true
+	}
+}
+
+// AutoCreateVertices makes AddEdge add missing source and target vertices instead of returning
+// ErrVertexNotFound. This requires the vertices to be their own hashes, as with StringHash or
+// IntHash, since the vertex value is taken from the hash passed to AddEdge. For other graphs,
+// AddEdge keeps returning ErrVertexNotFound.
+func AutoCreateVertices() func(*Traits) {
+	return func(t *Traits) {
+		t.AutoCreateVertices = true
+	}
+}
+
+// DetectHashCollisions makes AddVertex return ErrHashCollision when the hash of a vertex is already
+// taken by a different vertex, instead of treating it as the same vertex. This catches buggy Hash
+// functions early, at the cost of a lookup and comparison for each added vertex, so it's meant for
+// debugging.
+func DetectHashCollisions() func(*Traits) {
+	return func(t *Traits) {
+		t.DetectHashCollisions = true
+	}
+}
+
+// WithLimits caps the number of vertices and edges of the graph, e.g. to keep a graph built from
+// client input from exhausting the memory. Once a limit is reached, AddVertex and AddEdge return
+// ErrLimitExceeded, including for vertices created by AutoCreateVertices. A limit of 0 disables
+// it. The counts are taken from the store, so the limits also apply to a store filled beforehand.
+// The memory store keeps count of its edges, while stores without an EdgeCount method have their
+// edges listed for each added edge.
+//
+// The limits are checked before a vertex or edge is added, which isn't atomic: concurrent calls
+// may all pass the check and exceed the limit together. Graphs shared between goroutines should
+// add vertices and edges under a common lock if the limits must hold exactly.
+func WithLimits(maxVertices, maxEdges int) func(*Traits) {
+	return func(t *Traits) {
+		t.MaxVertices = maxVertices
+		t.MaxEdges = maxEdges
+	}
+}
@@ -0,0 +1,303 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPathsConflict is returned by MergePaths for paths which can't be merged
+// into a single route.
+var ErrPathsConflict = errors.New("paths conflict")
+
+// newEmptyLike creates an empty graph with the hash function and directedness
+// of g. Only graphs created by New or NewWithStore are supported, since the
+// hash function of other implementations isn't known.
+func newEmptyLike[K comparable, T any](g Graph[K, T]) (Graph[K, T], error) {
+	hash, _, err := internalsOf(g)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []func(*Traits)
+	if g.Traits().IsDirected {
+		options = append(options, Directed())
+	}
+
+	return New(hash, options...), nil
+}
+
+// internalsOf returns the hash function and the store of g. Only graphs
+// created by New or NewWithStore are supported.
+func internalsOf[K comparable, T any](g Graph[K, T]) (Hash[K, T], Store[K, T], error) {
+	switch g := g.(type) {
+	case *directed[K, T]:
+		return g.hash, g.store, nil
+	case *undirected[K, T]:
+		return g.hash, g.store, nil
+	default:
+		return nil, nil, errors.New("graph implementation not supported")
+	}
+}
+
+// addVerticesOf adds all vertices of src along with their attributes to dst.
+func addVerticesOf[K comparable, T any](dst, src Graph[K, T], hashes []K) error {
+	for _, hash := range hashes {
+		value, err := src.Vertex(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get vertex %v: %w", hash, err)
+		}
+
+		attributes, err := src.VertexAttributes(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get attributes of vertex %v: %w", hash, err)
+		}
+
+		if err := dst.AddVertex(value); err != nil {
+			return fmt.Errorf("failed to add vertex %v: %w", hash, err)
+		}
+
+		for key, value := range attributes {
+			if err := dst.SetVertexAttribute(hash, key, value); err != nil {
+				return fmt.Errorf("failed to set attribute of vertex %v: %w", hash, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Relabel creates a copy of the graph in memory, where every vertex and edge
+// endpoint is remapped to a new hash, e.g. to normalize airport codes from
+// different sources:
+//
+//	upper, err := graph.Relabel(g, strings.ToUpper)
+//
+// The vertex values, attributes, edge properties and traits are kept. The copy
+// hashes vertex values with the hash function of the graph followed by the
+// mapping. If the mapping maps two vertices to the same hash, ErrHashCollision
+// is returned. Only graphs created by New or NewWithStore are supported.
+func Relabel[K comparable, T any](g Graph[K, T], mapping func(K) K) (Graph[K, T], error) {
+	hash, store, err := internalsOf(g)
+	if err != nil {
+		return nil, err
+	}
+
+	relabeledTraits := *g.Traits()
+	relabeledHash := func(value T) K {
+		return mapping(hash(value))
+	}
+	relabeled := NewMemoryStore[K, T]()
+
+	hashes, err := store.ListVertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	origins := make(map[K]K, len(hashes))
+	for _, vertex := range hashes {
+		newHash := mapping(vertex)
+		if origin, ok := origins[newHash]; ok {
+			return nil, fmt.Errorf("%w: %v and %v are both mapped to %v", ErrHashCollision, origin, vertex, newHash)
+		}
+		origins[newHash] = vertex
+
+		value, err := store.Vertex(vertex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vertex %v: %w", vertex, err)
+		}
+
+		properties, err := store.VertexProperties(vertex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get properties of vertex %v: %w", vertex, err)
+		}
+
+		if err := relabeled.AddVertex(newHash, value); err != nil {
+			return nil, fmt.Errorf("failed to add vertex %v: %w", newHash, err)
+		}
+		if err := relabeled.UpdateVertexProperties(newHash, properties); err != nil {
+			return nil, fmt.Errorf("failed to set properties of vertex %v: %w", newHash, err)
+		}
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	for _, edge := range edges {
+		source, target := mapping(edge.Source), mapping(edge.Target)
+		err := relabeled.AddEdge(source, target, Edge[K]{Source: source, Target: target, Properties: edge.Properties})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add edge from %v to %v: %w", source, target, err)
+		}
+	}
+
+	if relabeledTraits.IsDirected {
+		return newDirected(relabeledHash, &relabeledTraits, relabeled), nil
+	}
+
+	return newUndirected(relabeledHash, &relabeledTraits, relabeled), nil
+}
+
+// Subgraph creates a copy of the graph in memory, which only contains the
+// given vertices and the edges joining two of them, e.g. to zoom into a region
+// of a flight network. Edges to vertices outside the set are left out. The
+// vertex values, attributes, edge properties and traits are kept. If any of the
+// vertices doesn't exist, ErrVertexNotFound is returned. Only graphs created by
+// New or NewWithStore are supported.
+func Subgraph[K comparable, T any](g Graph[K, T], vertices []K) (Graph[K, T], error) {
+	hash, store, err := internalsOf(g)
+	if err != nil {
+		return nil, err
+	}
+
+	subTraits := *g.Traits()
+	sub := NewMemoryStore[K, T]()
+
+	included := make(map[K]bool, len(vertices))
+	for _, vertex := range vertices {
+		if included[vertex] {
+			continue
+		}
+		included[vertex] = true
+
+		value, err := store.Vertex(vertex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vertex %v: %w", vertex, err)
+		}
+
+		properties, err := store.VertexProperties(vertex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get properties of vertex %v: %w", vertex, err)
+		}
+
+		if err := sub.AddVertex(vertex, value); err != nil {
+			return nil, fmt.Errorf("failed to add vertex %v: %w", vertex, err)
+		}
+		if err := sub.UpdateVertexProperties(vertex, properties); err != nil {
+			return nil, fmt.Errorf("failed to set properties of vertex %v: %w", vertex, err)
+		}
+	}
+
+	edges, err := store.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	for _, edge := range edges {
+		if !included[edge.Source] || !included[edge.Target] {
+			continue
+		}
+		if err := sub.AddEdge(edge.Source, edge.Target, edge); err != nil {
+			return nil, fmt.Errorf("failed to add edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+	}
+
+	if subTraits.IsDirected {
+		return newDirected(hash, &subTraits, sub), nil
+	}
+
+	return newUndirected(hash, &subTraits, sub), nil
+}
+
+// Complement returns the complement of the graph: a new graph with the same
+// vertices, which has an edge wherever the graph doesn't have one. Self-loops
+// are left out. In a flight network, its edges are the routes which don't
+// exist yet.
+//
+// The complement of a sparse graph is dense, with up to V*(V-1) edges for
+// directed and V*(V-1)/2 edges for undirected graphs, so building it takes
+// O(V²) time and memory. The complement is directed if the graph is, and has
+// none of its other traits, e.g. it isn't acyclic. Edge properties are lost.
+func Complement[K comparable, T any](g Graph[K, T]) (Graph[K, T], error) {
+	complement, err := newEmptyLike(g)
+	if err != nil {
+		return nil, err
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	hashes := make([]K, 0, len(adjacencyMap))
+	for hash := range adjacencyMap {
+		hashes = append(hashes, hash)
+	}
+
+	if err := addVerticesOf(complement, g, hashes); err != nil {
+		return nil, err
+	}
+
+	directed := g.Traits().IsDirected
+	for i, source := range hashes {
+		for j, target := range hashes {
+			// Undirected edges are only added once, for the first of both
+			// directions.
+			if i == j || (!directed && j < i) {
+				continue
+			}
+			if _, ok := adjacencyMap[source][target]; ok {
+				continue
+			}
+
+			if err := complement.AddEdge(source, target); err != nil {
+				return nil, fmt.Errorf("failed to add edge from %v to %v: %w", source, target, err)
+			}
+		}
+	}
+
+	return complement, nil
+}
+
+// MergePaths merges the paths, given as sequences of vertices, into a single
+// directed acyclic graph, e.g. to consolidate itineraries sharing some legs.
+// Vertices and edges which appear in several paths are only added once, and
+// empty paths are ignored.
+//
+// The merged graph has to remain a single route, so an ErrPathsConflict is
+// returned if the paths branch, i.e. a vertex is left or entered through two
+// different edges, or if they form a cycle. In the latter case, the error also
+// matches ErrEdgeCreatesCycle.
+func MergePaths[K comparable, T any](hash Hash[K, T], paths ...[]T) (Graph[K, T], error) {
+	merged := New(hash, Directed(), PreventCycles())
+
+	successors := make(map[K]K)
+	predecessors := make(map[K]K)
+
+	for _, path := range paths {
+		for i, value := range path {
+			err := merged.AddVertex(value)
+			if err != nil && !errors.Is(err, ErrVertexAlreadyExists) {
+				return nil, fmt.Errorf("failed to add vertex %v: %w", hash(value), err)
+			}
+			if i == 0 {
+				continue
+			}
+
+			source, target := hash(path[i-1]), hash(value)
+			if successor, ok := successors[source]; ok {
+				if successor == target {
+					continue
+				}
+				return nil, fmt.Errorf("%w: %v is followed by both %v and %v", ErrPathsConflict, source, successor, target)
+			}
+			if predecessor, ok := predecessors[target]; ok {
+				return nil, fmt.Errorf("%w: %v is preceded by both %v and %v", ErrPathsConflict, target, predecessor, source)
+			}
+
+			err = merged.AddEdge(source, target)
+			if errors.Is(err, ErrEdgeCreatesCycle) {
+				return nil, fmt.Errorf("%w: edge from %v to %v: %w", ErrPathsConflict, source, target, err)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to add edge from %v to %v: %w", source, target, err)
+			}
+
+			successors[source] = target
+			predecessors[target] = source
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// edgePairs returns the source and target of each edge of the graph.
+func edgePairs(t *testing.T, g Graph[string, string]) [][2]string {
+	edges, err := g.Edges()
+	assert.NoError(t, err)
+
+	pairs := make([][2]string, 0, len(edges))
+	for _, edge := range edges {
+		pairs = append(pairs, [2]string{edge.Source, edge.Target})
+	}
+
+	return pairs
+}
+
+func TestComplement(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "EWR"}}
+
+	g := newStringGraph(t, edges, Directed())
+	assert.NoError(t, g.AddVertex("IND"))
+	assert.NoError(t, g.SetVertexAttribute("IND", "city", "Indianapolis"))
+
+	complement, err := Complement(g)
+	assert.NoError(t, err)
+	assert.True(t, complement.Traits().IsDirected)
+	assert.ElementsMatch(t, [][2]string{
+		{"SFO", "EWR"}, {"SFO", "IND"},
+		{"ATL", "SFO"}, {"ATL", "IND"},
+		{"EWR", "SFO"}, {"EWR", "ATL"}, {"EWR", "IND"},
+		{"IND", "SFO"}, {"IND", "ATL"}, {"IND", "EWR"},
+	}, edgePairs(t, complement))
+
+	attributes, err := complement.VertexAttributes("IND")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"city": "Indianapolis"}, attributes)
+
+	// Undirected edges are missing in both directions or not at all.
+	g = newStringGraph(t, edges)
+	assert.NoError(t, g.AddVertex("IND"))
+
+	complement, err = Complement(g)
+	assert.NoError(t, err)
+	assert.False(t, complement.Traits().IsDirected)
+
+	for _, pair := range edgePairs(t, complement) {
+		_, err := g.Edge(pair[0], pair[1])
+		assert.ErrorIs(t, err, ErrEdgeNotFound)
+	}
+	size, err := complement.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, size)
+}
+
+func TestRelabel(t *testing.T) {
+	for _, directed := range []bool{true, false} {
+		var options []func(*Traits)
+		if directed {
+			options = append(options, Directed())
+		}
+		options = append(options, Weighted())
+
+		g := New(StringHash, options...)
+		for _, v := range []string{"sfo", "atl", "ewr"} {
+			assert.NoError(t, g.AddVertex(v))
+		}
+		assert.NoError(t, g.SetVertexAttribute("sfo", "city", "San Francisco"))
+		assert.NoError(t, g.AddEdge("sfo", "atl", EdgeWeight(4)))
+		assert.NoError(t, g.AddEdge("atl", "ewr", EdgeWeight(2)))
+
+		upper, err := Relabel(g, strings.ToUpper)
+		assert.NoError(t, err)
+		assert.Equal(t, *g.Traits(), *upper.Traits())
+		assert.ElementsMatch(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}}, edgePairs(t, upper))
+
+		edge, err := upper.Edge("SFO", "ATL")
+		assert.NoError(t, err)
+		assert.Equal(t, 4.0, edge.Properties.Weight)
+
+		// The values are kept and hashed with the mapping from now on.
+		value, err := upper.Vertex("SFO")
+		assert.NoError(t, err)
+		assert.Equal(t, "sfo", value)
+		assert.NoError(t, upper.AddVertex("ind"))
+		_, err = upper.Vertex("IND")
+		assert.NoError(t, err)
+
+		attributes, err := upper.VertexAttributes("SFO")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"city": "San Francisco"}, attributes)
+
+		// The original graph is left untouched.
+		_, err = g.Vertex("SFO")
+		assert.ErrorIs(t, err, ErrVertexNotFound)
+	}
+}
+
+func TestRelabelCollision(t *testing.T) {
+	g := newStringGraph(t, [][2]string{{"sfo", "SFO"}}, Directed())
+
+	_, err := Relabel(g, strings.ToUpper)
+	assert.ErrorIs(t, err, ErrHashCollision)
+}
+
+func TestSubgraph(t *testing.T) {
+	for _, directed := range []bool{true, false} {
+		var options []func(*Traits)
+		if directed {
+			options = append(options, Directed())
+		}
+		options = append(options, Weighted())
+
+		g := New(StringHash, options...)
+		for _, v := range []string{"SFO", "ATL", "EWR", "IND", "GSO"} {
+			assert.NoError(t, g.AddVertex(v))
+		}
+		assert.NoError(t, g.SetVertexAttribute("SFO", "city", "San Francisco"))
+		assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWeight(4)))
+		assert.NoError(t, g.AddEdge("ATL", "EWR", EdgeWeight(2)))
+		assert.NoError(t, g.AddEdge("EWR", "SFO", EdgeWeight(5)))
+		// Dangling edges to vertices outside the subgraph.
+		assert.NoError(t, g.AddEdge("ATL", "IND"))
+		assert.NoError(t, g.AddEdge("GSO", "SFO"))
+
+		sub, err := Subgraph(g, []string{"SFO", "ATL", "EWR", "ATL"})
+		assert.NoError(t, err)
+		assert.Equal(t, *g.Traits(), *sub.Traits())
+		assert.ElementsMatch(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}}, edgePairs(t, sub))
+
+		order, err := sub.Order()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, order)
+
+		edge, err := sub.Edge("SFO", "ATL")
+		assert.NoError(t, err)
+		assert.Equal(t, 4.0, edge.Properties.Weight)
+
+		attributes, err := sub.VertexAttributes("SFO")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"city": "San Francisco"}, attributes)
+
+		// The subgraph is a copy, changing it leaves the graph untouched.
+		assert.NoError(t, sub.RemoveEdge("SFO", "ATL"))
+		_, err = g.Edge("SFO", "ATL")
+		assert.NoError(t, err)
+
+		_, err = Subgraph(g, []string{"SFO", "LAX"})
+		assert.ErrorIs(t, err, ErrVertexNotFound)
+
+		empty, err := Subgraph(g, nil)
+		assert.NoError(t, err)
+		order, err = empty.Order()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, order)
+	}
+}
+
+func TestMergePaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		paths     [][]string
+		wantEdges [][2]string
+		wantErr   error
+	}{
+		{
+			name:      "Overlapping paths",
+			paths:     [][]string{{"SFO", "ATL", "EWR"}, {"ATL", "EWR", "IND"}},
+			wantEdges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}},
+		},
+		{
+			name:      "Disjoint paths and lone airports",
+			paths:     [][]string{{"SFO", "ATL"}, {}, {"EWR", "IND"}, {"GSO"}},
+			wantEdges: [][2]string{{"SFO", "ATL"}, {"EWR", "IND"}},
+		},
+		{
+			name:    "Paths leaving a vertex differently",
+			paths:   [][]string{{"SFO", "ATL", "EWR"}, {"ATL", "IND"}},
+			wantErr: ErrPathsConflict,
+		},
+		{
+			name:    "Paths entering a vertex differently",
+			paths:   [][]string{{"SFO", "EWR"}, {"ATL", "EWR"}},
+			wantErr: ErrPathsConflict,
+		},
+		{
+			name:    "Paths forming a cycle",
+			paths:   [][]string{{"SFO", "ATL", "EWR"}, {"EWR", "SFO"}},
+			wantErr: ErrEdgeCreatesCycle,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := MergePaths(StringHash, test.paths...)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				assert.ErrorIs(t, err, ErrPathsConflict)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantEdges, edgePairs(t, merged))
+
+			for _, path := range test.paths {
+				for _, vertex := range path {
+					_, err := merged.Vertex(vertex)
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,120 @@
 package graph
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// TraversalOptions configure a traversal of the graph.
+type TraversalOptions[K comparable] struct {
+	// Less orders the adjacencies of a vertex, which are visited from the
+	// least to the greatest. Without it, adjacencies are visited in random
+	// order.
+	Less func(a, b K) bool
+}
+
+// VisitOrder makes the traversal visit the adjacencies of each vertex in the
+// order given by less, which makes the traversal deterministic:
+//
+//	_ = graph.DFS(g, "SFO", visit, graph.VisitOrder(func(a, b string) bool {
+//		return a < b
+//	}))
+func VisitOrder[K comparable](less func(a, b K) bool) func(*TraversalOptions[K]) {
+	return func(o *TraversalOptions[K]) {
+		o.Less = less
+	}
+}
+
+// sortedKeys returns the keys of the map, ordered by less if it isn't nil.
+func sortedKeys[K comparable, V any](m map[K]V, less func(a, b K) bool) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	if less != nil {
+		sort.Slice(keys, func(i, j int) bool {
+			return less(keys[i], keys[j])
+		})
+	}
+
+	return keys
+}
+
+// successorsOf returns a function listing the successors of a vertex of g, or
+// its adjacencies if g is undirected, one vertex at a time. The store of graphs
+// created by New or NewWithStore is queried directly if it implements
+// Successors and, for undirected graphs, Predecessors, unless it returns
+// errors.ErrUnsupported. Otherwise, the adjacency map of g is built on the
+// first call.
+func successorsOf[K comparable, T any](g Graph[K, T]) func(hash K) ([]K, error) {
+	var adjacencyMap map[K]map[K]Edge[K]
+	fromAdjacencyMap := func(hash K) ([]K, error) {
+		if adjacencyMap == nil {
+			var err error
+			if adjacencyMap, err = g.AdjacencyMap(); err != nil {
+				return nil, fmt.Errorf("could not get adjacency map: %w", err)
+			}
+		}
+		return sortedKeys(adjacencyMap[hash], nil), nil
+	}
+
+	_, store, err := internalsOf(g)
+	if err != nil {
+		return fromAdjacencyMap
+	}
+	successors, ok := store.(interface {
+		Successors(hash K) ([]K, error)
+	})
+	if !ok {
+		return fromAdjacencyMap
+	}
+	predecessors, ok := store.(interface {
+		Predecessors(hash K) ([]K, error)
+	})
+	if !ok && !g.Traits().IsDirected {
+		return fromAdjacencyMap
+	}
+
+	unsupported := false
+	return func(hash K) ([]K, error) {
+		if unsupported {
+			return fromAdjacencyMap(hash)
+		}
+
+		adjacencies, err := successors.Successors(hash)
+		if err == nil && !g.Traits().IsDirected {
+			var ingoing []K
+			ingoing, err = predecessors.Predecessors(hash)
+			adjacencies = appendMissing(adjacencies, ingoing)
+		}
+		if errors.Is(err, errors.ErrUnsupported) {
+			unsupported = true
+			return fromAdjacencyMap(hash)
+		}
+
+		return adjacencies, err
+	}
+}
+
+// appendMissing appends the hashes which aren't part of hashes yet, e.g. the
+// ingoing edge of a self-loop, which is also an outgoing one.
+func appendMissing[K comparable](hashes, more []K) []K {
+	seen := make(map[K]bool, len(hashes))
+	for _, hash := range hashes {
+		seen[hash] = true
+	}
+
+	for _, hash := range more {
+		if !seen[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes
+}
 
 // DFS performs a depth-first search on the graph, starting from the given vertex. The visit
 // function will be invoked with the hash of the vertex currently visited. If it returns false, DFS
@@ -31,8 +145,16 @@ import "fmt"
 //		return c.Name == "London"
 //	}
 //
+// The adjacencies of a vertex are visited in random order, unless the order is
+// set with the VisitOrder option.
+//
 // DFS is non-recursive and maintains a stack instead.
-func DFS[K comparable, T any](g Graph[K, T], start K, visit func(K) bool) error {
+func DFS[K comparable, T any](g Graph[K, T], start K, visit func(K) bool, options ...func(*TraversalOptions[K])) error {
+	var opts TraversalOptions[K]
+	for _, option := range options {
+		option(&opts)
+	}
+
 	adjacencyMap, err := g.AdjacencyMap()
 	if err != nil {
 		return fmt.Errorf("could not get adjacency map: %w", err)
@@ -59,11 +181,291 @@ func DFS[K comparable, T any](g Graph[K, T], start K, visit func(K) bool) error
 			}
 			visited[currentHash] = true
 
+			adjacencies := make([]K, 0, len(adjacencyMap[currentHash]))
 			for adjacency := range adjacencyMap[currentHash] {
-				stack = append(stack, adjacency)
+				adjacencies = append(adjacencies, adjacency)
 			}
+
+			// The last pushed adjacency is visited first, so push them from
+			// the greatest to the least.
+			if opts.Less != nil {
+				sort.Slice(adjacencies, func(i, j int) bool {
+					return opts.Less(adjacencies[j], adjacencies[i])
+				})
+			}
+
+			stack = append(stack, adjacencies...)
 		}
 	}
 
 	return nil
 }
+
+// dfsSeq implements Graph.DFSSeq on top of DFS.
+func dfsSeq[K comparable, T any](g Graph[K, T], start K) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		_ = DFS(g, start, func(hash K) bool {
+			return !yield(hash)
+		})
+	}
+}
+
+// IterativeDeepeningDFS returns the longest simple path from the start vertex
+// with at most maxDepth edges, e.g. the longest itinerary with a capped number
+// of flights. It runs a depth-limited DFS for each depth from 1 to maxDepth,
+// and stops early once no path of the current depth exists. The successors of
+// a vertex are queried from the store when the search first reaches it, and
+// kept in the order they are visited for the deeper searches. So only the
+// vertices within maxDepth edges of start are held in memory, along with the
+// current path, rather than the whole graph. Stores which can't list the
+// successors of a single vertex fall back to the adjacency map of the graph.
+//
+// Among paths of the same length, the first one found is returned. Like for
+// DFS, the adjacencies of a vertex are visited in random order unless the order
+// is set with the VisitOrder option.
+func IterativeDeepeningDFS[K comparable, T any](g Graph[K, T], start K, maxDepth int, options ...func(*TraversalOptions[K])) ([]K, error) {
+	if maxDepth < 0 {
+		return nil, fmt.Errorf("max depth %d is negative", maxDepth)
+	}
+
+	var opts TraversalOptions[K]
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if _, err := g.Vertex(start); err != nil {
+		return nil, fmt.Errorf("could not find start vertex with hash %v: %w", start, err)
+	}
+
+	successors := successorsOf(g)
+	visitOrder := make(map[K][]K)
+	adjacencies := func(hash K) ([]K, error) {
+		if adjacencies, ok := visitOrder[hash]; ok {
+			return adjacencies, nil
+		}
+
+		adjacencies, err := successors(hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not get successors of vertex %v: %w", hash, err)
+		}
+		if opts.Less != nil {
+			sort.Slice(adjacencies, func(i, j int) bool {
+				return opts.Less(adjacencies[i], adjacencies[j])
+			})
+		}
+
+		visitOrder[hash] = adjacencies
+		return adjacencies, nil
+	}
+
+	path := []K{start}
+	onPath := map[K]bool{start: true}
+
+	// search extends the path until it has depth more edges, and reports
+	// whether it succeeded. On failure, the path is restored.
+	var search func(depth int) (bool, error)
+	search = func(depth int) (bool, error) {
+		if depth == 0 {
+			return true, nil
+		}
+
+		next, err := adjacencies(path[len(path)-1])
+		if err != nil {
+			return false, err
+		}
+
+		for _, adjacency := range next {
+			if onPath[adjacency] {
+				continue
+			}
+
+			path = append(path, adjacency)
+			onPath[adjacency] = true
+			found, err := search(depth - 1)
+			if found || err != nil {
+				return found, err
+			}
+			path = path[:len(path)-1]
+			delete(onPath, adjacency)
+		}
+
+		return false, nil
+	}
+
+	best := []K{start}
+	for depth := 1; depth <= maxDepth; depth++ {
+		// A path of this depth would start with a path of the previous one,
+		// so there's no point in searching any deeper.
+		found, err := search(depth)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		best = append([]K{}, path...)
+
+		path = path[:1]
+		onPath = map[K]bool{start: true}
+	}
+
+	return best, nil
+}
+
+// BFSLayers groups the vertices by their distance from the start vertex as
+// found by a breadth-first search: layer 0 only holds start, layer 1 its
+// adjacencies, layer 2 the adjacencies of those not seen before, and so on.
+// Vertices which can't be reached from start aren't part of any layer. The
+// order of the vertices within a layer is undefined.
+//
+// This is useful for laying out a graph radially around a vertex.
+func BFSLayers[K comparable, T any](g Graph[K, T], start K) ([][]K, error) {
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("could not get adjacency map: %w", err)
+	}
+
+	if _, ok := adjacencyMap[start]; !ok {
+		return nil, fmt.Errorf("could not find start vertex with hash %v", start)
+	}
+
+	return bfsLayers(adjacencyMap, start), nil
+}
+
+// bfsLayers implements BFSLayers on an adjacency map, so searches from many
+// vertices can share it.
+func bfsLayers[K comparable](adjacencyMap map[K]map[K]Edge[K], start K) [][]K {
+	visited := map[K]bool{start: true}
+	layers := [][]K{{start}}
+
+	for {
+		var next []K
+		for _, currentHash := range layers[len(layers)-1] {
+			for adjacency := range adjacencyMap[currentHash] {
+				if visited[adjacency] {
+					continue
+				}
+				visited[adjacency] = true
+				next = append(next, adjacency)
+			}
+		}
+
+		if len(next) == 0 {
+			return layers
+		}
+		layers = append(layers, next)
+	}
+}
+
+// VerticesAtDistance returns the vertices whose shortest path from the source
+// vertex has exactly n edges, i.e. layer n of BFSLayers, in undefined order.
+// In a flight network, these are the airports reachable with exactly n
+// flights, but not fewer. The result is empty if no vertex is that far away.
+func VerticesAtDistance[K comparable, T any](g Graph[K, T], source K, n int) ([]K, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("distance %d is negative", n)
+	}
+
+	layers, err := BFSLayers(g, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if n >= len(layers) {
+		return []K{}, nil
+	}
+
+	return layers[n], nil
+}
+
+// SpanningTree returns a new graph with the tree edges of a breadth-first or
+// depth-first search from the start vertex, i.e. the edges by which each
+// vertex was discovered. It only contains the vertices reachable from start,
+// along with their attributes, and is directed if the graph is. The edges keep
+// their properties.
+//
+// This illustrates how a search found its way through the graph. Like for
+// DFS, the adjacencies of a vertex are visited in random order, so the tree
+// isn't unique unless the order is set with the VisitOrder option.
+func SpanningTree[K comparable, T any](g Graph[K, T], start K, breadthFirst bool, options ...func(*TraversalOptions[K])) (Graph[K, T], error) {
+	var opts TraversalOptions[K]
+	for _, option := range options {
+		option(&opts)
+	}
+
+	adjacencyMap, err := g.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("could not get adjacency map: %w", err)
+	}
+
+	if _, ok := adjacencyMap[start]; !ok {
+		return nil, fmt.Errorf("could not find start vertex with hash %v", start)
+	}
+
+	tree, err := newEmptyLike(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pending edges are taken from the front for a BFS and from the back
+	// for a DFS. The edge leading to start is a placeholder.
+	pending := []Edge[K]{{Target: start}}
+	var order []K
+	var treeEdges []Edge[K]
+	visited := make(map[K]bool)
+
+	for len(pending) > 0 {
+		var edge Edge[K]
+		if breadthFirst {
+			edge, pending = pending[0], pending[1:]
+		} else {
+			edge, pending = pending[len(pending)-1], pending[:len(pending)-1]
+		}
+
+		if visited[edge.Target] {
+			continue
+		}
+		visited[edge.Target] = true
+		order = append(order, edge.Target)
+		if len(order) > 1 {
+			treeEdges = append(treeEdges, edge)
+		}
+
+		edges := make([]Edge[K], 0, len(adjacencyMap[edge.Target]))
+		for _, adjacency := range adjacencyMap[edge.Target] {
+			edges = append(edges, adjacency)
+		}
+
+		if opts.Less != nil {
+			sort.Slice(edges, func(i, j int) bool {
+				// A DFS takes the last pushed edge first, so push them
+				// from the greatest to the least.
+				if breadthFirst {
+					return opts.Less(edges[i].Target, edges[j].Target)
+				}
+				return opts.Less(edges[j].Target, edges[i].Target)
+			})
+		}
+
+		pending = append(pending, edges...)
+	}
+
+	if err := addVerticesOf(tree, g, order); err != nil {
+		return nil, err
+	}
+
+	for _, edge := range treeEdges {
+		properties := edge.Properties
+		err := tree.AddEdge(edge.Source, edge.Target, func(p *EdgeProperties) {
+			p.Weight = properties.Weight
+			for key, value := range properties.Attributes {
+				EdgeAttribute(key, value)(p)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add edge from %v to %v: %w", edge.Source, edge.Target, err)
+		}
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,400 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestDFSVisitOrder(t *testing.T) {
+	edges := [][2]string{{"SFO", "EWR"}, {"SFO", "ATL"}, {"SFO", "IND"}, {"ATL", "GSO"}, {"ATL", "DEN"}, {"IND", "BOS"}}
+
+	tests := []struct {
+		name string
+		less func(a, b string) bool
+	}{
+		{
+			name: "ascending",
+			less: func(a, b string) bool { return a < b },
+		},
+		{
+			name: "descending",
+			less: func(a, b string) bool { return a > b },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Map iteration order differs between runs, so traverse a few
+			// times to make sure the order is stable.
+			var firstOrder []string
+			for i := 0; i < 20; i++ {
+				g := newStringGraph(t, edges, Directed())
+
+				var order []string
+				err := DFS(g, "SFO", func(value string) bool {
+					order = append(order, value)
+					return false
+				}, VisitOrder(test.less))
+				assert.NoError(t, err)
+
+				if i == 0 {
+					firstOrder = order
+					assertVisitOrder(t, edges, "SFO", test.less, order)
+				}
+				assert.Equal(t, firstOrder, order)
+			}
+		})
+	}
+}
+
+// assertVisitOrder checks that order is a depth-first visit of the tree given
+// by edges: every vertex is visited once, after its parent, and the children
+// of a vertex are visited in the order given by less.
+func assertVisitOrder(t *testing.T, edges [][2]string, start string, less func(a, b string) bool, order []string) {
+	t.Helper()
+
+	position := make(map[string]int, len(order))
+	for i, value := range order {
+		_, seen := position[value]
+		assert.False(t, seen, "%s visited twice", value)
+		position[value] = i
+	}
+	assert.Len(t, position, len(edges)+1)
+	assert.Equal(t, 0, position[start])
+
+	children := make(map[string][]string)
+	for _, edge := range edges {
+		assert.Less(t, position[edge[0]], position[edge[1]], "%s visited before %s", edge[1], edge[0])
+		children[edge[0]] = append(children[edge[0]], edge[1])
+	}
+	for parent, values := range children {
+		sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+		for i := 1; i < len(values); i++ {
+			assert.Less(t, position[values[i-1]], position[values[i]], "children of %s visited out of order", parent)
+		}
+	}
+}
+
+func TestDFSSeq(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "GSO"}, {"GSO", "IND"}, {"IND", "EWR"}}
+
+	tests := []struct {
+		name      string
+		options   []func(*Traits)
+		stopAt    string
+		wantOrder []string
+	}{
+		{
+			name:      "directed",
+			options:   []func(*Traits){Directed()},
+			wantOrder: []string{"SFO", "ATL", "GSO", "IND", "EWR"},
+		},
+		{
+			name:      "directed with break",
+			options:   []func(*Traits){Directed()},
+			stopAt:    "GSO",
+			wantOrder: []string{"SFO", "ATL", "GSO"},
+		},
+		{
+			name:      "undirected with break",
+			stopAt:    "ATL",
+			wantOrder: []string{"SFO", "ATL"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, edges, test.options...)
+
+			var order []string
+			for hash := range g.DFSSeq("SFO") {
+				order = append(order, hash)
+				if hash == test.stopAt {
+					break
+				}
+			}
+			assert.Equal(t, test.wantOrder, order)
+		})
+	}
+
+	g := newStringGraph(t, edges, Directed())
+	for range g.DFSSeq("LAX") {
+		t.Fatal("unknown start vertex yielded a vertex")
+	}
+}
+
+func TestIterativeDeepeningDFS(t *testing.T) {
+	ascending := VisitOrder(func(a, b string) bool { return a < b })
+
+	// On a chain, plain DFS visits the vertices along the only path, so both
+	// searches have to agree within the depth limit.
+	chain := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}}, Directed())
+	var order []string
+	assert.NoError(t, DFS(chain, "SFO", func(value string) bool {
+		order = append(order, value)
+		return false
+	}, ascending))
+
+	for maxDepth := 0; maxDepth <= len(order)+1; maxDepth++ {
+		path, err := IterativeDeepeningDFS(chain, "SFO", maxDepth, ascending)
+		assert.NoError(t, err)
+		assert.Equal(t, order[:min(maxDepth+1, len(order))], path, "max depth %d", maxDepth)
+	}
+
+	tests := []struct {
+		name     string
+		edges    [][2]string
+		options  []func(*Traits)
+		maxDepth int
+		wantPath []string
+	}{
+		{
+			name:     "Deeper branch",
+			edges:    [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}},
+			options:  []func(*Traits){Directed()},
+			maxDepth: 5,
+			wantPath: []string{"SFO", "EWR", "IND", "GSO"},
+		},
+		{
+			name:     "Deeper branch cut by the limit",
+			edges:    [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}},
+			options:  []func(*Traits){Directed()},
+			maxDepth: 1,
+			wantPath: []string{"SFO", "ATL"},
+		},
+		{
+			name:     "Cycle isn't followed back",
+			edges:    [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "SFO"}},
+			options:  []func(*Traits){Directed()},
+			maxDepth: 5,
+			wantPath: []string{"SFO", "ATL", "EWR"},
+		},
+		{
+			name:     "Undirected",
+			edges:    [][2]string{{"ATL", "SFO"}, {"SFO", "EWR"}, {"EWR", "IND"}},
+			maxDepth: 5,
+			wantPath: []string{"SFO", "EWR", "IND"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			path, err := IterativeDeepeningDFS(g, "SFO", test.maxDepth, ascending)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantPath, path)
+		})
+	}
+
+	_, err := IterativeDeepeningDFS(chain, "SFO", -1)
+	assert.Error(t, err)
+
+	_, err = IterativeDeepeningDFS(chain, "BOS", 2)
+	assert.Error(t, err)
+}
+
+// successorCountingStore counts how often the successors of each vertex are
+// queried, and how often all edges are listed.
+type successorCountingStore struct {
+	*memoryStore[string, string]
+	successors map[string]int
+	lists      int
+}
+
+func (s *successorCountingStore) Successors(hash string) ([]string, error) {
+	s.successors[hash]++
+	return s.memoryStore.Successors(hash)
+}
+
+func (s *successorCountingStore) ListEdges() ([]Edge[string], error) {
+	s.lists++
+	return s.memoryStore.ListEdges()
+}
+
+func TestIterativeDeepeningDFSSuccessors(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"ATL", "EWR"}, {"EWR", "IND"}, {"IND", "GSO"}, {"ORD", "SFO"}}
+	ascending := VisitOrder(func(a, b string) bool { return a < b })
+
+	store := &successorCountingStore{
+		memoryStore: NewMemoryStore[string, string]().(*memoryStore[string, string]),
+		successors:  make(map[string]int),
+	}
+	g := NewWithStore(StringHash, store, Directed())
+	for _, edge := range edges {
+		for _, airport := range edge {
+			if _, err := g.Vertex(airport); err != nil {
+				assert.NoError(t, g.AddVertex(airport))
+			}
+		}
+		assert.NoError(t, g.AddEdge(edge[0], edge[1]))
+	}
+	store.lists = 0
+
+	path, err := IterativeDeepeningDFS(g, "SFO", 5, ascending)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "ATL", "EWR", "IND", "GSO"}, path)
+
+	// The successors are queried once per reached vertex, and the edges are
+	// never listed. ORD can't be reached from SFO.
+	assert.Equal(t, 0, store.lists)
+	assert.Equal(t, map[string]int{"SFO": 1, "ATL": 1, "EWR": 1, "IND": 1, "GSO": 1}, store.successors)
+
+	// Stores which can't list the successors of a vertex find the same path
+	// through the adjacency map, which is built once.
+	listing := &listCountingStore{Store: NewMemoryStore[string, string]()}
+	fallback := NewWithStore(StringHash, NewRetryingStore[string, string](listing), Directed())
+	for _, airport := range []string{"SFO", "ATL", "EWR", "IND", "GSO", "ORD"} {
+		assert.NoError(t, fallback.AddVertex(airport))
+	}
+	for _, edge := range edges {
+		assert.NoError(t, fallback.AddEdge(edge[0], edge[1]))
+	}
+	listing.lists = 0
+
+	path, err = IterativeDeepeningDFS(fallback, "SFO", 5, ascending)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SFO", "ATL", "EWR", "IND", "GSO"}, path)
+	assert.Equal(t, 1, listing.lists)
+}
+
+func TestBFSLayers(t *testing.T) {
+	tests := []struct {
+		name       string
+		edges      [][2]string
+		options    []func(*Traits)
+		wantLayers [][]string
+	}{
+		{
+			name:       "directed",
+			edges:      [][2]string{{"SFO", "ATL"}, {"SFO", "DEN"}, {"ATL", "EWR"}, {"DEN", "EWR"}, {"EWR", "BOS"}, {"IND", "SFO"}},
+			options:    []func(*Traits){Directed()},
+			wantLayers: [][]string{{"SFO"}, {"ATL", "DEN"}, {"EWR"}, {"BOS"}},
+		},
+		{
+			name:       "undirected",
+			edges:      [][2]string{{"SFO", "ATL"}, {"SFO", "DEN"}, {"ATL", "EWR"}, {"DEN", "EWR"}, {"EWR", "BOS"}, {"IND", "SFO"}},
+			wantLayers: [][]string{{"SFO"}, {"ATL", "DEN", "IND"}, {"EWR"}, {"BOS"}},
+		},
+		{
+			name:       "single vertex",
+			edges:      [][2]string{{"ATL", "EWR"}, {"SFO", "SFO"}},
+			options:    []func(*Traits){Directed()},
+			wantLayers: [][]string{{"SFO"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, test.edges, test.options...)
+
+			layers, err := BFSLayers(g, "SFO")
+			assert.NoError(t, err)
+			if assert.Len(t, layers, len(test.wantLayers)) {
+				for i := range test.wantLayers {
+					assert.ElementsMatch(t, test.wantLayers[i], layers[i], "layer %d", i)
+				}
+			}
+		})
+	}
+
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}}, Directed())
+	_, err := BFSLayers(g, "LAX")
+	assert.Error(t, err)
+}
+
+func TestVerticesAtDistance(t *testing.T) {
+	// EWR is one flight away through SFO-EWR and two through ATL, so it only
+	// counts at distance 1.
+	g := newStringGraph(t, [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"ATL", "EWR"}, {"ATL", "DEN"}, {"EWR", "BOS"}, {"IND", "SFO"}}, Directed())
+
+	tests := []struct {
+		name         string
+		distance     int
+		wantVertices []string
+	}{
+		{
+			name:         "source",
+			distance:     0,
+			wantVertices: []string{"SFO"},
+		},
+		{
+			name:         "one flight",
+			distance:     1,
+			wantVertices: []string{"ATL", "EWR"},
+		},
+		{
+			name:         "two flights",
+			distance:     2,
+			wantVertices: []string{"DEN", "BOS"},
+		},
+		{
+			name:     "too far",
+			distance: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vertices, err := VerticesAtDistance(g, "SFO", test.distance)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantVertices, vertices)
+		})
+	}
+
+	_, err := VerticesAtDistance(g, "SFO", -1)
+	assert.Error(t, err)
+
+	_, err = VerticesAtDistance(g, "LAX", 1)
+	assert.Error(t, err)
+}
+
+func TestSpanningTree(t *testing.T) {
+	edges := [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"SFO", "EWR"}, {"EWR", "IND"}, {"LAX", "SFO"}}
+	alphabetical := VisitOrder(func(a, b string) bool {
+		return a < b
+	})
+
+	tests := []struct {
+		name         string
+		breadthFirst bool
+		options      []func(*Traits)
+		wantEdges    [][2]string
+	}{
+		{
+			name:         "BFS",
+			breadthFirst: true,
+			options:      []func(*Traits){Directed()},
+			wantEdges:    [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"EWR", "IND"}},
+		},
+		{
+			name:      "DFS",
+			options:   []func(*Traits){Directed()},
+			wantEdges: [][2]string{{"SFO", "ATL"}, {"ATL", "EWR"}, {"EWR", "IND"}},
+		},
+		{
+			name:         "Undirected BFS",
+			breadthFirst: true,
+			wantEdges:    [][2]string{{"SFO", "ATL"}, {"SFO", "EWR"}, {"EWR", "IND"}, {"SFO", "LAX"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newStringGraph(t, edges, test.options...)
+
+			tree, err := SpanningTree(g, "SFO", test.breadthFirst, alphabetical)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantEdges, edgePairs(t, tree))
+
+			// A tree over V vertices has V-1 edges.
+			order, err := tree.Order()
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.wantEdges)+1, order)
+		})
+	}
+
+	// LAX can't be reached by the directed edges.
+	tree, err := SpanningTree(newStringGraph(t, edges, Directed()), "SFO", true)
+	assert.NoError(t, err)
+	_, err = tree.Vertex("LAX")
+	assert.ErrorIs(t, err, ErrVertexNotFound)
+
+	_, err = SpanningTree(newStringGraph(t, edges), "ORD", false)
+	assert.Error(t, err)
+}
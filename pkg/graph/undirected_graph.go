@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// undirected stores each edge once in the store, in the orientation it was
+// added with. Edge lookups and removals check both orientations, so (A, B)
+// and (B, A) always refer to the same edge.
+type undirected[K comparable, T any] struct {
+	hash   Hash[K, T]
+	traits *Traits
+	store  Store[K, T]
+}
+
+func newUndirected[K comparable, T any](hash Hash[K, T], traits *Traits, store Store[K, T]) *undirected[K, T] {
+	return &undirected[K, T]{
+		hash:   hash,
+		traits: traits,
+		store:  store,
+	}
+}
+
+func (u *undirected[K, T]) Traits() *Traits {
+	return u.traits
+}
+
+func (u *undirected[K, T]) Store() Store[K, T] {
+	return u.store
+}
+
+func (u *undirected[K, T]) AddVertex(value T) error {
+	hash := u.hash(value)
+	return addVertex(u.store, u.traits, hash, value)
+}
+
+func (u *undirected[K, T]) Vertex(hash K) (T, error) {
+	vertex, err := u.store.Vertex(hash)
+	return vertex, err
+}
+
+func (u *undirected[K, T]) RemoveVertex(hash K) error {
+	return u.store.RemoveVertex(hash)
+}
+
+func (u *undirected[K, T]) SetVertexAttribute(hash K, key, value string) error {
+	return setVertexAttribute(u.store, hash, key, value)
+}
+
+func (u *undirected[K, T]) VertexAttributes(hash K) (map[string]string, error) {
+	properties, err := u.store.VertexProperties(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return properties.Attributes, nil
+}
+
+func (u *undirected[K, T]) AddEdge(sourceHash, targetHash K, options ...func(*EdgeProperties)) (err error) {
+	// The limit is checked first, so no vertices are auto-created for an edge
+	// which can't be added.
+	err = checkEdgeLimit(u.store, u.traits, func() bool {
+		_, err := u.storedEdge(sourceHash, targetHash)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Vertices created for the edge are removed again if it's rejected.
+	var created []K
+	defer func() {
+		if err != nil {
+			removeVertices(u.store, created)
+		}
+	}()
+
+	for _, end := range []struct {
+		name string
+		hash K
+	}{{"source", sourceHash}, {"target", targetHash}} {
+		ok, err := vertexForEdge(u.store, u.traits, u.hash, end.hash)
+		if err != nil {
+			return fmt.Errorf("%s vertex %v: %w", end.name, end.hash, err)
+		}
+		if ok {
+			created = append(created, end.hash)
+		}
+	}
+
+	if _, err := u.storedEdge(sourceHash, targetHash); !errors.Is(err, ErrEdgeNotFound) {
+		return ErrEdgeAlreadyExists
+	}
+
+	// If the user opted in to preventing cycles, run a cycle check. The store
+	// fast path only follows ingoing edges, so always use the generic check.
+	if u.traits.PreventCycles {
+		createsCycle, err := CreatesCycle[K, T](u, sourceHash, targetHash)
+		if err != nil {
+			return fmt.Errorf("check for cycles: %w", err)
+		}
+		if createsCycle {
+			return ErrEdgeCreatesCycle
+		}
+	}
+
+	edge := Edge[K]{
+		Source: sourceHash,
+		Target: targetHash,
+	}
+
+	for _, option := range options {
+		option(&edge.Properties)
+	}
+
+	if err := checkEdgeID(u.store, edge); err != nil {
+		return err
+	}
+
+	return u.store.AddEdge(sourceHash, targetHash, edge)
+}
+
+func (u *undirected[K, T]) Edge(sourceHash, targetHash K) (Edge[T], error) {
+	edge, err := u.storedEdge(sourceHash, targetHash)
+	if err != nil {
+		return Edge[T]{}, err
+	}
+
+	sourceVertex, err := u.store.Vertex(sourceHash)
+	if err != nil {
+		return Edge[T]{}, err
+	}
+
+	targetVertex, err := u.store.Vertex(targetHash)
+	if err != nil {
+		return Edge[T]{}, err
+	}
+
+	return Edge[T]{
+		Source:     sourceVertex,
+		Target:     targetVertex,
+		Properties: edge.Properties,
+	}, nil
+}
+
+func (u *undirected[K, T]) Edges() ([]Edge[K], error) {
+	return u.store.ListEdges()
+}
+
+func (u *undirected[K, T]) EdgeList() ([]Edge[T], error) {
+	return edgeList(u.store)
+}
+
+func (u *undirected[K, T]) CollapseParallelEdges(keep func(a, b EdgeProperties) EdgeProperties) error {
+	return collapseParallelEdges(u.store, false, keep)
+}
+
+func (u *undirected[K, T]) RemoveEdge(source, target K) error {
+	edge, err := u.storedEdge(source, target)
+	if err != nil {
+		return err
+	}
+
+	if err := u.store.RemoveEdge(edge.Source, edge.Target); err != nil {
+		return fmt.Errorf("failed to remove edge from %v to %v: %w", source, target, err)
+	}
+
+	return nil
+}
+
+func (u *undirected[K, T]) AdjacencyMap() (map[K]map[K]Edge[K], error) {
+	vertices, err := u.store.ListVertices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices: %w", err)
+	}
+
+	edges, err := u.store.ListEdges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	m := make(map[K]map[K]Edge[K], len(vertices))
+
+	for _, vertex := range vertices {
+		m[vertex] = make(map[K]Edge[K])
+	}
+
+	for _, edge := range edges {
+		m[edge.Source][edge.Target] = edge
+		m[edge.Target][edge.Source] = Edge[K]{
+			Source:     edge.Target,
+			Target:     edge.Source,
+			Properties: edge.Properties,
+		}
+	}
+
+	return m, nil
+}
+
+func (u *undirected[K, T]) PredecessorMap() (map[K]map[K]Edge[K], error) {
+	return u.AdjacencyMap()
+}
+
+func (u *undirected[K, T]) Sources() ([]K, error) {
+	predecessorMap, err := u.PredecessorMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get predecessor map: %w", err)
+	}
+
+	return verticesWithoutEdges(predecessorMap), nil
+}
+
+func (u *undirected[K, T]) Sinks() ([]K, error) {
+	adjacencyMap, err := u.AdjacencyMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjacency map: %w", err)
+	}
+
+	return verticesWithoutEdges(adjacencyMap), nil
+}
+
+func (u *undirected[K, T]) DFSSeq(start K) iter.Seq[K] {
+	return dfsSeq[K, T](u, start)
+}
+
+func (u *undirected[K, T]) Order() (int, error) {
+	return u.store.VertexCount()
+}
+
+func (u *undirected[K, T]) Size() (int, error) {
+	edges, err := u.store.ListEdges()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list edges: %w", err)
+	}
+
+	return len(edges), nil
+}
+
+// storedEdge returns the edge joining the two vertices in the orientation it
+// has been stored with, or ErrEdgeNotFound if there is no such edge.
+func (u *undirected[K, T]) storedEdge(a, b K) (Edge[K], error) {
+	edge, err := u.store.Edge(a, b)
+	if err == nil || !errors.Is(err, ErrEdgeNotFound) {
+		return edge, err
+	}
+
+	return u.store.Edge(b, a)
+}
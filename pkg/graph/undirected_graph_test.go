@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUndirectedEdgeLookup(t *testing.T) {
+	g := New(StringHash)
+	for _, v := range []string{"A", "B", "C"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("A", "B"))
+
+	edge, err := g.Edge("B", "A")
+	assert.NoError(t, err)
+	assert.Equal(t, Edge[string]{Source: "B", Target: "A"}, edge)
+
+	_, err = g.Edge("A", "B")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, g.AddEdge("B", "A"), ErrEdgeAlreadyExists)
+
+	_, err = g.Edge("A", "C")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+
+	size, err := g.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	adjacencyMap, err := g.AdjacencyMap()
+	assert.NoError(t, err)
+	assert.Contains(t, adjacencyMap["A"], "B")
+	assert.Contains(t, adjacencyMap["B"], "A")
+	assert.Empty(t, adjacencyMap["C"])
+
+	assert.NoError(t, g.RemoveEdge("B", "A"))
+	_, err = g.Edge("A", "B")
+	assert.ErrorIs(t, err, ErrEdgeNotFound)
+}
+
+func TestUndirectedPreventCycles(t *testing.T) {
+	g := New(StringHash, PreventCycles())
+	for _, v := range []string{"A", "B", "C"} {
+		assert.NoError(t, g.AddVertex(v))
+	}
+	assert.NoError(t, g.AddEdge("A", "B"))
+	assert.NoError(t, g.AddEdge("C", "B"))
+	assert.ErrorIs(t, g.AddEdge("A", "C"), ErrEdgeCreatesCycle)
+}
+
+func TestNewDirectedness(t *testing.T) {
+	assert.IsType(t, &undirected[string, string]{}, New(StringHash))
+	assert.IsType(t, &directed[string, string]{}, New(StringHash, Directed()))
+}
+
+func TestUndirectedAutoCreateVertices(t *testing.T) {
+	g := New(IntHash, AutoCreateVertices())
+	assert.NoError(t, g.AddEdge(1, 2))
+
+	_, err := g.Edge(2, 1)
+	assert.NoError(t, err)
+
+	order, err := g.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, order)
+}
+
+func TestUndirectedCollapseParallelEdges(t *testing.T) {
+	store := newMultiStore()
+	assert.NoError(t, store.AddVertex("SFO", "SFO"))
+	assert.NoError(t, store.AddVertex("ATL", "ATL"))
+	assert.NoError(t, store.AddEdge("SFO", "ATL", Edge[string]{Source: "SFO", Target: "ATL", Properties: EdgeProperties{Weight: 300}}))
+	assert.NoError(t, store.AddEdge("ATL", "SFO", Edge[string]{Source: "ATL", Target: "SFO", Properties: EdgeProperties{Weight: 250}}))
+	g := NewWithStore(StringHash, store, Weighted())
+
+	err := g.CollapseParallelEdges(func(a, b EdgeProperties) EdgeProperties {
+		if b.Weight < a.Weight {
+			return b
+		}
+		return a
+	})
+	assert.NoError(t, err)
+
+	edges, err := g.Edges()
+	assert.NoError(t, err)
+	assert.Equal(t, []Edge[string]{{Source: "SFO", Target: "ATL", Properties: EdgeProperties{Weight: 250}}}, edges)
+}
+
+func TestUndirectedEdgeByID(t *testing.T) {
+	g := New(StringHash, AutoCreateVertices())
+	assert.NoError(t, g.AddEdge("SFO", "ATL", EdgeWithID("DL1234")))
+
+	edge, err := g.Edge("ATL", "SFO")
+	assert.NoError(t, err)
+	assert.Equal(t, "DL1234", edge.Properties.ID)
+
+	byID, err := EdgeByID(g, "DL1234")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"SFO", "ATL"}, []string{byID.Source, byID.Target})
+}
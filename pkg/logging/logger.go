@@ -2,33 +2,51 @@ package logging
 
 import (
 	"artemb/flights-path/pkg/config"
+	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"os"
 )
 
-func NewLogger(config *config.Logging) (*zap.Logger, error) {
+var encoderConfig = zapcore.EncoderConfig{
+	TimeKey:        "init_timestamp",
+	LevelKey:       "log_level",
+	NameKey:        "log_name",
+	CallerKey:      "caller",
+	MessageKey:     "msg",
+	StacktraceKey:  "stacktrace",
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	EncodeTime:     zapcore.ISO8601TimeEncoder,
+	EncodeDuration: zapcore.StringDurationEncoder,
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+}
+
+// NewLogger builds the logger with the configured level, encoding and output.
+// The output is "stdout", "stderr" or the path of a file the logs are appended
+// to.
+func NewLogger(cfg *config.Logging) (*zap.Logger, error) {
 	level := zap.NewAtomicLevel()
-	err := level.UnmarshalText([]byte(config.Level))
+	err := level.UnmarshalText([]byte(cfg.Level))
 	if err != nil {
 		return nil, err
 	}
 
-	cw := zapcore.Lock(os.Stdout)
-	je := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
-		TimeKey:        "init_timestamp",
-		LevelKey:       "log_level",
-		NameKey:        "log_name",
-		CallerKey:      "caller",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     zapcore.ISO8601TimeEncoder,
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
-	})
-	zapCore := zapcore.NewCore(je, cw, level)
+	encoder, err := newEncoder(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	output := cfg.Output
+	if output == "" {
+		output = config.OutputStdout
+	}
+
+	sink, _, err := zap.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("can't open log output %s: %w", output, err)
+	}
+
+	zapCore := zapcore.NewCore(encoder, zapcore.Lock(sink), level)
 
 	logger := zap.New(
 		zapCore,
@@ -40,3 +58,14 @@ func NewLogger(config *config.Logging) (*zap.Logger, error) {
 
 	return logger, nil
 }
+
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	switch encoding {
+	case config.EncodingJSON:
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case config.EncodingConsole, "":
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+}
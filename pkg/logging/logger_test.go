@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"artemb/flights-path/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogger(t *testing.T) {
+	for _, encoding := range []string{config.EncodingJSON, config.EncodingConsole} {
+		for _, output := range []string{config.OutputStdout, config.OutputStderr, "file"} {
+			t.Run(encoding+" to "+output, func(t *testing.T) {
+				file := filepath.Join(t.TempDir(), "api.log")
+				if output == "file" {
+					output = file
+				}
+
+				logger, err := NewLogger(&config.Logging{Level: "info", Encoding: encoding, Output: output})
+				assert.NoError(t, err)
+				assert.NotNil(t, logger)
+
+				if output != file {
+					return
+				}
+
+				logger.Info("started")
+				assert.NoError(t, logger.Sync())
+
+				data, err := os.ReadFile(file)
+				assert.NoError(t, err)
+				assert.Equal(t, encoding == config.EncodingJSON, strings.HasPrefix(string(data), "{"))
+				assert.Contains(t, string(data), "started")
+			})
+		}
+	}
+}
+
+func TestNewLoggerInvalid(t *testing.T) {
+	_, err := NewLogger(&config.Logging{Level: "info", Encoding: "xml", Output: config.OutputStdout})
+	assert.Error(t, err)
+
+	_, err = NewLogger(&config.Logging{Level: "loud", Encoding: config.EncodingJSON, Output: config.OutputStdout})
+	assert.Error(t, err)
+
+	_, err = NewLogger(&config.Logging{Level: "info", Encoding: config.EncodingJSON, Output: filepath.Join(t.TempDir(), "missing", "api.log")})
+	assert.Error(t, err)
+}
+
+func TestNewEncoder(t *testing.T) {
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "started"}
+
+	tests := []struct {
+		encoding   string
+		wantPrefix string
+	}{
+		{encoding: config.EncodingJSON, wantPrefix: "{"},
+		{encoding: config.EncodingConsole, wantPrefix: entry.Time.Format("2006-01-02")},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			encoder, err := newEncoder(test.encoding)
+			assert.NoError(t, err)
+
+			buf, err := encoder.EncodeEntry(entry, nil)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(buf.String(), test.wantPrefix), buf.String())
+		})
+	}
+}